@@ -21,11 +21,22 @@ type userFlags struct {
 	snakeCase     bool
 	enableTracing bool
 	force         bool
+	grpcHTTP      bool
+	protoPath     string
+	webhook       bool
 
 	args []string
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "spec" {
+		if err := runSpec(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var flags userFlags
 	flag.StringVar(&flags.outDir, "out", ".", "output directory")
 	flag.BoolVar(&flags.flatLayout, "flat", true, "whether to use flat layout")
@@ -33,9 +44,13 @@ func main() {
 	flag.BoolVar(&flags.snakeCase, "snake", true, "whether to use snake-case for default names")
 	flag.BoolVar(&flags.enableTracing, "trace", false, "whether to enable tracing")
 	flag.BoolVar(&flags.force, "force", false, "whether to remove previously generated files before generating new ones")
+	flag.BoolVar(&flags.grpcHTTP, "grpchttp", false, "whether to add google.api.http options (converted from HTTP annotations) to the .proto file")
+	flag.BoolVar(&flags.webhook, "webhook", false, "whether to generate the webhook handler for events")
+	flag.StringVar(&flags.protoPath, "proto_path", "", "comma-separated directories in which protoc searches for imports (e.g. google/api/annotations.proto)")
 
 	flag.Usage = func() {
 		fmt.Println(`kungen [flags] source-file interface-name`)
+		fmt.Println(`kungen spec [flags] source-file interface-name`)
 		flag.PrintDefaults()
 	}
 
@@ -75,6 +90,9 @@ func run(flags userFlags) error {
 		SnakeCase:     flags.snakeCase,
 		Formatted:     flags.formatted,
 		EnableTracing: flags.enableTracing,
+		GRPCHTTPRules: flags.grpcHTTP,
+		ProtoPaths:    splitList(flags.protoPath),
+		EventWebhook:  flags.webhook,
 	})
 	files, err := generator.Generate(srcFilename, interfaceName)
 	if err != nil {
@@ -90,6 +108,16 @@ func run(flags userFlags) error {
 	return nil
 }
 
+// splitList splits a comma-separated list into non-empty items.
+func splitList(s string) (items []string) {
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return
+}
+
 // removeGeneratedFiles recursively remove all files generated by kun from dir.
 func removeGeneratedFiles(dir string) error {
 	isGenerated := func(path string) (bool, error) {
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/RussellLuo/kun/gen"
+)
+
+// runSpec runs the spec command, which prints the specification of the
+// interface (e.g. the next fire times of the cron jobs).
+func runSpec(args []string) error {
+	fs := flag.NewFlagSet("spec", flag.ExitOnError)
+	flatLayout := fs.Bool("flat", true, "whether to use flat layout")
+	snakeCase := fs.Bool("snake", true, "whether to use snake-case for default names")
+	n := fs.Int("n", 5, "number of the next fire times to print for each cron job")
+
+	fs.Usage = func() {
+		fmt.Println(`kungen spec [flags] source-file interface-name`)
+		fs.PrintDefaults()
+	}
+
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("need 2 arguments")
+	}
+
+	srcFilename, interfaceName := fs.Arg(0), fs.Arg(1)
+
+	srcFilename, err := filepath.Abs(srcFilename)
+	if err != nil {
+		return err
+	}
+
+	generator := gen.New(&gen.Options{
+		FlatLayout: *flatLayout,
+		SnakeCase:  *snakeCase,
+	})
+	return generator.Spec(os.Stdout, srcFilename, interfaceName, time.Now(), *n)
+}
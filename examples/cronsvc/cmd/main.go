@@ -8,20 +8,23 @@ import (
 	"time"
 
 	"github.com/RussellLuo/kun/examples/cronsvc"
+	"github.com/RussellLuo/kun/pkg/cronjob"
 	"github.com/RussellLuo/micron"
 )
 
 func main() {
-	c := micron.New(
+	c, err := cronjob.NewScheduler(
+		// Use cronjob.NewSQLLocker(db) instead to run the jobs in multiple replicas.
 		micron.NewSemaphoreLocker(),
-		&micron.Options{
-			Timezone: "Asia/Shanghai",
-			LockTTL:  2 * time.Second, // Assume the maximal clock error is 2s.
-			ErrHandler: func(err error) {
-				log.Printf("err: %v", err)
-			},
-		},
+		cronjob.DefaultTimezone("Asia/Shanghai"),
+		cronjob.LockTTL(2*time.Second), // Assume the maximal clock error is 2s.
+		cronjob.ErrorHandler(func(err error) {
+			log.Printf("err: %v", err)
+		}),
 	)
+	if err != nil {
+		log.Fatalf("err: %v", err)
+	}
 
 	jobs := cronsvc.NewCronJobs(&cronsvc.Handler{})
 	if err := c.AddJob(jobs...); err != nil {
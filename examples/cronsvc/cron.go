@@ -4,6 +4,11 @@
 package cronsvc
 
 import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/cronjob"
 	"github.com/RussellLuo/micron"
 )
 
@@ -14,5 +19,46 @@ func NewCronJobs(svc Service) []micron.Job {
 			Expr:    "@every 5s",
 			Handler: svc.SendEmail,
 		},
+		cronjob.Wrap(micron.Job{
+			Name:    "send_report",
+			Expr:    "0 0 9 * * * *",
+			Handler: svc.SendReport,
+		}, cronjob.Timezone("Asia/Shanghai"), cronjob.Jitter(1*time.Minute), cronjob.Overlap(cronjob.OverlapSkip)),
+		{
+			Name: "sync_data_eu",
+			Expr: "@every 10s",
+			Handler: func(ctx context.Context) error {
+				ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+				defer cancel()
+
+				var args struct {
+					Region    string `json:"region"`
+					BatchSize int    `json:"batchSize"`
+				}
+				if err := json.Unmarshal([]byte(`{"region": "eu", "batchSize": 100}`), &args); err != nil {
+					return err
+				}
+
+				return svc.SyncData(ctx, args.Region, args.BatchSize)
+			},
+		},
+		{
+			Name: "sync_data_us",
+			Expr: "@every 10s",
+			Handler: func(ctx context.Context) error {
+				ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+				defer cancel()
+
+				var args struct {
+					Region    string `json:"region"`
+					BatchSize int    `json:"batchSize"`
+				}
+				if err := json.Unmarshal([]byte(`{"region": "us", "batchSize": 50}`), &args); err != nil {
+					return err
+				}
+
+				return svc.SyncData(ctx, args.Region, args.BatchSize)
+			},
+		},
 	}
 }
@@ -6,6 +6,8 @@ package cronsvc
 import (
 	"context"
 
+	"github.com/RussellLuo/kun/pkg/httpoption"
+	"github.com/RussellLuo/validating/v3"
 	"github.com/go-kit/kit/endpoint"
 )
 
@@ -29,3 +31,61 @@ func MakeEndpointOfSendEmail(s Service) endpoint.Endpoint {
 		}, nil
 	}
 }
+
+type SendReportResponse struct {
+	Err error `json:"-"`
+}
+
+func (r *SendReportResponse) Body() interface{} { return r }
+
+// Failed implements endpoint.Failer.
+func (r *SendReportResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfSendReport creates the endpoint for s.SendReport.
+func MakeEndpointOfSendReport(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		err := s.SendReport(
+			ctx,
+		)
+		return &SendReportResponse{
+			Err: err,
+		}, nil
+	}
+}
+
+type SyncDataRequest struct {
+	Region    string `json:"region"`
+	BatchSize int    `json:"batch_size"`
+}
+
+// ValidateSyncDataRequest creates a validator for SyncDataRequest.
+func ValidateSyncDataRequest(newSchema func(*SyncDataRequest) validating.Schema) httpoption.Validator {
+	return httpoption.FuncValidator(func(value interface{}) error {
+		req := value.(*SyncDataRequest)
+		return httpoption.Validate(newSchema(req))
+	})
+}
+
+type SyncDataResponse struct {
+	Err error `json:"-"`
+}
+
+func (r *SyncDataResponse) Body() interface{} { return r }
+
+// Failed implements endpoint.Failer.
+func (r *SyncDataResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfSyncData creates the endpoint for s.SyncData.
+func MakeEndpointOfSyncData(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*SyncDataRequest)
+		err := s.SyncData(
+			ctx,
+			req.Region,
+			req.BatchSize,
+		)
+		return &SyncDataResponse{
+			Err: err,
+		}, nil
+	}
+}
@@ -11,6 +11,13 @@ import (
 type Service interface {
 	//kun:cron expr='@every 5s'
 	SendEmail(ctx context.Context) error
+
+	//kun:cron expr='0 0 9 * * * *' tz=Asia/Shanghai jitter=1m overlap=skip
+	SendReport(ctx context.Context) error
+
+	//kun:cron name=sync_data_eu expr='@every 10s' args='{"region": "eu", "batchSize": 100}' timeout=3s
+	//kun:cron name=sync_data_us expr='@every 10s' args='{"region": "us", "batchSize": 50}' timeout=3s
+	SyncData(ctx context.Context, region string, batchSize int) error
 }
 
 type Handler struct{}
@@ -19,3 +26,13 @@ func (h *Handler) SendEmail(ctx context.Context) error {
 	log.Println("Sending an email")
 	return nil
 }
+
+func (h *Handler) SendReport(ctx context.Context) error {
+	log.Println("Sending the daily report")
+	return nil
+}
+
+func (h *Handler) SyncData(ctx context.Context, region string, batchSize int) error {
+	log.Printf("Syncing data of region %s (batch size: %d)", region, batchSize)
+	return nil
+}
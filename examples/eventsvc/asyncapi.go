@@ -0,0 +1,47 @@
+// Code generated by kun; DO NOT EDIT.
+// github.com/RussellLuo/kun
+
+package eventsvc
+
+import (
+	"reflect"
+
+	"github.com/RussellLuo/kun/pkg/asyncapi"
+	"github.com/RussellLuo/kun/pkg/oas2"
+)
+
+var (
+	asyncAPIBase = `asyncapi: "` + asyncapi.Version + `"
+info:
+  title: "No Title"
+  version: "0.0.0"
+  description: "Service is used for handling events."
+defaultContentType: "application/json"
+channels:
+  "created":
+    publish:
+      operationId: "EventCreated"
+      message:
+        $ref: "#/components/messages/EventCreated"
+components:
+  messages:
+    EventCreated:
+      name: "created"
+      payload:
+        $ref: "#/components/schemas/EventCreatedPayload"
+`
+)
+
+func getAsyncAPIDefinitions() map[string]oas2.Definition {
+	defs := make(map[string]oas2.Definition)
+
+	oas2.AddDefinition(defs, "EventCreatedPayload", reflect.ValueOf(&EventCreatedRequest{}))
+
+	return defs
+}
+
+// AsyncAPIDoc returns the AsyncAPI document, in YAML, of the events.
+func AsyncAPIDoc() string {
+	defs := getAsyncAPIDefinitions()
+	return asyncAPIBase + asyncapi.GenSchemas(defs)
+}
@@ -5,42 +5,25 @@ import (
 
 	"github.com/RussellLuo/kun/examples/eventsvc"
 	"github.com/RussellLuo/kun/pkg/eventcodec"
-	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/RussellLuo/kun/pkg/eventpubsub/membus"
 )
 
-type event struct {
-	typ  string
-	data []byte
-}
-
-func (e *event) Type() string {
-	return e.typ
-}
-
-func (e *event) Data() interface{} {
-	return e.data
-}
-
-type publisher struct {
-	sub eventpubsub.Handler
-}
-
-func (p *publisher) Publish(ctx context.Context, typ string, data interface{}) error {
-	e := &event{
-		typ:  typ,
-		data: data.([]byte),
-	}
-
-	// For demonstration, we deliver the event e to the subscriber sub directly.
-	return p.sub.Handle(ctx, e)
-}
-
 func main() {
+	bus := membus.New()
 	codecs := eventcodec.NewDefaultCodecs(nil)
+
 	sub := eventsvc.NewEventHandler(&eventsvc.Subscriber{}, codecs)
+	if err := bus.Subscribe("*", sub); err != nil {
+		panic(err)
+	}
 
-	pub := eventsvc.NewEventPublisher(&publisher{sub: sub}, codecs)
+	pub := eventsvc.NewEventPublisher(bus, codecs)
 	if err := pub.EventCreated(context.Background(), 1); err != nil {
 		panic(err)
 	}
+
+	// Wait for the event to be handled.
+	if err := bus.Close(context.Background()); err != nil {
+		panic(err)
+	}
 }
@@ -5,12 +5,14 @@ package eventsvc
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/RussellLuo/kun/pkg/eventcodec"
 	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/RussellLuo/kun/pkg/eventpubsub/webhook"
 )
 
-func NewEventHandler(svc Service, codecs eventcodec.Codecs) eventpubsub.Handler {
+func NewEventHandler(svc Service, codecs eventcodec.Codecs, opts ...eventpubsub.RetryOption) eventpubsub.Handler {
 	var codec eventcodec.Codec
 	handlerSet := eventpubsub.NewHandlerSet()
 
@@ -20,7 +22,13 @@ func NewEventHandler(svc Service, codecs eventcodec.Codecs) eventpubsub.Handler
 		decodeEventCreatedInput(codec),
 	))
 
-	return handlerSet
+	return eventpubsub.WrapHandler(handlerSet, opts...)
+}
+
+// NewWebhookHandler creates an HTTP handler, which receives the events pushed
+// as webhook requests signed by the secret of verifier.
+func NewWebhookHandler(svc Service, codecs eventcodec.Codecs, verifier *webhook.Verifier, opts ...eventpubsub.RetryOption) http.Handler {
+	return webhook.NewHandler(NewEventHandler(svc, codecs, opts...), verifier)
 }
 
 func decodeEventCreatedInput(codec eventcodec.Codec) eventpubsub.DecodeInputFunc {
@@ -46,17 +54,29 @@ func decodeEventCreatedInput(codec eventcodec.Codec) eventpubsub.DecodeInputFunc
 type EventPublisher struct {
 	publisher eventpubsub.Publisher
 	codecs    eventcodec.Codecs
+	source    string
 }
 
 func NewEventPublisher(publisher eventpubsub.Publisher, codecs eventcodec.Codecs) *EventPublisher {
 	return &EventPublisher{
 		publisher: publisher,
 		codecs:    codecs,
+		source:    "/eventsvc/Service",
 	}
 }
 
+// WithSource sets the CloudEvents attribute source of the published events,
+// which defaults to "/eventsvc/Service".
+func (p *EventPublisher) WithSource(source string) *EventPublisher {
+	p.source = source
+	return p
+}
+
 func (p *EventPublisher) EventCreated(ctx context.Context, id int) (err error) {
+	attrs := eventpubsub.NewAttributes("created", p.source)
+
 	codec := p.codecs.EncodeDecoder("EventCreated")
+	attrs.DataContentType = eventcodec.ContentType(codec)
 
 	_data, err := codec.Encode(&EventCreatedRequest{
 		Id: id,
@@ -65,5 +85,5 @@ func (p *EventPublisher) EventCreated(ctx context.Context, id int) (err error) {
 		return err
 	}
 
-	return p.publisher.Publish(ctx, "created", _data)
+	return eventpubsub.Publish(ctx, p.publisher, eventpubsub.NewCloudEvent(attrs, _data))
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/RussellLuo/kun/examples/tasksvc"
+	"github.com/RussellLuo/kun/pkg/taskqueue"
+)
+
+func main() {
+	// Use taskqueue.NewSQLQueue(db) instead to share the queue among processes.
+	queue := taskqueue.NewMemoryQueue()
+
+	// The worker side.
+	handler := tasksvc.NewTaskHandler(&tasksvc.Handler{})
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"emails", "maintenance"} {
+		worker := taskqueue.NewWorker(queue, name, handler,
+			taskqueue.PollInterval(100*time.Millisecond),
+			taskqueue.ErrorHandler(func(ctx context.Context, task *taskqueue.Task, err error) {
+				log.Printf("err: %v", err)
+			}),
+		)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = worker.Run(ctx)
+		}()
+	}
+
+	// The client side.
+	client := tasksvc.NewTaskClient(queue)
+	if err := client.SendEmail(context.Background(), "bob@example.com", "Hello"); err != nil {
+		log.Fatalf("err: %v", err)
+	}
+	// Run the task in 2 seconds.
+	if err := client.CleanUpSessions(taskqueue.WithDelay(context.Background(), 2*time.Second)); err != nil {
+		log.Fatalf("err: %v", err)
+	}
+
+	wg.Wait()
+}
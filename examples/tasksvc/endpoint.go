@@ -0,0 +1,70 @@
+// Code generated by kun; DO NOT EDIT.
+// github.com/RussellLuo/kun
+
+package tasksvc
+
+import (
+	"context"
+
+	"github.com/RussellLuo/kun/pkg/httpoption"
+	"github.com/RussellLuo/validating/v3"
+	"github.com/go-kit/kit/endpoint"
+)
+
+type CleanUpSessionsResponse struct {
+	Err error `json:"-"`
+}
+
+func (r *CleanUpSessionsResponse) Body() interface{} { return r }
+
+// Failed implements endpoint.Failer.
+func (r *CleanUpSessionsResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfCleanUpSessions creates the endpoint for s.CleanUpSessions.
+func MakeEndpointOfCleanUpSessions(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		err := s.CleanUpSessions(
+			ctx,
+		)
+		return &CleanUpSessionsResponse{
+			Err: err,
+		}, nil
+	}
+}
+
+type SendEmailRequest struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+}
+
+// ValidateSendEmailRequest creates a validator for SendEmailRequest.
+func ValidateSendEmailRequest(newSchema func(*SendEmailRequest) validating.Schema) httpoption.Validator {
+	return httpoption.FuncValidator(func(value interface{}) error {
+		req := value.(*SendEmailRequest)
+		return httpoption.Validate(newSchema(req))
+	})
+}
+
+type SendEmailResponse struct {
+	Err error `json:"-"`
+}
+
+func (r *SendEmailResponse) Body() interface{} { return r }
+
+// Failed implements endpoint.Failer.
+func (r *SendEmailResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfSendEmail creates the endpoint for s.SendEmail.
+func MakeEndpointOfSendEmail(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*SendEmailRequest)
+		err := s.SendEmail(
+			ctx,
+			req.To,
+			req.Subject,
+		)
+		return &SendEmailResponse{
+			Err: err,
+		}, nil
+	}
+}
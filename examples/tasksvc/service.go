@@ -0,0 +1,29 @@
+package tasksvc
+
+import (
+	"context"
+	"log"
+)
+
+//go:generate kungen ./service.go Service
+
+// Service is used for handling tasks.
+type Service interface {
+	//kun:task queue=emails
+	SendEmail(ctx context.Context, to, subject string) error
+
+	//kun:task name=cleanup queue=maintenance
+	CleanUpSessions(ctx context.Context) error
+}
+
+type Handler struct{}
+
+func (h *Handler) SendEmail(ctx context.Context, to, subject string) error {
+	log.Printf("Sending an email to %s: %s", to, subject)
+	return nil
+}
+
+func (h *Handler) CleanUpSessions(ctx context.Context) error {
+	log.Println("Cleaning up the expired sessions")
+	return nil
+}
@@ -0,0 +1,164 @@
+// Code generated by kun; DO NOT EDIT.
+// github.com/RussellLuo/kun
+
+package telemetrysvcgrpc
+
+import (
+	"context"
+
+	"github.com/RussellLuo/kun/pkg/httpoption"
+	"github.com/RussellLuo/validating/v3"
+	"github.com/go-kit/kit/endpoint"
+)
+
+type EchoRequest struct {
+	In  <-chan Point `json:"-"`
+	Out chan<- Point `json:"-"`
+}
+
+// ValidateEchoRequest creates a validator for EchoRequest.
+func ValidateEchoRequest(newSchema func(*EchoRequest) validating.Schema) httpoption.Validator {
+	return httpoption.FuncValidator(func(value interface{}) error {
+		req := value.(*EchoRequest)
+		return httpoption.Validate(newSchema(req))
+	})
+}
+
+type EchoResponse struct {
+	Err error `json:"-"`
+}
+
+func (r *EchoResponse) Body() interface{} { return r }
+
+// Failed implements endpoint.Failer.
+func (r *EchoResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfEcho creates the endpoint for s.Echo.
+func MakeEndpointOfEcho(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*EchoRequest)
+		err := s.Echo(
+			ctx,
+			req.In,
+			req.Out,
+		)
+		return &EchoResponse{
+			Err: err,
+		}, nil
+	}
+}
+
+type IngestRequest struct {
+	Metric string       `json:"metric"`
+	Points <-chan Point `json:"-"`
+}
+
+// ValidateIngestRequest creates a validator for IngestRequest.
+func ValidateIngestRequest(newSchema func(*IngestRequest) validating.Schema) httpoption.Validator {
+	return httpoption.FuncValidator(func(value interface{}) error {
+		req := value.(*IngestRequest)
+		return httpoption.Validate(newSchema(req))
+	})
+}
+
+type IngestResponse struct {
+	Count int   `json:"count"`
+	Err   error `json:"-"`
+}
+
+func (r *IngestResponse) Body() interface{} { return r }
+
+// Failed implements endpoint.Failer.
+func (r *IngestResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfIngest creates the endpoint for s.Ingest.
+func MakeEndpointOfIngest(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*IngestRequest)
+		count, err := s.Ingest(
+			ctx,
+			req.Metric,
+			req.Points,
+		)
+		return &IngestResponse{
+			Count: count,
+			Err:   err,
+		}, nil
+	}
+}
+
+type ListRequest struct {
+	Metric string                  `json:"metric"`
+	Limit  *int                    `json:"limit"`
+	Yield  func(point Point) error `json:"-"`
+}
+
+// ValidateListRequest creates a validator for ListRequest.
+func ValidateListRequest(newSchema func(*ListRequest) validating.Schema) httpoption.Validator {
+	return httpoption.FuncValidator(func(value interface{}) error {
+		req := value.(*ListRequest)
+		return httpoption.Validate(newSchema(req))
+	})
+}
+
+type ListResponse struct {
+	Err error `json:"-"`
+}
+
+func (r *ListResponse) Body() interface{} { return r }
+
+// Failed implements endpoint.Failer.
+func (r *ListResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfList creates the endpoint for s.List.
+func MakeEndpointOfList(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*ListRequest)
+		err := s.List(
+			ctx,
+			req.Metric,
+			req.Limit,
+			req.Yield,
+		)
+		return &ListResponse{
+			Err: err,
+		}, nil
+	}
+}
+
+type WatchRequest struct {
+	Metric string `json:"metric"`
+}
+
+// ValidateWatchRequest creates a validator for WatchRequest.
+func ValidateWatchRequest(newSchema func(*WatchRequest) validating.Schema) httpoption.Validator {
+	return httpoption.FuncValidator(func(value interface{}) error {
+		req := value.(*WatchRequest)
+		return httpoption.Validate(newSchema(req))
+	})
+}
+
+type WatchResponse struct {
+	Points <-chan Point `json:"-"`
+	Err    error        `json:"-"`
+}
+
+func (r *WatchResponse) Body() interface{} { return r }
+
+// Failed implements endpoint.Failer.
+func (r *WatchResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfWatch creates the endpoint for s.Watch.
+func MakeEndpointOfWatch(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*WatchRequest)
+		points, err := s.Watch(
+			ctx,
+			req.Metric,
+		)
+		return &WatchResponse{
+			Points: points,
+			Err:    err,
+		}, nil
+	}
+}
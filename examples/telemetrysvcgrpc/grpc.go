@@ -0,0 +1,294 @@
+// Code generated by kun; DO NOT EDIT.
+// github.com/RussellLuo/kun
+
+package telemetrysvcgrpc
+
+import (
+	"context"
+	"io"
+
+	"github.com/RussellLuo/kun/examples/telemetrysvcgrpc/pb"
+	"github.com/RussellLuo/kun/pkg/grpccodec"
+)
+
+func init() {
+	// Register the mappings from proto enums to Go constants.
+	grpccodec.RegisterEnum(pb.Kind(0), map[int32]interface{}{
+		0: "",
+		1: "gauge",
+		2: "counter",
+	})
+}
+
+type grpcServer struct {
+	pb.UnimplementedServiceServer
+
+	// Streaming RPCs are served by calling svc directly, since Go kit
+	// endpoints only support unary calls.
+	svc    Service
+	codecs grpccodec.Codecs
+}
+
+func (s *grpcServer) Echo(stream pb.Service_EchoServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	codec := s.codecs.EncodeDecoder("Echo")
+
+	// The non-stream parameters are decoded from the first request message.
+	first, recvErr := stream.Recv()
+	if recvErr != nil && recvErr != io.EOF {
+		return recvErr
+	}
+
+	// On errors, ctx is canceled before recvc is closed, so that the service
+	// can tell a broken stream from a finished one.
+	recvc := make(chan Point)
+	recv := grpccodec.NewStreamReceiver(cancel)
+	defer recv.Stop()
+	go func() {
+		defer recv.Done()
+		defer close(recvc)
+		for msg := first; msg != nil; {
+			// The first message may carry the non-stream parameters only.
+			if msg != first || grpccodec.HasElem(msg, "in") {
+				var elem echoStreamRequest
+				if err := codec.DecodeRequest(msg, &elem); err != nil {
+					recv.Fail(err)
+					return
+				}
+				select {
+				case recvc <- elem.In:
+				case <-ctx.Done():
+					return
+				}
+			}
+			var err error
+			if !recv.Receive(func() { msg, err = stream.Recv() }) {
+				return
+			}
+			if err != nil && err != io.EOF {
+				recv.Fail(err)
+				return
+			}
+		}
+	}()
+
+	send := func(elem Point) error {
+		msg := new(pb.EchoResponse)
+		if err := codec.EncodeResponse(&echoStreamResponse{Out: elem}, msg); err != nil {
+			return err
+		}
+		return stream.Send(msg)
+	}
+
+	// The channel is owned by the service, which may close it when done.
+	// Either way, the stream ends once the service returns.
+	sendc := make(chan Point)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.svc.Echo(ctx, recvc, sendc)
+	}()
+	var sendErr, svcErr error
+loop:
+	for {
+		select {
+		case elem, ok := <-sendc:
+			switch {
+			case !ok:
+				sendc = nil
+			case sendErr == nil:
+				if sendErr = send(elem); sendErr != nil {
+					// Drain the stream until the service returns.
+					cancel()
+				}
+			}
+		case svcErr = <-errc:
+			break loop
+		}
+	}
+
+	// The error occurred in receiving, if any, takes precedence, since the
+	// service may have returned due to the broken stream.
+	if err := recv.Stop(); err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	if svcErr != nil {
+		return svcErr
+	}
+
+	return nil
+}
+
+// echoStreamRequest holds a single stream element of pb.EchoRequest.
+type echoStreamRequest struct {
+	In Point `json:"in"`
+}
+
+// echoStreamResponse holds a single stream element of pb.EchoResponse.
+type echoStreamResponse struct {
+	Out Point `json:"out"`
+}
+
+func (s *grpcServer) Ingest(stream pb.Service_IngestServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	codec := s.codecs.EncodeDecoder("Ingest")
+
+	// The non-stream parameters are decoded from the first request message.
+	first, recvErr := stream.Recv()
+	if recvErr != nil && recvErr != io.EOF {
+		return recvErr
+	}
+	var in IngestRequest
+	if first != nil {
+		if err := codec.DecodeRequest(first, &in); err != nil {
+			return err
+		}
+	}
+
+	// On errors, ctx is canceled before recvc is closed, so that the service
+	// can tell a broken stream from a finished one.
+	recvc := make(chan Point)
+	recv := grpccodec.NewStreamReceiver(cancel)
+	defer recv.Stop()
+	go func() {
+		defer recv.Done()
+		defer close(recvc)
+		for msg := first; msg != nil; {
+			// The first message may carry the non-stream parameters only.
+			if msg != first || grpccodec.HasElem(msg, "points") {
+				var elem ingestStreamRequest
+				if err := codec.DecodeRequest(msg, &elem); err != nil {
+					recv.Fail(err)
+					return
+				}
+				select {
+				case recvc <- elem.Points:
+				case <-ctx.Done():
+					return
+				}
+			}
+			var err error
+			if !recv.Receive(func() { msg, err = stream.Recv() }) {
+				return
+			}
+			if err != nil && err != io.EOF {
+				recv.Fail(err)
+				return
+			}
+		}
+	}()
+
+	count, err := s.svc.Ingest(ctx, in.Metric, recvc)
+
+	// The error occurred in receiving, if any, takes precedence, since the
+	// service may have returned due to the broken stream.
+	if err := recv.Stop(); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	msg := new(pb.IngestResponse)
+	resp := &IngestResponse{
+		Count: count,
+	}
+	if err := codec.EncodeResponse(resp, msg); err != nil {
+		return err
+	}
+	return stream.SendAndClose(msg)
+}
+
+// ingestStreamRequest holds a single stream element of pb.IngestRequest.
+type ingestStreamRequest struct {
+	Points Point `json:"points"`
+}
+
+func (s *grpcServer) List(req *pb.ListRequest, stream pb.Service_ListServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	codec := s.codecs.EncodeDecoder("List")
+
+	var in ListRequest
+	if err := codec.DecodeRequest(req, &in); err != nil {
+		return err
+	}
+
+	send := func(elem Point) error {
+		msg := new(pb.ListResponse)
+		if err := codec.EncodeResponse(&listStreamResponse{Point: elem}, msg); err != nil {
+			return err
+		}
+		return stream.Send(msg)
+	}
+	if err := s.svc.List(ctx, in.Metric, in.Limit, send); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// listStreamResponse holds a single stream element of pb.ListResponse.
+type listStreamResponse struct {
+	Point Point `json:"point"`
+}
+
+func (s *grpcServer) Watch(req *pb.WatchRequest, stream pb.Service_WatchServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	codec := s.codecs.EncodeDecoder("Watch")
+
+	var in WatchRequest
+	if err := codec.DecodeRequest(req, &in); err != nil {
+		return err
+	}
+
+	send := func(elem Point) error {
+		msg := new(pb.WatchResponse)
+		if err := codec.EncodeResponse(&watchStreamResponse{Points: elem}, msg); err != nil {
+			return err
+		}
+		return stream.Send(msg)
+	}
+
+	sendc, err := s.svc.Watch(ctx, in.Metric)
+	if err != nil {
+		return err
+	}
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case elem, ok := <-sendc:
+			if !ok {
+				break loop
+			}
+			if err := send(elem); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// watchStreamResponse holds a single stream element of pb.WatchResponse.
+type watchStreamResponse struct {
+	Points Point `json:"points"`
+}
+
+func NewGRPCServer(svc Service, codecs grpccodec.Codecs) pb.ServiceServer {
+	s := new(grpcServer)
+	s.svc, s.codecs = svc, codecs
+
+	return s
+}
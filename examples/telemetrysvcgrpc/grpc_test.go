@@ -0,0 +1,214 @@
+package telemetrysvcgrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/RussellLuo/kun/examples/telemetrysvcgrpc/pb"
+	"github.com/RussellLuo/kun/pkg/grpccodec"
+	"google.golang.org/grpc"
+)
+
+// ingestStream is a fake stream of Ingest, which returns the given requests
+// and then the given error.
+type ingestStream struct {
+	grpc.ServerStream
+
+	reqs []*pb.IngestRequest
+	err  error
+
+	resp *pb.IngestResponse
+}
+
+func (s *ingestStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *ingestStream) Recv() (*pb.IngestRequest, error) {
+	if len(s.reqs) == 0 {
+		return nil, s.err
+	}
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+	return req, nil
+}
+
+func (s *ingestStream) SendAndClose(resp *pb.IngestResponse) error {
+	s.resp = resp
+	return nil
+}
+
+// ingestService is a fake service, which records whether the stream is
+// broken when the points are exhausted.
+type ingestService struct {
+	Service
+
+	count  int
+	broken bool
+}
+
+func (s *ingestService) Ingest(ctx context.Context, metric string, points <-chan Point) (int, error) {
+	for range points {
+		s.count++
+	}
+	if ctx.Err() != nil {
+		s.broken = true
+		return 0, ctx.Err()
+	}
+	return s.count, nil
+}
+
+func TestGRPCServer_Ingest(t *testing.T) {
+	tests := []struct {
+		name       string
+		inReqs     []*pb.IngestRequest
+		inErr      error // The error returned by Recv after all the requests.
+		wantCount  int
+		wantBroken bool
+		wantResp   bool
+		wantErrStr string
+	}{
+		{
+			name: "finished",
+			inReqs: []*pb.IngestRequest{
+				{Metric: "cpu", Points: &pb.Point{Value: 1}},
+				{Points: &pb.Point{Value: 2}},
+			},
+			inErr:     io.EOF,
+			wantCount: 2,
+			wantResp:  true,
+		},
+		{
+			name: "params-only first message",
+			inReqs: []*pb.IngestRequest{
+				{Metric: "cpu"},
+				{Points: &pb.Point{Value: 1}},
+				{Points: &pb.Point{Value: 2}},
+			},
+			inErr:     io.EOF,
+			wantCount: 2,
+			wantResp:  true,
+		},
+		{
+			name: "broken",
+			inReqs: []*pb.IngestRequest{
+				{Metric: "cpu", Points: &pb.Point{Value: 1}},
+				{Points: &pb.Point{Value: 2}},
+			},
+			inErr:      errors.New("connection reset"),
+			wantCount:  2,
+			wantBroken: true,
+			wantErrStr: "connection reset",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &ingestService{}
+			stream := &ingestStream{reqs: tt.inReqs, err: tt.inErr}
+
+			s := NewGRPCServer(svc, grpccodec.NewDefaultCodecs(nil))
+			err := s.Ingest(stream)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+
+			if svc.count != tt.wantCount {
+				t.Fatalf("Count: got (%d), want (%d)", svc.count, tt.wantCount)
+			}
+			if svc.broken != tt.wantBroken {
+				t.Fatalf("Broken: got (%v), want (%v)", svc.broken, tt.wantBroken)
+			}
+			if (stream.resp != nil) != tt.wantResp {
+				t.Fatalf("Resp: got (%v), want (%v)", stream.resp, tt.wantResp)
+			}
+		})
+	}
+}
+
+// echoStream is a fake stream of Echo, which returns the given requests and
+// then io.EOF, and records the sent responses.
+type echoStream struct {
+	grpc.ServerStream
+
+	reqs  []*pb.EchoRequest
+	resps []*pb.EchoResponse
+}
+
+func (s *echoStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *echoStream) Recv() (*pb.EchoRequest, error) {
+	if len(s.reqs) == 0 {
+		return nil, io.EOF
+	}
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+	return req, nil
+}
+
+func (s *echoStream) Send(resp *pb.EchoResponse) error {
+	s.resps = append(s.resps, resp)
+	return nil
+}
+
+// echoService is a fake service, which closes the output channel if
+// closeOut is true.
+type echoService struct {
+	Service
+
+	closeOut bool
+}
+
+func (s *echoService) Echo(ctx context.Context, in <-chan Point, out chan<- Point) error {
+	if s.closeOut {
+		defer close(out)
+	}
+	for p := range in {
+		out <- p
+	}
+	return nil
+}
+
+func TestGRPCServer_Echo(t *testing.T) {
+	tests := []struct {
+		name     string
+		closeOut bool
+	}{
+		{
+			name:     "closed by service",
+			closeOut: true,
+		},
+		{
+			name:     "not closed by service",
+			closeOut: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &echoService{closeOut: tt.closeOut}
+			stream := &echoStream{reqs: []*pb.EchoRequest{
+				{In: &pb.Point{Value: 1}},
+				{In: &pb.Point{Value: 2}},
+			}}
+
+			s := NewGRPCServer(svc, grpccodec.NewDefaultCodecs(nil))
+			if err := s.Echo(stream); err != nil {
+				t.Fatalf("Err: got (%#v), want (<nil>)", err)
+			}
+
+			if len(stream.resps) != 2 {
+				t.Fatalf("Resps: got (%d), want (2)", len(stream.resps))
+			}
+			for i, resp := range stream.resps {
+				if want := float64(i + 1); resp.Out.GetValue() != want {
+					t.Fatalf("Resps[%d]: got (%v), want (%v)", i, resp.Out.GetValue(), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ServiceClient is the client API for Service service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ServiceClient interface {
+	// Echo sends back every point it receives.
+	Echo(ctx context.Context, opts ...grpc.CallOption) (Service_EchoClient, error)
+	// Ingest collects a stream of points for the given metric.
+	Ingest(ctx context.Context, opts ...grpc.CallOption) (Service_IngestClient, error)
+	// List sends the points collected for the given metric. At most limit
+	// points are sent if limit is specified.
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (Service_ListClient, error)
+	// Watch sends the points newly collected for the given metric,
+	// until the client cancels the call.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Service_WatchClient, error)
+}
+
+type serviceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewServiceClient(cc grpc.ClientConnInterface) ServiceClient {
+	return &serviceClient{cc}
+}
+
+func (c *serviceClient) Echo(ctx context.Context, opts ...grpc.CallOption) (Service_EchoClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Service_ServiceDesc.Streams[0], "/pb.Service/Echo", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &serviceEchoClient{stream}
+	return x, nil
+}
+
+type Service_EchoClient interface {
+	Send(*EchoRequest) error
+	Recv() (*EchoResponse, error)
+	grpc.ClientStream
+}
+
+type serviceEchoClient struct {
+	grpc.ClientStream
+}
+
+func (x *serviceEchoClient) Send(m *EchoRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *serviceEchoClient) Recv() (*EchoResponse, error) {
+	m := new(EchoResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *serviceClient) Ingest(ctx context.Context, opts ...grpc.CallOption) (Service_IngestClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Service_ServiceDesc.Streams[1], "/pb.Service/Ingest", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &serviceIngestClient{stream}
+	return x, nil
+}
+
+type Service_IngestClient interface {
+	Send(*IngestRequest) error
+	CloseAndRecv() (*IngestResponse, error)
+	grpc.ClientStream
+}
+
+type serviceIngestClient struct {
+	grpc.ClientStream
+}
+
+func (x *serviceIngestClient) Send(m *IngestRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *serviceIngestClient) CloseAndRecv() (*IngestResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(IngestResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *serviceClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (Service_ListClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Service_ServiceDesc.Streams[2], "/pb.Service/List", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &serviceListClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Service_ListClient interface {
+	Recv() (*ListResponse, error)
+	grpc.ClientStream
+}
+
+type serviceListClient struct {
+	grpc.ClientStream
+}
+
+func (x *serviceListClient) Recv() (*ListResponse, error) {
+	m := new(ListResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *serviceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Service_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Service_ServiceDesc.Streams[3], "/pb.Service/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &serviceWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Service_WatchClient interface {
+	Recv() (*WatchResponse, error)
+	grpc.ClientStream
+}
+
+type serviceWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *serviceWatchClient) Recv() (*WatchResponse, error) {
+	m := new(WatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ServiceServer is the server API for Service service.
+// All implementations must embed UnimplementedServiceServer
+// for forward compatibility
+type ServiceServer interface {
+	// Echo sends back every point it receives.
+	Echo(Service_EchoServer) error
+	// Ingest collects a stream of points for the given metric.
+	Ingest(Service_IngestServer) error
+	// List sends the points collected for the given metric. At most limit
+	// points are sent if limit is specified.
+	List(*ListRequest, Service_ListServer) error
+	// Watch sends the points newly collected for the given metric,
+	// until the client cancels the call.
+	Watch(*WatchRequest, Service_WatchServer) error
+	mustEmbedUnimplementedServiceServer()
+}
+
+// UnimplementedServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedServiceServer struct {
+}
+
+func (UnimplementedServiceServer) Echo(Service_EchoServer) error {
+	return status.Errorf(codes.Unimplemented, "method Echo not implemented")
+}
+func (UnimplementedServiceServer) Ingest(Service_IngestServer) error {
+	return status.Errorf(codes.Unimplemented, "method Ingest not implemented")
+}
+func (UnimplementedServiceServer) List(*ListRequest, Service_ListServer) error {
+	return status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedServiceServer) Watch(*WatchRequest, Service_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedServiceServer) mustEmbedUnimplementedServiceServer() {}
+
+// UnsafeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ServiceServer will
+// result in compilation errors.
+type UnsafeServiceServer interface {
+	mustEmbedUnimplementedServiceServer()
+}
+
+func RegisterServiceServer(s grpc.ServiceRegistrar, srv ServiceServer) {
+	s.RegisterService(&Service_ServiceDesc, srv)
+}
+
+func _Service_Echo_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ServiceServer).Echo(&serviceEchoServer{stream})
+}
+
+type Service_EchoServer interface {
+	Send(*EchoResponse) error
+	Recv() (*EchoRequest, error)
+	grpc.ServerStream
+}
+
+type serviceEchoServer struct {
+	grpc.ServerStream
+}
+
+func (x *serviceEchoServer) Send(m *EchoResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *serviceEchoServer) Recv() (*EchoRequest, error) {
+	m := new(EchoRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Service_Ingest_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ServiceServer).Ingest(&serviceIngestServer{stream})
+}
+
+type Service_IngestServer interface {
+	SendAndClose(*IngestResponse) error
+	Recv() (*IngestRequest, error)
+	grpc.ServerStream
+}
+
+type serviceIngestServer struct {
+	grpc.ServerStream
+}
+
+func (x *serviceIngestServer) SendAndClose(m *IngestResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *serviceIngestServer) Recv() (*IngestRequest, error) {
+	m := new(IngestRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Service_List_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ServiceServer).List(m, &serviceListServer{stream})
+}
+
+type Service_ListServer interface {
+	Send(*ListResponse) error
+	grpc.ServerStream
+}
+
+type serviceListServer struct {
+	grpc.ServerStream
+}
+
+func (x *serviceListServer) Send(m *ListResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Service_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ServiceServer).Watch(m, &serviceWatchServer{stream})
+}
+
+type Service_WatchServer interface {
+	Send(*WatchResponse) error
+	grpc.ServerStream
+}
+
+type serviceWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *serviceWatchServer) Send(m *WatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Service_ServiceDesc is the grpc.ServiceDesc for Service service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Service_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.Service",
+	HandlerType: (*ServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Echo",
+			Handler:       _Service_Echo_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Ingest",
+			Handler:       _Service_Ingest_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "List",
+			Handler:       _Service_List_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _Service_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pb/telemetrysvcgrpc.proto",
+}
@@ -0,0 +1,131 @@
+package telemetrysvcgrpc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//go:generate kungen ./service.go Service
+
+// Service is used for collecting and watching metric points.
+//kun:grpc enum=Kind:KindGauge=1,KindCounter=2
+type Service interface {
+	// Ingest collects a stream of points for the given metric.
+	//kun:grpc
+	Ingest(ctx context.Context, metric string, points <-chan Point) (count int, err error)
+
+	// List sends the points collected for the given metric. At most limit
+	// points are sent if limit is specified.
+	//kun:grpc
+	List(ctx context.Context, metric string, limit *int, yield func(point Point) error) (err error)
+
+	// Watch sends the points newly collected for the given metric,
+	// until the client cancels the call.
+	//kun:grpc
+	Watch(ctx context.Context, metric string) (points <-chan Point, err error)
+
+	// Echo sends back every point it receives.
+	//kun:grpc
+	Echo(ctx context.Context, in <-chan Point, out chan<- Point) (err error)
+}
+
+// Kind is the kind of a metric.
+type Kind string
+
+const (
+	KindGauge   Kind = "gauge"
+	KindCounter Kind = "counter"
+)
+
+// Point is a single data point of a metric.
+type Point struct {
+	Kind   Kind              `json:"kind"`
+	Value  float64           `json:"value"`
+	Time   time.Time         `json:"time"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type Telemetry struct {
+	mu       sync.Mutex
+	points   map[string][]Point
+	watchers map[string][]chan Point
+}
+
+func NewTelemetry() *Telemetry {
+	return &Telemetry{
+		points:   make(map[string][]Point),
+		watchers: make(map[string][]chan Point),
+	}
+}
+
+func (t *Telemetry) Ingest(ctx context.Context, metric string, points <-chan Point) (int, error) {
+	count := 0
+	for p := range points {
+		t.mu.Lock()
+		t.points[metric] = append(t.points[metric], p)
+		for _, w := range t.watchers[metric] {
+			select {
+			case w <- p:
+			default: // Drop the point for slow watchers.
+			}
+		}
+		t.mu.Unlock()
+		count++
+	}
+	return count, nil
+}
+
+func (t *Telemetry) List(ctx context.Context, metric string, limit *int, yield func(Point) error) error {
+	t.mu.Lock()
+	points := append([]Point(nil), t.points[metric]...)
+	t.mu.Unlock()
+
+	if limit != nil && *limit < len(points) {
+		points = points[:*limit]
+	}
+
+	for _, p := range points {
+		if err := yield(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Telemetry) Watch(ctx context.Context, metric string) (<-chan Point, error) {
+	w := make(chan Point, 16)
+
+	t.mu.Lock()
+	t.watchers[metric] = append(t.watchers[metric], w)
+	t.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		watchers := t.watchers[metric]
+		for i, c := range watchers {
+			if c == w {
+				t.watchers[metric] = append(watchers[:i], watchers[i+1:]...)
+				break
+			}
+		}
+		close(w)
+	}()
+
+	return w, nil
+}
+
+func (t *Telemetry) Echo(ctx context.Context, in <-chan Point, out chan<- Point) error {
+	defer close(out)
+	for p := range in {
+		select {
+		case out <- p:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -1,10 +1,16 @@
 package generator
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/RussellLuo/kun/gen/cron/parser"
 	utilannotation "github.com/RussellLuo/kun/gen/util/annotation"
 	"github.com/RussellLuo/kun/gen/util/generator"
 	"github.com/RussellLuo/kun/gen/util/openapi"
+	"github.com/RussellLuo/kun/pkg/caseconv"
 	"github.com/RussellLuo/kun/pkg/ifacetool"
 )
 
@@ -16,9 +22,20 @@ var (
 package {{.PkgInfo.CurrentPkgName}}
 
 import (
+	{{- range .Imports}}
+	"{{.}}"
+	{{- end}}
+
+	{{- if .HasArgs}}
 	{{- range .Data.Imports}}
+	{{- if not (imported .)}}
 	{{.ImportString}}
 	{{- end}}
+	{{- end}}
+	{{- end}}
+	{{- if .Wrapped}}
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	{{- end}}
 	"github.com/RussellLuo/micron"
 
 	{{- if .PkgInfo.EndpointPkgPath}}
@@ -28,13 +45,45 @@ import (
 
 func NewCronJobs(svc {{$.Data.SrcPkgQualifier}}{{$.Data.InterfaceName}}) []micron.Job {
 	return []micron.Job{
-		{{range .Spec.Operations -}}
-		{
-			Name: "{{getJobName .GoMethodName}}",
-			Expr: "{{getJobExpr .GoMethodName}}",
-			Handler: svc.{{.Name}},
-		},
-		{{end -}} {{/* range .Spec.Operations */ -}}
+		{{- range .Spec.Operations}}
+		{{- $op := .}}
+		{{- $nonCtxParams := nonCtxParams .Request.Params}}
+		{{- range getJobs .GoMethodName}}
+		{{- $wrapped := or .Timezone .Jitter .Overlap}}
+		{{if $wrapped}}cronjob.Wrap(micron.Job{{end}}{
+			Name: "{{.Name}}",
+			Expr: "{{.Expr}}",
+			{{- if or .Args .Timeout}}
+			Handler: func(ctx context.Context) error {
+				{{- if .Timeout}}
+				ctx, cancel := context.WithTimeout(ctx, {{durationExpr .Timeout}})
+				defer cancel()
+				{{- end}}
+				{{- if .Args}}
+
+				var args struct {
+					{{- range $nonCtxParams}}
+					{{title .Name}} {{.Type}} ` + "`" + `json:"{{.Name}}"` + "`" + `
+					{{- end}}
+				}
+				if err := json.Unmarshal([]byte({{quote .Args}}), &args); err != nil {
+					return err
+				}
+				{{- end}}
+
+				return svc.{{$op.Name}}(ctx{{range $nonCtxParams}}, args.{{title .Name}}{{end}})
+			},
+			{{- else}}
+			Handler: svc.{{$op.Name}},
+			{{- end}}
+		}
+		{{- if $wrapped}}
+		{{- if .Timezone}}, cronjob.Timezone("{{.Timezone}}"){{end}}
+		{{- if .Jitter}}, cronjob.Jitter({{durationExpr .Jitter}}){{end}}
+		{{- if .Overlap}}, cronjob.Overlap(cronjob.Overlap{{title .Overlap}}){{end}})
+		{{- end}},
+		{{- end}} {{- /* range getJobs .GoMethodName */}}
+		{{- end}} {{- /* range .Spec.Operations */}}
 	}
 }
 `
@@ -54,27 +103,112 @@ func New(opts *Options) *Generator {
 	return &Generator{opts: opts}
 }
 
-func (g *Generator) Generate(pkgInfo *generator.PkgInfo, ifaceData *ifacetool.Data, cronSpec map[string]*parser.Job, spec *openapi.Specification) (*generator.File, error) {
+func (g *Generator) Generate(pkgInfo *generator.PkgInfo, ifaceData *ifacetool.Data, cronSpec map[string][]*parser.Job, spec *openapi.Specification) (*generator.File, error) {
+	u := usageOf(cronSpec)
+	imports := u.stdImports()
 	data := struct {
 		PkgInfo *generator.PkgInfo
 		Data    *ifacetool.Data
 		Spec    *openapi.Specification
+		Imports []string
+		HasArgs bool // The source imports are only used by the arguments.
+		Wrapped bool
 	}{
 		PkgInfo: pkgInfo,
 		Data:    ifaceData,
 		Spec:    spec,
+		Imports: imports,
+		HasArgs: u.args,
+		Wrapped: u.wrapped,
 	}
 
 	return generator.Generate(template, data, generator.Options{
 		Funcs: map[string]interface{}{
-			"getJobName": func(methodName string) string {
-				return cronSpec[methodName].Name
+			"title": caseconv.UpperFirst,
+			"imported": func(i *ifacetool.Import) bool {
+				// Skip the source imports, which have been imported above.
+				for _, path := range imports {
+					if i.Alias == "" && i.Path == path {
+						return true
+					}
+				}
+				return false
+			},
+			"getJobs": func(methodName string) []*parser.Job {
+				return cronSpec[methodName]
 			},
-			"getJobExpr": func(methodName string) string {
-				return cronSpec[methodName].Expr
+			"nonCtxParams": func(params []*openapi.Param) (out []*openapi.Param) {
+				for _, p := range params {
+					if p.Type != "context.Context" {
+						out = append(out, p)
+					}
+				}
+				return
+			},
+			"durationExpr": durationExpr,
+			"quote": func(s string) string {
+				if strings.Contains(s, "`") {
+					return strconv.Quote(s)
+				}
+				return "`" + s + "`"
 			},
 		},
 		Formatted:      g.opts.Formatted,
 		TargetFileName: "cron.go",
 	})
 }
+
+// usage records the features used by the jobs, which determine the imports
+// of the generated code.
+type usage struct {
+	args    bool
+	timeout bool
+	jitter  bool
+	wrapped bool // Whether any job needs to be wrapped by cronjob.Wrap.
+}
+
+func usageOf(jobs map[string][]*parser.Job) (u usage) {
+	for _, js := range jobs {
+		for _, j := range js {
+			u.args = u.args || j.Args != ""
+			u.timeout = u.timeout || j.Timeout > 0
+			u.jitter = u.jitter || j.Jitter > 0
+			u.wrapped = u.wrapped || j.Timezone != "" || j.Jitter > 0 || j.Overlap != ""
+		}
+	}
+	return u
+}
+
+// stdImports returns the standard packages used by the generated code.
+func (u usage) stdImports() (imports []string) {
+	if u.args || u.timeout {
+		imports = append(imports, "context")
+	}
+	if u.args {
+		imports = append(imports, "encoding/json")
+	}
+	if u.timeout || u.jitter {
+		imports = append(imports, "time")
+	}
+	return imports
+}
+
+// durationExpr returns the Go expression of the duration d.
+func durationExpr(d time.Duration) string {
+	units := []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	}
+	for _, u := range units {
+		if d%u.d == 0 {
+			return fmt.Sprintf("%d * %s", d/u.d, u.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", d)
+}
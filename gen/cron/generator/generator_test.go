@@ -0,0 +1,107 @@
+package generator
+
+import (
+	goparser "go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/gen/cron/parser"
+	"github.com/RussellLuo/kun/gen/util/generator"
+	"github.com/RussellLuo/kun/gen/util/openapi"
+	"github.com/RussellLuo/kun/pkg/ifacetool"
+)
+
+func TestGenerator_Generate_Imports(t *testing.T) {
+	tests := []struct {
+		name        string
+		inJob       *parser.Job
+		wantImports []string
+	}{
+		{
+			name:        "plain",
+			inJob:       &parser.Job{Name: "sync", Expr: "@every 5s"},
+			wantImports: []string{"github.com/RussellLuo/micron"},
+		},
+		{
+			name:        "timeout",
+			inJob:       &parser.Job{Name: "sync", Expr: "@every 5s", Timeout: time.Second},
+			wantImports: []string{"context", "time", "github.com/RussellLuo/micron"},
+		},
+		{
+			name:  "args",
+			inJob: &parser.Job{Name: "sync", Expr: "@every 5s", Args: `{"region":"eu"}`},
+			wantImports: []string{
+				"context",
+				"encoding/json",
+				"github.com/RussellLuo/micron",
+			},
+		},
+		{
+			name:  "timezone",
+			inJob: &parser.Job{Name: "sync", Expr: "@every 5s", Timezone: "Asia/Shanghai"},
+			wantImports: []string{
+				"github.com/RussellLuo/kun/pkg/cronjob",
+				"github.com/RussellLuo/micron",
+			},
+		},
+		{
+			name:  "jitter",
+			inJob: &parser.Job{Name: "sync", Expr: "@every 5s", Jitter: time.Second},
+			wantImports: []string{
+				"time",
+				"github.com/RussellLuo/kun/pkg/cronjob",
+				"github.com/RussellLuo/micron",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &openapi.Specification{
+				Operations: []*openapi.Operation{{
+					Name:         "Sync",
+					GoMethodName: "Sync",
+					Request: openapi.Request{
+						Params: []*openapi.Param{
+							{Name: "ctx", Type: "context.Context"},
+							{Name: "region", Type: "string"},
+						},
+					},
+				}},
+			}
+			data := &ifacetool.Data{
+				InterfaceName: "Service",
+				Imports:       []*ifacetool.Import{{Path: "context"}},
+			}
+
+			// Generate unformatted code, whose imports are not fixed by the
+			// formatters.
+			g := New(&Options{})
+			file, err := g.Generate(
+				&generator.PkgInfo{CurrentPkgName: "cronsvc"},
+				data,
+				map[string][]*parser.Job{"Sync": {tt.inJob}},
+				spec,
+			)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			f, err := goparser.ParseFile(token.NewFileSet(), "cron.go", file.Content, 0)
+			if err != nil {
+				t.Fatalf("err: %v\n%s", err, file.Content)
+			}
+			var got []string
+			for _, spec := range f.Imports {
+				path, _ := strconv.Unquote(spec.Path.Value)
+				got = append(got, path)
+			}
+			if !reflect.DeepEqual(got, tt.wantImports) {
+				t.Fatalf("Imports: got (%v), want (%v)", got, tt.wantImports)
+			}
+		})
+	}
+}
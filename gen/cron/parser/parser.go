@@ -1,14 +1,17 @@
 package parser
 
 import (
+	"encoding/json"
 	"fmt"
 	"go/types"
 	"regexp"
+	"time"
 
 	"github.com/RussellLuo/kun/gen/util/annotation"
 	"github.com/RussellLuo/kun/gen/util/parser"
 	"github.com/RussellLuo/kun/pkg/caseconv"
 	"github.com/RussellLuo/kun/pkg/ifacetool"
+	"github.com/RussellLuo/micron"
 )
 
 var (
@@ -18,79 +21,215 @@ var (
 type Job struct {
 	Name string
 	Expr string
+
+	// Args is the JSON object of the constant arguments, whose keys are
+	// the names of the method parameters (except the context).
+	Args string
+
+	// Timeout is the deadline put on the context passed to the method.
+	// Zero means no timeout.
+	Timeout time.Duration
+
+	// Timezone is the timezone, in which Expr is interpreted. Empty means
+	// the timezone of the scheduler.
+	Timezone string
+
+	// Jitter is the maximum random delay before each run. Zero means no jitter.
+	Jitter time.Duration
+
+	// Overlap is the policy when the job is triggered while its previous run
+	// is still going, which is one of "allow", "skip" and "queue". Empty means
+	// the default policy (i.e. "allow").
+	Overlap string
 }
 
-func Parse(data *ifacetool.Data, snakeCase bool) (map[string]*Job, error) {
-	c := make(map[string]*Job)
+// Parse parses the cron jobs of each method. A method may be annotated by
+// multiple //kun:cron directives, to be scheduled as multiple jobs (typically
+// with different arguments).
+//
+// The errors of the directives are prefixed by their source positions, if
+// known.
+func Parse(data *ifacetool.Data, snakeCase bool) (map[string][]*Job, error) {
+	c := make(map[string][]*Job)
+	names := make(map[string]string) // job name -> method name
 
 	for _, m := range data.Methods {
-		if !validateSignature(m) {
-			return nil, fmt.Errorf("the signature of method %s must be `func(context.Context) error` when annotated by %s directive", m.Name, annotation.DirectiveCron)
+		if !parser.ValidateSignature(m) {
+			return nil, fmt.Errorf("the signature of method %s must be `func(context.Context, ...) error` when annotated by %s directive", m.Name, annotation.DirectiveCron)
 		}
 
-		for _, comment := range m.Doc {
+		directives := make(map[string]bool)
+		for i, comment := range m.Doc {
 			if annotation.Directive(comment).Dialect() != annotation.DialectCron {
 				continue
 			}
 
-			result := reCron.FindStringSubmatch(comment)
-			if len(result) != 2 {
-				return nil, fmt.Errorf("invalid %s directive: %s", annotation.DirectiveCron, comment)
+			if directives[comment] {
+				return nil, parser.WithPos(m, i, fmt.Errorf("duplicate %s directive of the method %s", annotation.DirectiveCron, m.Name))
 			}
+			directives[comment] = true
 
-			pairs, err := parser.ParseOptionPairs(result[1])
+			job, err := parseJob(m, comment, snakeCase)
 			if err != nil {
-				return nil, err
+				return nil, parser.WithPos(m, i, err)
 			}
 
-			job := new(Job)
-
-			for _, pair := range pairs {
-				switch pair.Key {
-				case "name":
-					job.Name = pair.Value
-				case "expr":
-					job.Expr = pair.Value
-				default:
-					return nil, fmt.Errorf(`unrecognized %s key "%s" in comment: %s`, annotation.DirectiveCron, pair.Key, comment)
-				}
+			if method, ok := names[job.Name]; ok && method == m.Name {
+				return nil, parser.WithPos(m, i, fmt.Errorf("duplicate job name %q of the method %s, which must be specified by key \"name\"", job.Name, m.Name))
+			} else if ok {
+				return nil, parser.WithPos(m, i, fmt.Errorf("duplicate job name %q of the methods %s and %s, which must be specified by key \"name\"", job.Name, method, m.Name))
 			}
+			names[job.Name] = m.Name
 
-			// Here we assume that all annotation keys are specified in the same line.
+			c[m.Name] = append(c[m.Name], job)
+		}
+	}
 
-			if job.Name == "" {
-				job.Name = caseconv.ToLowerCamelCase(m.Name)
-				if snakeCase {
-					job.Name = caseconv.ToSnakeCase(m.Name)
-				}
-			}
+	return c, nil
+}
+
+func parseJob(m *ifacetool.Method, comment string, snakeCase bool) (*Job, error) {
+	result := reCron.FindStringSubmatch(comment)
+	if len(result) != 2 {
+		return nil, fmt.Errorf("invalid %s directive: %s", annotation.DirectiveCron, comment)
+	}
+
+	pairs, err := parser.ParseOptionPairs(result[1])
+	if err != nil {
+		return nil, err
+	}
 
-			if job.Expr == "" {
-				return nil, fmt.Errorf(`missing key "expr" for %s directive in comment: %s`, annotation.DirectiveCron, comment)
+	job := new(Job)
+
+	for _, pair := range pairs {
+		switch pair.Key {
+		case "name":
+			job.Name = pair.Value
+		case "expr":
+			job.Expr = pair.Value
+		case "args":
+			job.Args = pair.Value
+		case "timeout":
+			timeout, err := time.ParseDuration(pair.Value)
+			if err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("invalid timeout %q of the method %s, which must be a positive duration", pair.Value, m.Name)
+			}
+			job.Timeout = timeout
+		case "tz":
+			if _, err := time.LoadLocation(pair.Value); err != nil {
+				return nil, fmt.Errorf("invalid tz %q of the method %s: %v", pair.Value, m.Name, err)
+			}
+			job.Timezone = pair.Value
+		case "jitter":
+			jitter, err := time.ParseDuration(pair.Value)
+			if err != nil || jitter <= 0 {
+				return nil, fmt.Errorf("invalid jitter %q of the method %s, which must be a positive duration", pair.Value, m.Name)
+			}
+			job.Jitter = jitter
+		case "overlap":
+			switch pair.Value {
+			case "allow", "skip", "queue":
+				job.Overlap = pair.Value
+			default:
+				return nil, fmt.Errorf("invalid overlap %q of the method %s, which must be one of allow, skip and queue", pair.Value, m.Name)
 			}
+		default:
+			return nil, fmt.Errorf(`unrecognized %s key "%s" in comment: %s`, annotation.DirectiveCron, pair.Key, comment)
+		}
+	}
+
+	// Here we assume that all annotation keys are specified in the same line.
 
-			c[m.Name] = job
-			break // No need to continue since we have found the annotation.
+	if job.Name == "" {
+		job.Name = caseconv.ToLowerCamelCase(m.Name)
+		if snakeCase {
+			job.Name = caseconv.ToSnakeCase(m.Name)
 		}
 	}
 
-	return c, nil
+	if job.Expr == "" {
+		return nil, fmt.Errorf(`missing key "expr" for %s directive in comment: %s`, annotation.DirectiveCron, comment)
+	}
+	if err := checkExpr(m, job.Expr); err != nil {
+		return nil, err
+	}
+
+	if err := checkArgs(m, job.Args); err != nil {
+		return nil, err
+	}
+
+	return job, nil
 }
 
-func validateSignature(m *ifacetool.Method) bool {
-	if len(m.Params) != 1 || len(m.Returns) != 1 {
-		return false
+// checkExpr checks that expr is in one of the formats supported by micron,
+// i.e. "@every <duration>", the predefined schedules (e.g. "@daily") and the
+// cron expressions, and that expr ever fires.
+//
+// To keep the generation deterministic, the occurrence is checked from a
+// fixed reference time rather than the current time, thus an expression,
+// which only fires in the past (e.g. in a specific year), is not reported.
+func checkExpr(m *ifacetool.Method, expr string) error {
+	schedule, err := micron.Parse(expr)
+	if err != nil {
+		return fmt.Errorf("invalid expr %q of the method %s: %v", expr, m.Name, err)
+	}
+	if ref := time.Unix(0, 0).UTC(); !schedule.Next(ref).After(ref) {
+		return fmt.Errorf("invalid expr %q of the method %s, which never fires", expr, m.Name)
 	}
+	return nil
+}
 
-	p := m.Params[0]
-	if _, ok := p.Type.Underlying().(*types.Interface); !ok || p.TypeString != "context.Context" {
-		return false
+// checkArgs checks that args provides the values of all the parameters
+// (except the context) of the method m, and that the values of basic-typed
+// parameters are of the corresponding JSON types.
+func checkArgs(m *ifacetool.Method, args string) error {
+	params := m.Params[1:]
+	if args == "" {
+		if len(params) > 0 {
+			return fmt.Errorf(`missing key "args" for the parameters of the method %s`, m.Name)
+		}
+		return nil
 	}
 
-	r := m.Returns[0]
-	if _, ok := r.Type.Underlying().(*types.Interface); !ok || r.TypeString != "error" {
-		return false
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(args), &values); err != nil {
+		return fmt.Errorf("invalid args of the method %s, which must be a JSON object: %v", m.Name, err)
 	}
 
-	return true
+	for _, p := range params {
+		v, ok := values[p.Name]
+		if !ok {
+			return fmt.Errorf("missing argument %q of the method %s", p.Name, m.Name)
+		}
+		if !matchJSONType(p.Type, v) {
+			return fmt.Errorf("invalid argument %q of the method %s, which must be of type %s", p.Name, m.Name, p.TypeString)
+		}
+		delete(values, p.Name)
+	}
+	for name := range values {
+		return fmt.Errorf("unknown argument %q of the method %s", name, m.Name)
+	}
+
+	return nil
+}
+
+// matchJSONType reports whether the JSON value v can be decoded into a value
+// of type typ. Only basic types are checked.
+func matchJSONType(typ types.Type, v interface{}) bool {
+	basic, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return true
+	}
+
+	info := basic.Info()
+	switch v.(type) {
+	case string:
+		return info&types.IsString != 0
+	case float64:
+		return info&types.IsNumeric != 0
+	case bool:
+		return info&types.IsBoolean != 0
+	default: // null, object or array
+		return false
+	}
 }
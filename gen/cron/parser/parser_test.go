@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/ifacetool"
+)
+
+func TestParse(t *testing.T) {
+	ctxType := types.NewNamed(
+		types.NewTypeName(0, types.NewPackage("context", "context"), "Context", nil),
+		types.NewInterfaceType(nil, nil).Complete(),
+		nil,
+	)
+	ctxParam := &ifacetool.Param{Name: "ctx", TypeString: "context.Context", Type: ctxType}
+	errReturn := &ifacetool.Param{TypeString: "error", Type: types.Universe.Lookup("error").Type()}
+	newParam := func(name string, typ types.Type) *ifacetool.Param {
+		return &ifacetool.Param{Name: name, TypeString: typ.String(), Type: typ}
+	}
+	newMethod := func(name string, doc []string, params ...*ifacetool.Param) *ifacetool.Method {
+		return &ifacetool.Method{
+			Name:    name,
+			Doc:     doc,
+			Params:  append([]*ifacetool.Param{ctxParam}, params...),
+			Returns: []*ifacetool.Param{errReturn},
+		}
+	}
+	region := newParam("region", types.Typ[types.String])
+	size := newParam("size", types.Typ[types.Int])
+
+	tests := []struct {
+		name       string
+		inMethods  []*ifacetool.Method
+		wantJobs   map[string][]*Job
+		wantErrStr string
+	}{
+		{
+			name: "no args",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", []string{"//kun:cron expr='@every 5s' timeout=1m30s"}),
+			},
+			wantJobs: map[string][]*Job{
+				"SendEmail": {{Name: "send_email", Expr: "@every 5s", Timeout: 90 * time.Second}},
+			},
+		},
+		{
+			name: "expression formats",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", []string{
+					"//kun:cron name=every expr='@every 5s'",
+					"//kun:cron name=predefined expr='@daily'",
+					"//kun:cron name=standard expr='*/5 * * * *'",
+					"//kun:cron name=seconds expr='0 */5 * * * * *'",
+				}),
+			},
+			wantJobs: map[string][]*Job{
+				"SendEmail": {
+					{Name: "every", Expr: "@every 5s"},
+					{Name: "predefined", Expr: "@daily"},
+					{Name: "standard", Expr: "*/5 * * * *"},
+					{Name: "seconds", Expr: "0 */5 * * * * *"},
+				},
+			},
+		},
+		{
+			name: "invalid expr",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", []string{"//kun:cron expr='@evry 5s'"}),
+			},
+			wantErrStr: `invalid expr "@evry 5s" of the method SendEmail: missing field(s)`,
+		},
+		{
+			name: "invalid duration",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", []string{"//kun:cron expr='@every -5s'"}),
+			},
+			wantErrStr: `invalid expr "@every -5s" of the method SendEmail, which never fires`,
+		},
+		{
+			name: "impossible date",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", []string{"//kun:cron expr='0 0 0 30 2 * *'"}),
+			},
+			wantErrStr: `invalid expr "0 0 0 30 2 * *" of the method SendEmail, which never fires`,
+		},
+		{
+			name: "specific year",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", []string{"//kun:cron expr='0 0 0 1 1 * 2000'"}),
+			},
+			wantJobs: map[string][]*Job{
+				"SendEmail": {{Name: "send_email", Expr: "0 0 0 1 1 * 2000"}},
+			},
+		},
+		{
+			name: "error with position",
+			inMethods: []*ifacetool.Method{
+				{
+					Name: "SendEmail",
+					Doc: []string{
+						"// SendEmail sends emails.",
+						"//kun:cron expr='*/5 * * *'",
+					},
+					DocPos: []token.Position{
+						{Filename: "service.go", Line: 10, Column: 2},
+						{Filename: "service.go", Line: 11, Column: 2},
+					},
+					Params:  []*ifacetool.Param{ctxParam},
+					Returns: []*ifacetool.Param{errReturn},
+				},
+			},
+			wantErrStr: `service.go:11:2: invalid expr "*/5 * * *" of the method SendEmail: missing field(s)`,
+		},
+		{
+			name: "policies",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendReport", []string{"//kun:cron expr='0 0 9 * * * *' tz=Asia/Shanghai jitter=1m overlap=skip"}),
+			},
+			wantJobs: map[string][]*Job{
+				"SendReport": {{Name: "send_report", Expr: "0 0 9 * * * *", Timezone: "Asia/Shanghai", Jitter: time.Minute, Overlap: "skip"}},
+			},
+		},
+		{
+			name: "invalid tz",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendReport", []string{"//kun:cron expr='0 0 9 * * * *' tz=Mars/Olympus"}),
+			},
+			wantErrStr: `invalid tz "Mars/Olympus" of the method SendReport: unknown time zone Mars/Olympus`,
+		},
+		{
+			name: "invalid jitter",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendReport", []string{"//kun:cron expr='0 0 9 * * * *' jitter=soon"}),
+			},
+			wantErrStr: `invalid jitter "soon" of the method SendReport, which must be a positive duration`,
+		},
+		{
+			name: "invalid overlap",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendReport", []string{"//kun:cron expr='0 0 9 * * * *' overlap=wait"}),
+			},
+			wantErrStr: `invalid overlap "wait" of the method SendReport, which must be one of allow, skip and queue`,
+		},
+		{
+			name: "multiple jobs with args",
+			inMethods: []*ifacetool.Method{
+				newMethod("Sync", []string{
+					`//kun:cron name=sync_eu expr='@every 5s' args='{"region": "eu", "size": 10}'`,
+					`//kun:cron name=sync_us expr='@every 5s' args='{"region": "us", "size": 20}'`,
+				}, region, size),
+			},
+			wantJobs: map[string][]*Job{
+				"Sync": {
+					{Name: "sync_eu", Expr: "@every 5s", Args: `{"region": "eu", "size": 10}`},
+					{Name: "sync_us", Expr: "@every 5s", Args: `{"region": "us", "size": 20}`},
+				},
+			},
+		},
+		{
+			name: "duplicate job names",
+			inMethods: []*ifacetool.Method{
+				newMethod("Sync", []string{
+					`//kun:cron expr='@every 5s' args='{"region": "eu"}'`,
+					`//kun:cron expr='@every 5s' args='{"region": "us"}'`,
+				}, region),
+			},
+			wantErrStr: `duplicate job name "sync" of the method Sync, which must be specified by key "name"`,
+		},
+		{
+			name: "duplicate job names across methods",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", []string{"//kun:cron name=send expr='@every 5s'"}),
+				newMethod("SendReport", []string{"//kun:cron name=send expr='@daily'"}),
+			},
+			wantErrStr: `duplicate job name "send" of the methods SendEmail and SendReport, which must be specified by key "name"`,
+		},
+		{
+			name: "duplicate directives",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", []string{
+					"//kun:cron name=send expr='@every 5s'",
+					"//kun:cron name=send expr='@every 5s'",
+				}),
+			},
+			wantErrStr: `duplicate //kun:cron directive of the method SendEmail`,
+		},
+		{
+			name: "missing args",
+			inMethods: []*ifacetool.Method{
+				newMethod("Sync", []string{"//kun:cron expr='@every 5s'"}, region),
+			},
+			wantErrStr: `missing key "args" for the parameters of the method Sync`,
+		},
+		{
+			name: "missing argument",
+			inMethods: []*ifacetool.Method{
+				newMethod("Sync", []string{`//kun:cron expr='@every 5s' args='{"region": "eu"}'`}, region, size),
+			},
+			wantErrStr: `missing argument "size" of the method Sync`,
+		},
+		{
+			name: "unknown argument",
+			inMethods: []*ifacetool.Method{
+				newMethod("Sync", []string{`//kun:cron expr='@every 5s' args='{"region": "eu", "zone": "a"}'`}, region),
+			},
+			wantErrStr: `unknown argument "zone" of the method Sync`,
+		},
+		{
+			name: "mismatched argument type",
+			inMethods: []*ifacetool.Method{
+				newMethod("Sync", []string{`//kun:cron expr='@every 5s' args='{"region": "eu", "size": "10"}'`}, region, size),
+			},
+			wantErrStr: `invalid argument "size" of the method Sync, which must be of type int`,
+		},
+		{
+			name: "invalid args",
+			inMethods: []*ifacetool.Method{
+				newMethod("Sync", []string{`//kun:cron expr='@every 5s' args=eu`}, region),
+			},
+			wantErrStr: `invalid args of the method Sync, which must be a JSON object: invalid character 'e' looking for beginning of value`,
+		},
+		{
+			name: "invalid timeout",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", []string{"//kun:cron expr='@every 5s' timeout=-1s"}),
+			},
+			wantErrStr: `invalid timeout "-1s" of the method SendEmail, which must be a positive duration`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := Parse(&ifacetool.Data{Methods: tt.inMethods}, true)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(jobs, tt.wantJobs) {
+				t.Fatalf("Jobs: got (%#v), want (%#v)", jobs, tt.wantJobs)
+			}
+		})
+	}
+}
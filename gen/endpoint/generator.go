@@ -221,7 +221,7 @@ func (g *Generator) Generate(pkgInfo *generator.PkgInfo, ifaceData *ifacetool.Da
 					return ""
 				}
 
-				if name == "" || typ == "error" {
+				if name == "" || typ == "error" || isStreamType(typ) {
 					name = "-"
 				} else {
 					// Only useful for adding correct tags for Response fields.
@@ -239,3 +239,14 @@ func (g *Generator) Generate(pkgInfo *generator.PkgInfo, ifaceData *ifacetool.Da
 		TargetFileName: "endpoint.go",
 	})
 }
+
+// isStreamType reports whether typ is a channel or a function, which
+// are used as streams and thus can never be encoded or decoded.
+func isStreamType(typ string) bool {
+	for _, prefix := range []string{"chan ", "<-chan ", "chan<- ", "func("} {
+		if strings.HasPrefix(typ, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package asyncapi
+
+import (
+	"fmt"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/RussellLuo/kun/gen/event/parser"
+	utilannotation "github.com/RussellLuo/kun/gen/util/annotation"
+	"github.com/RussellLuo/kun/gen/util/generator"
+	"github.com/RussellLuo/kun/gen/util/openapi"
+	"github.com/RussellLuo/kun/pkg/caseconv"
+	"github.com/RussellLuo/kun/pkg/ifacetool"
+)
+
+var (
+	template = utilannotation.FileHeader + `
+{{- $endpointPkgPrefix := .PkgInfo.EndpointPkgPrefix}}
+
+package {{.PkgInfo.CurrentPkgName}}
+
+import (
+	"reflect"
+
+	"github.com/RussellLuo/kun/pkg/asyncapi"
+	"github.com/RussellLuo/kun/pkg/oas2"
+
+	{{- if .PkgInfo.EndpointPkgPath}}
+	"{{.PkgInfo.EndpointPkgPath}}"
+	{{- end}}
+)
+
+var (
+	asyncAPIBase = ` + "`" + `asyncapi: "` + "` + asyncapi.Version + `" + `"
+info:
+  title: {{quote .Spec.Metadata.Title}}
+  version: {{quote .Spec.Metadata.Version}}
+  description: {{quote .Spec.Metadata.Description}}
+defaultContentType: "application/json"
+channels:
+{{- range .Spec.Operations}}
+  {{- $description := description .}}
+  {{quote (getEventType .GoMethodName)}}:
+    {{- if $description}}
+    description: {{quote $description}}
+    {{- end}}
+    publish:
+      operationId: "{{.GoMethodName}}"
+      {{- if $description}}
+      summary: {{quote $description}}
+      {{- end}}
+      message:
+        $ref: "#/components/messages/{{.GoMethodName}}"
+{{- end}} {{- /* range .Spec.Operations */}}
+components:
+  messages:
+{{- range .Spec.Operations}}
+  {{- $description := description .}}
+  {{- $payload := getPayload .GoMethodName}}
+    {{.GoMethodName}}:
+      name: {{quote (getEventType .GoMethodName)}}
+      {{- if $description}}
+      summary: {{quote $description}}
+      {{- end}}
+      {{- if $payload.Schema}}
+      payload:
+        $ref: "#/components/schemas/{{$payload.Schema}}"
+      {{- else if $payload.Type}}
+      payload:
+        type: {{$payload.Type}}
+        {{- if $payload.Format}}
+        format: {{$payload.Format}}
+        {{- end}}
+      {{- end}} {{- /* if $payload.Schema */}}
+{{- end}} {{- /* range .Spec.Operations */}}
+` + "`" + `
+)
+
+func getAsyncAPIDefinitions() map[string]oas2.Definition {
+	defs := make(map[string]oas2.Definition)
+
+	{{range .Spec.Operations -}}
+	{{- $payload := getPayload .GoMethodName}}
+	{{- $dataField := getDataField .GoMethodName}}
+
+	{{- if $payload.Schema}}
+	{{- if $dataField}}
+	oas2.AddDefinition(defs, "{{$payload.Schema}}", reflect.ValueOf((&{{$endpointPkgPrefix}}{{.GoMethodName}}Request{}).{{title $dataField}}))
+	{{- else if hasDataStruct .GoMethodName}}
+	oas2.AddDefinition(defs, "{{$payload.Schema}}", reflect.ValueOf(&{{lowerFirst .Name}}EventData{}))
+	{{- else}}
+	oas2.AddDefinition(defs, "{{$payload.Schema}}", reflect.ValueOf(&{{$endpointPkgPrefix}}{{.GoMethodName}}Request{}))
+	{{- end}} {{/* if $dataField */}}
+	{{- end}} {{- /* if $payload.Schema */}}
+
+	{{end -}} {{/* range .Spec.Operations */}}
+
+	return defs
+}
+
+// AsyncAPIDoc returns the AsyncAPI document, in YAML, of the events.
+func AsyncAPIDoc() string {
+	defs := getAsyncAPIDefinitions()
+	return asyncAPIBase + asyncapi.GenSchemas(defs)
+}
+`
+)
+
+type Options struct {
+	SchemaPtr bool
+	SchemaTag string
+	Formatted bool
+}
+
+type Generator struct {
+	opts *Options
+}
+
+func New(opts *Options) *Generator {
+	return &Generator{opts: opts}
+}
+
+// Payload describes the payload of an event message, which is either a
+// reference to a schema or an inline schema of a basic type.
+type Payload struct {
+	Schema string // The name of the schema, if any.
+	Type   string // The JSON type, if inline.
+	Format string // The JSON format, if inline.
+}
+
+func (g *Generator) Generate(pkgInfo *generator.PkgInfo, ifaceData *ifacetool.Data, eventInfo *parser.EventInfo, spec *openapi.Specification) (*generator.File, error) {
+	data := struct {
+		PkgInfo *generator.PkgInfo
+		Spec    *openapi.Specification
+	}{
+		PkgInfo: pkgInfo,
+		Spec:    spec,
+	}
+
+	methodMap := make(map[string]*ifacetool.Method)
+	for _, method := range ifaceData.Methods {
+		methodMap[method.Name] = method
+	}
+
+	return generator.Generate(template, data, generator.Options{
+		Funcs: map[string]interface{}{
+			"title":      caseconv.UpperFirst,
+			"lowerFirst": caseconv.LowerFirst,
+			"quote":      quote,
+			"description": func(op *openapi.Operation) string {
+				return strings.TrimSpace(strings.TrimPrefix(op.Description, op.Name))
+			},
+			"getEventType": func(methodName string) string {
+				return eventInfo.Types[methodName]
+			},
+			"getDataField": func(methodName string) string {
+				return eventInfo.DataFields[methodName]
+			},
+			"hasDataStruct": func(methodName string) bool {
+				_, ok := eventInfo.Fields[methodName]
+				return ok
+			},
+			"getPayload": func(methodName string) Payload {
+				method, ok := methodMap[methodName]
+				if !ok {
+					panic(fmt.Errorf("no method named %q", methodName))
+				}
+				return getPayload(method, eventInfo)
+			},
+		},
+		Formatted:      g.opts.Formatted,
+		TargetFileName: "asyncapi.go",
+	})
+}
+
+// quote quotes s as a double-quoted scalar in YAML, which is also safe to be
+// embedded in a Go raw string literal.
+func quote(s string) string {
+	// The escape sequences of Go are compatible with the ones of YAML, except
+	// that backquotes must be escaped for raw string literals.
+	return strings.ReplaceAll(strconv.Quote(s), "`", `\u0060`)
+}
+
+// getPayload determines the payload of the event message of method m, which
+// is consistent with the event data encoded by the event publisher.
+func getPayload(m *ifacetool.Method, eventInfo *parser.EventInfo) Payload {
+	schema := m.Name + "Payload"
+
+	if dataField := eventInfo.DataFields[m.Name]; dataField != "" {
+		for _, p := range m.Params {
+			if p.Name != dataField {
+				continue
+			}
+			if typ, format := basicJSONType(p.Type); typ != "" {
+				return Payload{Type: typ, Format: format}
+			}
+		}
+		return Payload{Schema: schema}
+	}
+
+	if fields, ok := eventInfo.Fields[m.Name]; ok {
+		if len(fields) == 0 {
+			return Payload{}
+		}
+		return Payload{Schema: schema}
+	}
+
+	for _, p := range m.Params {
+		if p.TypeString != "context.Context" {
+			return Payload{Schema: schema}
+		}
+	}
+	return Payload{}
+}
+
+// basicJSONType returns the JSON type (and format) of t, if t is a basic
+// type or time.Time.
+func basicJSONType(t types.Type) (typ, format string) {
+	if named, ok := t.(*types.Named); ok {
+		obj := named.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+			return "string", "date-time"
+		}
+	}
+
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "", ""
+	}
+
+	info := basic.Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		return "boolean", ""
+	case info&types.IsString != 0:
+		return "string", ""
+	case info&types.IsInteger != 0:
+		return "integer", ""
+	case info&types.IsFloat != 0:
+		return "number", ""
+	default:
+		return "", ""
+	}
+}
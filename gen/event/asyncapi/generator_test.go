@@ -0,0 +1,125 @@
+package asyncapi
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/RussellLuo/kun/gen/event/parser"
+	"github.com/RussellLuo/kun/gen/util/generator"
+	"github.com/RussellLuo/kun/gen/util/openapi"
+	"github.com/RussellLuo/kun/pkg/ifacetool"
+	"sigs.k8s.io/yaml"
+)
+
+func TestGenerator_Generate(t *testing.T) {
+	method := &ifacetool.Method{
+		Name: "UserCreated",
+		Params: []*ifacetool.Param{
+			{Name: "ctx", TypeString: "context.Context"},
+		},
+	}
+	spec := &openapi.Specification{
+		Metadata: &openapi.Metadata{
+			Title:       `The "user" events`,
+			Version:     "1.0.0",
+			Description: "Events of `users`\\n with \"quotes\" and backslashes \\.",
+		},
+		Operations: []*openapi.Operation{
+			{
+				Name:         "UserCreated",
+				GoMethodName: "UserCreated",
+				Description:  `UserCreated is sent when a "new" user is created.`,
+			},
+		},
+	}
+	eventInfo := &parser.EventInfo{
+		Types: map[string]string{"UserCreated": "user.created"},
+	}
+
+	g := New(&Options{})
+	file, err := g.Generate(
+		&generator.PkgInfo{CurrentPkgName: "usersvc"},
+		&ifacetool.Data{Methods: []*ifacetool.Method{method}},
+		eventInfo,
+		spec,
+	)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	doc := asyncAPIBase(t, file.Content)
+	var got map[string]interface{}
+	if err := yaml.Unmarshal([]byte(doc), &got); err != nil {
+		t.Fatalf("err: %v\n%s", err, doc)
+	}
+
+	want := map[string]interface{}{
+		"asyncapi": "2.0.0",
+		"info": map[string]interface{}{
+			"title":       spec.Metadata.Title,
+			"version":     spec.Metadata.Version,
+			"description": spec.Metadata.Description,
+		},
+		"defaultContentType": "application/json",
+		"channels": map[string]interface{}{
+			"user.created": map[string]interface{}{
+				"description": `is sent when a "new" user is created.`,
+				"publish": map[string]interface{}{
+					"operationId": "UserCreated",
+					"summary":     `is sent when a "new" user is created.`,
+					"message": map[string]interface{}{
+						"$ref": "#/components/messages/UserCreated",
+					},
+				},
+			},
+		},
+		"components": map[string]interface{}{
+			"messages": map[string]interface{}{
+				"UserCreated": map[string]interface{}{
+					"name":    "user.created",
+					"summary": `is sent when a "new" user is created.`,
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Doc: got (%#v), want (%#v)", got, want)
+	}
+}
+
+// asyncAPIBase returns the YAML document held by the variable asyncAPIBase
+// in the generated code, where asyncapi.Version is replaced by "2.0.0".
+func asyncAPIBase(t *testing.T, code []byte) string {
+	f, err := goparser.ParseFile(token.NewFileSet(), "asyncapi.go", code, 0)
+	if err != nil {
+		t.Fatalf("err: %v\n%s", err, code)
+	}
+
+	var doc string
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || spec.Names[0].Name != "asyncAPIBase" {
+			return true
+		}
+		ast.Inspect(spec.Values[0], func(n ast.Node) bool {
+			switch x := n.(type) {
+			case *ast.BasicLit:
+				s, err := strconv.Unquote(x.Value)
+				if err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				doc += s
+			case *ast.SelectorExpr:
+				doc += "2.0.0" // asyncapi.Version
+				return false
+			}
+			return true
+		})
+		return false
+	})
+	return doc
+}
@@ -2,6 +2,8 @@ package generator
 
 import (
 	"fmt"
+	"go/types"
+	"strings"
 
 	"github.com/RussellLuo/kun/gen/event/parser"
 	utilannotation "github.com/RussellLuo/kun/gen/util/annotation"
@@ -19,18 +21,28 @@ var (
 package {{.PkgInfo.CurrentPkgName}}
 
 import (
+	{{- if .Opts.Webhook}}
+	"net/http"
+	{{- end}}
+
 	{{- range .Data.Imports}}
 	{{.ImportString}}
 	{{- end}}
 	"github.com/RussellLuo/kun/pkg/eventcodec"
 	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	{{- if .Opts.Webhook}}
+	"github.com/RussellLuo/kun/pkg/eventpubsub/webhook"
+	{{- end}}
 
 	{{- if .PkgInfo.EndpointPkgPath}}
 	"{{.PkgInfo.EndpointPkgPath}}"
 	{{- end}}
 )
 
-func NewEventHandler(svc {{$.Data.SrcPkgQualifier}}{{$.Data.InterfaceName}}, codecs eventcodec.Codecs) eventpubsub.Handler {
+func NewEventHandler(svc {{$.Data.SrcPkgQualifier}}{{$.Data.InterfaceName}}, codecs eventcodec.Codecs, opts ...eventpubsub.RetryOption) eventpubsub.Handler {
+	{{- if hasVersions}}
+	upcasters := eventcodec.UpcastersOf(codecs)
+	{{- end}}
 	var codec eventcodec.Codec
 	handlerSet := eventpubsub.NewHandlerSet()
 
@@ -38,46 +50,99 @@ func NewEventHandler(svc {{$.Data.SrcPkgQualifier}}{{$.Data.InterfaceName}}, cod
 	codec = codecs.EncodeDecoder("{{.GoMethodName}}")
 	handlerSet.Add("{{getEventType .GoMethodName}}", eventpubsub.NewSubscriber(
 		{{$endpointPkgPrefix}}MakeEndpointOf{{.GoMethodName}}(svc),
-		decode{{.Name}}Input(codec),
+		decode{{.Name}}Input(codec{{if getVersion .GoMethodName}}, upcasters{{end}}),
 	))
 
 	{{end -}} {{/* range .Spec.Operations */ -}}
-	return handlerSet
+	return eventpubsub.WrapHandler(handlerSet, opts...)
+}
+
+{{- if .Opts.Webhook}}
+
+// NewWebhookHandler creates an HTTP handler, which receives the events pushed
+// as webhook requests signed by the secret of verifier.
+func NewWebhookHandler(svc {{$.Data.SrcPkgQualifier}}{{$.Data.InterfaceName}}, codecs eventcodec.Codecs, verifier *webhook.Verifier, opts ...eventpubsub.RetryOption) http.Handler {
+	return webhook.NewHandler(NewEventHandler(svc, codecs, opts...), verifier)
 }
+{{- end}} {{/* if .Opts.Webhook */}}
 
 {{- range .Spec.Operations}}
 
 {{- $nonCtxParams := nonCtxParams .Request.Params}}
 {{- $methodHasNonCtxParams := methodHasNonCtxParams .GoMethodName}}
 {{- $hasBodyParams := hasBodyParams $nonCtxParams}}
-{{- $dataField := getDataField}}
-
-func decode{{.Name}}Input(codec eventcodec.Codec) eventpubsub.DecodeInputFunc {
+{{- $dataField := getDataField .GoMethodName}}
+{{- $hasDataStruct := hasDataStruct .GoMethodName}}
+{{- $fields := getFields .GoMethodName}}
+{{- $version := getVersion .GoMethodName}}
+{{- $decodesData := or $dataField $fields (and (not $hasDataStruct) $hasBodyParams)}}
+{{- $eventData := "event.Data()"}}
+{{- if $version}}{{$eventData = "_data"}}{{end}}
+
+func decode{{.Name}}Input(codec eventcodec.Codec{{if $version}}, upcasters *eventpubsub.Upcasters{{end}}) eventpubsub.DecodeInputFunc {
 	return func(_ context.Context, event eventpubsub.Event) (interface{}, error) {
+		{{- if $version}}
+		{{- if $decodesData}}
+		_data, err := upcasters.Upcast(event, {{$version}})
+		if err != nil {
+			return nil, err
+		}
+		{{- else}}
+		if _, err := upcasters.Upcast(event, {{$version}}); err != nil {
+			return nil, err
+		}
+		{{- end}} {{/* if $decodesData */}}
+		{{/* Keep a blank line */}}
+		{{- end}} {{/* if $version */}}
+
 		{{- if $methodHasNonCtxParams}}
 		var input {{$endpointPkgPrefix}}{{.GoMethodName}}Request
 
 		{{end -}}
 
 		{{if $dataField -}}
-		if err := codec.Decode(event.Data(), &input.{{title $dataField}}); err != nil {
+		if err := codec.Decode({{$eventData}}, &input.{{title $dataField}}); err != nil {
 			return nil, err
 		}
+		{{else if $hasDataStruct -}}
+		{{if $fields -}}
+		var data {{lowerFirst .Name}}EventData
+		if err := codec.Decode({{$eventData}}, &data); err != nil {
+			return nil, err
+		}
+		{{- range $fields}}
+		input.{{title .Path}} = data.{{.GoName}}
+		{{- end}} {{/* range $fields */}}
+		{{end -}} {{/* if $fields */}}
 		{{else if $hasBodyParams -}}
-		if err := codec.Decode(event.Data(), &input); err != nil {
+		if err := codec.Decode({{$eventData}}, &input); err != nil {
 			return nil, err
 		}
 		{{end -}} {{/* if $dataField */}}
 
+		{{- range getAttrs .GoMethodName}}
+		input.{{title .Param}} = eventpubsub.{{if .Time}}TimeAttribute{{else}}StringAttribute{{end}}(event, "{{.Name}}")
+		{{- end}} {{/* range getAttrs .GoMethodName */}}
+
 		{{- if $methodHasNonCtxParams}}
 
 		return {{addAmpersand "input"}}, nil
-		{{- else -}}
+		{{- else}}
 		return nil, nil
 		{{- end}} {{/* if $methodHasNonCtxParams */}}
 	}
 }
 
+{{- if $fields}}
+
+// {{lowerFirst .Name}}EventData is the event data of {{.GoMethodName}}.
+type {{lowerFirst .Name}}EventData struct {
+	{{- range $fields}}
+	{{.GoName}} {{typeString .Type}} {{addTag .Name}}
+	{{- end}}
+}
+{{- end}} {{/* if $fields */}}
+
 {{- end}} {{/* range .Spec.Operations */}}
 
 // EventPublisher implements {{$.Data.SrcPkgQualifier}}{{$.Data.InterfaceName}} on the publisher side.
@@ -91,28 +156,57 @@ func decode{{.Name}}Input(codec eventcodec.Codec) eventpubsub.DecodeInputFunc {
 type EventPublisher struct {
 	publisher eventpubsub.Publisher
 	codecs    eventcodec.Codecs
+	source    string
 }
 
 func NewEventPublisher(publisher eventpubsub.Publisher, codecs eventcodec.Codecs) *EventPublisher {
 	return &EventPublisher{
 		publisher: publisher,
 		codecs:    codecs,
+		source:    "/{{.Data.SrcPkgName}}/{{.Data.InterfaceName}}",
 	}
 }
 
+// WithSource sets the CloudEvents attribute source of the published events,
+// which defaults to "/{{.Data.SrcPkgName}}/{{.Data.InterfaceName}}".
+func (p *EventPublisher) WithSource(source string) *EventPublisher {
+	p.source = source
+	return p
+}
+
 {{- range .Spec.Operations}}
 
 {{- $nonCtxParams := nonCtxParams .Request.Params}}
-{{- $methodHasNonCtxParams := methodHasNonCtxParams .GoMethodName}}
-{{- $dataField := getDataField}}
+{{- $dataField := getDataField .GoMethodName}}
+{{- $hasDataStruct := hasDataStruct .GoMethodName}}
+{{- $fields := getFields .GoMethodName}}
 {{- $method := getMethod .GoMethodName}}
 
 func (p *EventPublisher) {{$method.Name}}({{$method.ArgList}}) {{$method.ReturnArgNamedValueList}} {
-	{{- if $nonCtxParams}}
+	attrs := eventpubsub.NewAttributes("{{getEventType .GoMethodName}}", p.source)
+	{{- range getAttrs .GoMethodName}}
+	attrs.Set("{{.Name}}", {{.Param}})
+	{{- end}} {{/* range getAttrs .GoMethodName */}}
+	{{- with getKey .GoMethodName}}
+	attrs.Set(eventpubsub.AttrPartitionKey, {{.}})
+	{{- end}}
+	{{- with getVersion .GoMethodName}}
+	attrs.Set(eventpubsub.AttrDataVersion, {{.}})
+	{{- end}}
+
+	{{- if or $dataField $fields (and (not $hasDataStruct) $nonCtxParams)}}
+
 	codec := p.codecs.EncodeDecoder("{{.GoMethodName}}")
+	attrs.DataContentType = eventcodec.ContentType(codec)
 
 	{{if $dataField -}}
 	_data, err := codec.Encode({{$dataField}})
+	{{- else if $fields}}
+	_data, err := codec.Encode(&{{lowerFirst .Name}}EventData{
+		{{- range $fields}}
+		{{.GoName}}: {{.Path}},
+		{{- end}}
+	})
 	{{- else}}
 	_data, err := codec.Encode({{addAmpersand ""}}{{$endpointPkgPrefix}}{{.GoMethodName}}Request{
 		{{- range $nonCtxParams}}
@@ -123,14 +217,12 @@ func (p *EventPublisher) {{$method.Name}}({{$method.ArgList}}) {{$method.ReturnA
 	if err != nil {
 		return err
 	}
-	{{- end}} {{/* if $nonCtxParams */}}
-
-	{{- if $nonCtxParams}}
 
-	return p.publisher.Publish({{getCtxArg .GoMethodName}}, "{{getEventType .GoMethodName}}", _data)
+	return eventpubsub.Publish({{getCtxArg .GoMethodName}}, p.publisher, eventpubsub.NewCloudEvent(attrs, _data))
 	{{- else}}
-	return p.publisher.Publish({{getCtxArg .GoMethodName}}, "{{getEventType .GoMethodName}}", nil)
-	{{- end}} {{/* if $nonCtxParams */}}
+
+	return eventpubsub.Publish({{getCtxArg .GoMethodName}}, p.publisher, eventpubsub.NewCloudEvent(attrs, nil))
+	{{- end}}
 }
 
 {{- end}} {{/* range .Spec.Operations */}}
@@ -141,6 +233,9 @@ type Options struct {
 	SchemaPtr bool
 	SchemaTag string
 	Formatted bool
+
+	// Webhook indicates whether to generate NewWebhookHandler.
+	Webhook bool
 }
 
 type Generator struct {
@@ -169,6 +264,22 @@ func (g *Generator) Generate(pkgInfo *generator.PkgInfo, ifaceData *ifacetool.Da
 		methodMap[method.Name] = method
 	}
 
+	// qualifier qualifies the types used in the event data structs.
+	qualifier := func(pkg *types.Package) string {
+		for _, imp := range ifaceData.Imports {
+			if imp.Path == pkg.Path() {
+				if imp.Alias != "" {
+					return imp.Alias
+				}
+				return pkg.Name()
+			}
+		}
+		if pkg.Name() == ifaceData.SrcPkgName {
+			return strings.TrimSuffix(ifaceData.SrcPkgQualifier, ".")
+		}
+		return pkg.Name()
+	}
+
 	return generator.Generate(template, data, generator.Options{
 		Funcs: map[string]interface{}{
 			"title":      caseconv.UpperFirst,
@@ -208,8 +319,53 @@ func (g *Generator) Generate(pkgInfo *generator.PkgInfo, ifaceData *ifacetool.Da
 				}
 				return false
 			},
-			"getDataField": func() string {
-				return eventInfo.DataField
+			"getDataField": func(methodName string) string {
+				return eventInfo.DataFields[methodName]
+			},
+			"getAttrs": func(methodName string) []*parser.Attr {
+				return eventInfo.Attrs[methodName]
+			},
+			"hasDataStruct": func(methodName string) bool {
+				_, ok := eventInfo.Fields[methodName]
+				return ok
+			},
+			"getFields": func(methodName string) []*parser.Field {
+				return eventInfo.Fields[methodName]
+			},
+			"typeString": func(t types.Type) string {
+				return types.TypeString(t, qualifier)
+			},
+			"addTag": func(name string) string {
+				if g.opts.SchemaTag == "" {
+					return ""
+				}
+				return fmt.Sprintf("`%s:\"%s\"`", g.opts.SchemaTag, name)
+			},
+			"getKey": func(methodName string) string {
+				param := eventInfo.Keys[methodName]
+				if param == "" {
+					return ""
+				}
+
+				// Convert the argument to a string.
+				for _, p := range methodMap[methodName].Params {
+					if p.Name != param {
+						continue
+					}
+					if b, ok := p.Type.Underlying().(*types.Basic); ok && b.Info()&types.IsString != 0 {
+						if p.TypeString == "string" {
+							return param
+						}
+						return "string(" + param + ")"
+					}
+				}
+				return "fmt.Sprint(" + param + ")"
+			},
+			"getVersion": func(methodName string) int {
+				return eventInfo.Versions[methodName]
+			},
+			"hasVersions": func() bool {
+				return len(eventInfo.Versions) > 0
 			},
 			"getEventType": func(methodName string) string {
 				return eventInfo.Types[methodName]
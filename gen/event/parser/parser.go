@@ -2,27 +2,82 @@ package parser
 
 import (
 	"fmt"
+	"go/types"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
+	httpparser "github.com/RussellLuo/kun/gen/http/parser"
 	"github.com/RussellLuo/kun/gen/util/annotation"
 	"github.com/RussellLuo/kun/pkg/caseconv"
 	"github.com/RussellLuo/kun/pkg/ifacetool"
 )
 
 var (
-	reEvent = regexp.MustCompile(`^` + annotation.DirectiveEvent.String() + `(.*)$`)
+	reEvent    = regexp.MustCompile(`^` + annotation.DirectiveEvent.String() + `(.*)$`)
+	reAttrName = regexp.MustCompile(`^[a-z0-9]+$`)
 )
 
 type EventInfo struct {
-	Types     map[string]string // method names => event types
-	DataField string
+	Types      map[string]string   // method names => event types
+	DataFields map[string]string   // method names => names of the arguments mapped to the event data
+	Attrs      map[string][]*Attr  // method names => attribute bindings
+	Fields     map[string][]*Field // method names => event data fields
+	Keys       map[string]string   // method names => names of the arguments used as the partition keys
+	Versions   map[string]int      // method names => versions of the event data
+}
+
+// Attr binds a method argument to a CloudEvents context attribute.
+type Attr struct {
+	Param string // The name of the method argument.
+	Name  string // The name of the context attribute.
+	Time  bool   // Whether the argument is of type time.Time.
+}
+
+// Field binds a method argument, or a field of a struct argument, to a field
+// of the event data.
+//
+// Fields are only available for methods whose event data needs a dedicated
+// struct, i.e. methods with attribute bindings or aggregate bindings, but
+// without a data argument. For other methods, the event data is always the
+// endpoint request.
+type Field struct {
+	Name       string     // The name of the event data field.
+	Param      string     // The name of the method argument.
+	ParamField string     // The name of the struct field of the argument, if aggregate.
+	Type       types.Type // The type of the argument (or the struct field).
+}
+
+// GoName returns the name of the corresponding field in the Go struct of
+// the event data.
+func (f *Field) GoName() string {
+	return caseconv.UpperFirst(f.Param) + f.ParamField
+}
+
+// Path returns the selector expression of the argument (or the struct field).
+func (f *Field) Path() string {
+	if f.ParamField != "" {
+		return f.Param + "." + f.ParamField
+	}
+	return f.Param
 }
 
 func Parse(data *ifacetool.Data, snakeCase bool) (*EventInfo, error) {
-	e := &EventInfo{Types: make(map[string]string)}
+	e := &EventInfo{
+		Types:      make(map[string]string),
+		DataFields: make(map[string]string),
+		Attrs:      make(map[string][]*Attr),
+		Fields:     make(map[string][]*Field),
+		Keys:       make(map[string]string),
+		Versions:   make(map[string]int),
+	}
 
 	for _, m := range data.Methods {
+		// Bindings specified by fields=, i.e. argument names => data field names.
+		var bindings map[string]string
+		var isEvent bool
+
 		for _, comment := range m.Doc {
 			if annotation.Directive(comment).Dialect() != annotation.DialectEvent {
 				continue
@@ -33,12 +88,10 @@ func Parse(data *ifacetool.Data, snakeCase bool) (*EventInfo, error) {
 				return nil, fmt.Errorf("invalid %s directive: %s", annotation.DirectiveEvent, comment)
 			}
 
+			isEvent = true
+
 			value := strings.TrimSpace(result[1])
 			if value == "" {
-				e.Types[m.Name] = caseconv.ToLowerCamelCase(m.Name)
-				if snakeCase {
-					e.Types[m.Name] = caseconv.ToSnakeCase(m.Name)
-				}
 				continue
 			}
 
@@ -57,22 +110,276 @@ func Parse(data *ifacetool.Data, snakeCase bool) (*EventInfo, error) {
 					if !isMethodParam(m, v) {
 						return nil, fmt.Errorf("no argument %q declared in the method %s", v, m.Name)
 					}
-					e.DataField = v
+					e.DataFields[m.Name] = v
+				case "attrs":
+					attrs, err := parseAttrs(m, v)
+					if err != nil {
+						return nil, err
+					}
+					e.Attrs[m.Name] = attrs
+				case "fields":
+					b, err := parseBindings(m, v)
+					if err != nil {
+						return nil, err
+					}
+					bindings = b
+				case "key":
+					if err := checkKey(m, v); err != nil {
+						return nil, err
+					}
+					e.Keys[m.Name] = v
+				case "version":
+					version, err := strconv.Atoi(v)
+					if err != nil || version < 1 {
+						return nil, fmt.Errorf("invalid version %q of the method %s, which must be a positive integer", v, m.Name)
+					}
+					e.Versions[m.Name] = version
 				default:
 					return nil, fmt.Errorf(`unrecognized %s key "%s" in comment: %s`, annotation.DirectiveEvent, k, comment)
 				}
 			}
 		}
+
+		if isEvent && e.Types[m.Name] == "" {
+			// The event type defaults to the method name.
+			e.Types[m.Name] = caseconv.ToLowerCamelCase(m.Name)
+			if snakeCase {
+				e.Types[m.Name] = caseconv.ToSnakeCase(m.Name)
+			}
+		}
+
+		dataField := e.DataFields[m.Name]
+		if dataField != "" && bindings != nil {
+			return nil, fmt.Errorf("fields and data cannot be specified at the same time in the method %s", m.Name)
+		}
+		for _, a := range e.Attrs[m.Name] {
+			if a.Name == "partitionkey" && e.Keys[m.Name] != "" {
+				return nil, fmt.Errorf("attribute %q of the method %s cannot be specified along with key", a.Name, m.Name)
+			}
+			if a.Name == "dataversion" && e.Versions[m.Name] != 0 {
+				return nil, fmt.Errorf("attribute %q of the method %s cannot be specified along with version", a.Name, m.Name)
+			}
+			if a.Param == dataField {
+				return nil, fmt.Errorf("argument %q of the method %s cannot be mapped to both the event data and attribute %q", a.Param, m.Name, a.Name)
+			}
+			if _, ok := bindings[a.Param]; ok {
+				return nil, fmt.Errorf("argument %q of the method %s cannot be mapped to both the event data and attribute %q", a.Param, m.Name, a.Name)
+			}
+		}
+
+		if dataField == "" && (bindings != nil || len(e.Attrs[m.Name]) > 0) {
+			fields, err := parseFields(m, bindings, e.Attrs[m.Name], snakeCase)
+			if err != nil {
+				return nil, err
+			}
+			e.Fields[m.Name] = fields
+		}
 	}
 
 	return e, nil
 }
 
+// parseAttrs parses the attribute bindings in the form of
+// `<argName>:<attrName>,...`, where `:<attrName>` can be omitted if the
+// attribute has the same name as the argument (in lower case).
+func parseAttrs(m *ifacetool.Method, value string) (attrs []*Attr, err error) {
+	for _, s := range strings.Split(value, ",") {
+		parts := strings.SplitN(s, ":", 2)
+		param, name := parts[0], strings.ToLower(parts[0])
+		if len(parts) == 2 {
+			name = parts[1]
+		}
+
+		p := methodParam(m, param)
+		if p == nil {
+			return nil, fmt.Errorf("no argument %q declared in the method %s", param, m.Name)
+		}
+
+		switch name {
+		case "specversion", "type", "datacontenttype":
+			return nil, fmt.Errorf("attribute %q of the method %s is reserved", name, m.Name)
+		}
+		if !reAttrName.MatchString(name) {
+			return nil, fmt.Errorf("invalid attribute name %q of the method %s", name, m.Name)
+		}
+
+		attr := &Attr{Param: param, Name: name}
+		switch p.TypeString {
+		case "string":
+		case "time.Time":
+			attr.Time = true
+		default:
+			return nil, fmt.Errorf("argument %q (bound to attribute %q) of the method %s must be of type string or time.Time", param, name, m.Name)
+		}
+		if name == "time" && !attr.Time {
+			return nil, fmt.Errorf("argument %q (bound to attribute %q) of the method %s must be of type time.Time", param, name, m.Name)
+		}
+
+		attrs = append(attrs, attr)
+	}
+	return attrs, nil
+}
+
+// checkKey checks whether the argument named param, which is used as the
+// partition key, is of a basic type.
+func checkKey(m *ifacetool.Method, param string) error {
+	p := methodParam(m, param)
+	if p == nil {
+		return fmt.Errorf("no argument %q declared in the method %s", param, m.Name)
+	}
+
+	if b, ok := p.Type.Underlying().(*types.Basic); !ok || b.Info()&(types.IsBoolean|types.IsNumeric|types.IsString) == 0 {
+		return fmt.Errorf("argument %q (used as the partition key) of the method %s must be of a basic type", param, m.Name)
+	}
+	return nil
+}
+
+// parseBindings parses the field bindings in the form of
+// `<argName>:<fieldName>,...`, where `:<fieldName>` can be omitted. An
+// argument of struct type, without `:<fieldName>`, is aggregate, that is,
+// each of its struct fields is bound to a data field per its struct tag.
+func parseBindings(m *ifacetool.Method, value string) (map[string]string, error) {
+	bindings := make(map[string]string)
+	for _, s := range strings.Split(value, ",") {
+		parts := strings.SplitN(s, ":", 2)
+		param, name := parts[0], ""
+		if len(parts) == 2 {
+			if name = parts[1]; name == "" {
+				return nil, fmt.Errorf("empty field name for argument %q of the method %s", param, m.Name)
+			}
+		}
+
+		if !isMethodParam(m, param) {
+			return nil, fmt.Errorf("no argument %q declared in the method %s", param, m.Name)
+		}
+		bindings[param] = name
+	}
+	return bindings, nil
+}
+
+// parseFields determines the data fields of method m, to which all the
+// arguments (except context.Context and the ones bound to attributes) are
+// bound.
+func parseFields(m *ifacetool.Method, bindings map[string]string, attrs []*Attr, snakeCase bool) (fields []*Field, err error) {
+	defaultName := func(name string) string {
+		if snakeCase {
+			return caseconv.ToSnakeCase(name)
+		}
+		return caseconv.ToLowerCamelCase(name)
+	}
+
+	isAttr := make(map[string]bool)
+	for _, a := range attrs {
+		isAttr[a.Param] = true
+	}
+
+	seen := make(map[string]string) // data field names => argument names
+	addField := func(f *Field) error {
+		if param, ok := seen[f.Name]; ok {
+			return fmt.Errorf("duplicate data field %q of arguments %q and %q in the method %s", f.Name, param, f.Path(), m.Name)
+		}
+		seen[f.Name] = f.Path()
+		fields = append(fields, f)
+		return nil
+	}
+
+	for _, p := range m.Params {
+		if p.TypeString == "context.Context" || isAttr[p.Name] {
+			continue
+		}
+
+		name, ok := bindings[p.Name]
+		if !ok || name != "" {
+			if name == "" {
+				name = defaultName(p.Name)
+			}
+			if err := addField(&Field{Name: name, Param: p.Name, Type: p.Type}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// This is an aggregate binding.
+		aggFields, err := parseAggregateFields(m, p, snakeCase)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range aggFields {
+			if err := addField(f); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return fields, nil
+}
+
+// parseAggregateFields binds the fields of the struct argument p to data
+// fields, by following the same rules as HTTP request parameters. That is,
+// the data field name is specified by `kun:"name=<name>"` (or omitted by
+// `kun:"-"`), and only fields of basic types or slices of basic types are
+// supported.
+func parseAggregateFields(m *ifacetool.Method, p *ifacetool.Param, snakeCase bool) (fields []*Field, err error) {
+	st, ok := p.Type.Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("argument %q of the method %s must be a struct to be aggregate", p.Name, m.Name)
+	}
+
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		if !v.Exported() {
+			continue
+		}
+
+		var typeName string
+		switch ft := v.Type().Underlying().(type) {
+		case *types.Basic:
+			typeName = ft.Name()
+		case *types.Slice:
+			et, ok := ft.Elem().Underlying().(*types.Basic)
+			if !ok {
+				return nil, fmt.Errorf("event data field cannot be mapped to struct field %q (of type %v) from argument %q in method %s", v.Name(), ft, p.Name, m.Name)
+			}
+			typeName = "[]" + et.Name()
+		default:
+			return nil, fmt.Errorf("event data field cannot be mapped to struct field %q (of type %v) from argument %q in method %s", v.Name(), ft, p.Name, m.Name)
+		}
+
+		field := &httpparser.StructField{
+			Name:      v.Name(),
+			CamelCase: !snakeCase,
+			Type:      typeName,
+			Tag:       reflect.StructTag(st.Tag(i)),
+		}
+		if err := field.Parse(); err != nil {
+			return nil, err
+		}
+		if field.Omitted {
+			continue
+		}
+
+		fields = append(fields, &Field{
+			// The location of the parameter (if any) is ignored, and only
+			// the first parameter is used, if there are several ones.
+			Name:       field.Params[0].Name,
+			Param:      p.Name,
+			ParamField: v.Name(),
+			Type:       v.Type(),
+		})
+	}
+
+	return fields, nil
+}
+
 func isMethodParam(m *ifacetool.Method, name string) bool {
+	return methodParam(m, name) != nil
+}
+
+func methodParam(m *ifacetool.Method, name string) *ifacetool.Param {
 	for _, p := range m.Params {
 		if p.Name == name {
-			return true
+			return p
 		}
 	}
-	return false
+	return nil
 }
@@ -0,0 +1,237 @@
+package parser
+
+import (
+	"go/types"
+	"reflect"
+	"testing"
+
+	"github.com/RussellLuo/kun/pkg/ifacetool"
+)
+
+func TestParse(t *testing.T) {
+	str := types.Typ[types.String]
+	ctxParam := &ifacetool.Param{Name: "ctx", TypeString: "context.Context"}
+	newParam := func(name string, typ types.Type) *ifacetool.Param {
+		return &ifacetool.Param{Name: name, TypeString: typ.String(), Type: typ}
+	}
+	keyType := types.NewStruct([]*types.Var{
+		types.NewField(0, nil, "UserID", str, false),
+		types.NewField(0, nil, "OrgID", str, false),
+		types.NewField(0, nil, "Secret", str, false),
+	}, []string{
+		`kun:"name=uid"`,
+		``,
+		`kun:"name=-"`,
+	})
+
+	tests := []struct {
+		name       string
+		inMethods  []*ifacetool.Method
+		wantInfo   *EventInfo
+		wantErrStr string
+	}{
+		{
+			name: "per-method data",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:   "Created",
+					Doc:    []string{"//kun:event data=a"},
+					Params: []*ifacetool.Param{ctxParam, newParam("a", str)},
+				},
+				{
+					Name:   "Deleted",
+					Doc:    []string{"//kun:event type=deleted data=b"},
+					Params: []*ifacetool.Param{ctxParam, newParam("b", str)},
+				},
+			},
+			wantInfo: &EventInfo{
+				Types:      map[string]string{"Created": "created", "Deleted": "deleted"},
+				DataFields: map[string]string{"Created": "a", "Deleted": "b"},
+				Attrs:      map[string][]*Attr{},
+				Fields:     map[string][]*Field{},
+				Keys:       map[string]string{},
+				Versions:   map[string]int{},
+			},
+		},
+		{
+			name: "directives across lines",
+			inMethods: []*ifacetool.Method{
+				{
+					Name: "Created",
+					Doc: []string{
+						"//kun:event type=user.created",
+						"//kun:event data=a",
+					},
+					Params: []*ifacetool.Param{ctxParam, newParam("a", str)},
+				},
+				{
+					Name: "UserDeleted",
+					Doc: []string{
+						"//kun:event data=b",
+						"//kun:event attrs=id",
+					},
+					Params: []*ifacetool.Param{ctxParam, newParam("b", str), newParam("id", str)},
+				},
+			},
+			wantInfo: &EventInfo{
+				Types:      map[string]string{"Created": "user.created", "UserDeleted": "user_deleted"},
+				DataFields: map[string]string{"Created": "a", "UserDeleted": "b"},
+				Attrs:      map[string][]*Attr{"UserDeleted": {{Param: "id", Name: "id"}}},
+				Fields:     map[string][]*Field{},
+				Keys:       map[string]string{},
+				Versions:   map[string]int{},
+			},
+		},
+		{
+			name: "fields",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:   "KeyAdded",
+					Doc:    []string{"//kun:event fields=key,note:memo attrs=eventID:id"},
+					Params: []*ifacetool.Param{ctxParam, newParam("key", keyType), newParam("note", str), newParam("eventID", str)},
+				},
+			},
+			wantInfo: &EventInfo{
+				Types:      map[string]string{"KeyAdded": "key_added"},
+				DataFields: map[string]string{},
+				Attrs: map[string][]*Attr{
+					"KeyAdded": {{Param: "eventID", Name: "id"}},
+				},
+				Fields: map[string][]*Field{
+					"KeyAdded": {
+						{Name: "uid", Param: "key", ParamField: "UserID", Type: str},
+						{Name: "org_id", Param: "key", ParamField: "OrgID", Type: str},
+						{Name: "memo", Param: "note", Type: str},
+					},
+				},
+				Keys:     map[string]string{},
+				Versions: map[string]int{},
+			},
+		},
+		{
+			name: "partition key",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:   "Updated",
+					Doc:    []string{"//kun:event key=userID"},
+					Params: []*ifacetool.Param{ctxParam, newParam("userID", types.Typ[types.Int]), newParam("name", str)},
+				},
+			},
+			wantInfo: &EventInfo{
+				Types:      map[string]string{"Updated": "updated"},
+				DataFields: map[string]string{},
+				Attrs:      map[string][]*Attr{},
+				Fields:     map[string][]*Field{},
+				Keys:       map[string]string{"Updated": "userID"},
+				Versions:   map[string]int{},
+			},
+		},
+		{
+			name: "version",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:   "Updated",
+					Doc:    []string{"//kun:event version=2"},
+					Params: []*ifacetool.Param{ctxParam, newParam("name", str)},
+				},
+			},
+			wantInfo: &EventInfo{
+				Types:      map[string]string{"Updated": "updated"},
+				DataFields: map[string]string{},
+				Attrs:      map[string][]*Attr{},
+				Fields:     map[string][]*Field{},
+				Keys:       map[string]string{},
+				Versions:   map[string]int{"Updated": 2},
+			},
+		},
+		{
+			name: "invalid version",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:   "Updated",
+					Doc:    []string{"//kun:event version=v2"},
+					Params: []*ifacetool.Param{ctxParam, newParam("name", str)},
+				},
+			},
+			wantErrStr: `invalid version "v2" of the method Updated, which must be a positive integer`,
+		},
+		{
+			name: "non-basic partition key",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:   "Updated",
+					Doc:    []string{"//kun:event key=key"},
+					Params: []*ifacetool.Param{ctxParam, newParam("key", keyType)},
+				},
+			},
+			wantErrStr: `argument "key" (used as the partition key) of the method Updated must be of a basic type`,
+		},
+		{
+			name: "partition key and attribute",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:   "Updated",
+					Doc:    []string{"//kun:event key=a attrs=b:partitionkey"},
+					Params: []*ifacetool.Param{ctxParam, newParam("a", str), newParam("b", str)},
+				},
+			},
+			wantErrStr: `attribute "partitionkey" of the method Updated cannot be specified along with key`,
+		},
+		{
+			name: "fields and data",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:   "Created",
+					Doc:    []string{"//kun:event data=a fields=a"},
+					Params: []*ifacetool.Param{ctxParam, newParam("a", str)},
+				},
+			},
+			wantErrStr: "fields and data cannot be specified at the same time in the method Created",
+		},
+		{
+			name: "non-struct aggregate",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:   "Created",
+					Doc:    []string{"//kun:event fields=a"},
+					Params: []*ifacetool.Param{ctxParam, newParam("a", str)},
+				},
+			},
+			wantErrStr: `argument "a" of the method Created must be a struct to be aggregate`,
+		},
+		{
+			name: "duplicate fields",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:   "Created",
+					Doc:    []string{"//kun:event fields=a:uid,key"},
+					Params: []*ifacetool.Param{ctxParam, newParam("a", str), newParam("key", keyType)},
+				},
+			},
+			wantErrStr: `duplicate data field "uid" of arguments "a" and "key.UserID" in the method Created`,
+		},
+		{
+			name: "attribute bound to data",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:   "Created",
+					Doc:    []string{"//kun:event data=a attrs=a:subject"},
+					Params: []*ifacetool.Param{ctxParam, newParam("a", str)},
+				},
+			},
+			wantErrStr: `argument "a" of the method Created cannot be mapped to both the event data and attribute "subject"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := Parse(&ifacetool.Data{Methods: tt.inMethods}, true)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if !reflect.DeepEqual(info, tt.wantInfo) {
+				t.Fatalf("EventInfo: got (%#v), want (%#v)", info, tt.wantInfo)
+			}
+		})
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	crongenerator "github.com/RussellLuo/kun/gen/cron/generator"
 	cronparser "github.com/RussellLuo/kun/gen/cron/parser"
 	"github.com/RussellLuo/kun/gen/endpoint"
+	eventasyncapi "github.com/RussellLuo/kun/gen/event/asyncapi"
 	eventgenerator "github.com/RussellLuo/kun/gen/event/generator"
 	eventparser "github.com/RussellLuo/kun/gen/event/parser"
 	"github.com/RussellLuo/kun/gen/grpc/grpc"
@@ -18,6 +20,9 @@ import (
 	"github.com/RussellLuo/kun/gen/http/httpclient"
 	"github.com/RussellLuo/kun/gen/http/oas2"
 	httpparser "github.com/RussellLuo/kun/gen/http/parser"
+	httpspec "github.com/RussellLuo/kun/gen/http/spec"
+	taskgenerator "github.com/RussellLuo/kun/gen/task/generator"
+	taskparser "github.com/RussellLuo/kun/gen/task/parser"
 	"github.com/RussellLuo/kun/gen/util/docutil"
 	"github.com/RussellLuo/kun/gen/util/generator"
 	"github.com/RussellLuo/kun/gen/util/openapi"
@@ -33,6 +38,15 @@ type Options struct {
 	Formatted     bool
 	SnakeCase     bool
 	EnableTracing bool
+
+	// GRPCHTTPRules indicates whether to add google.api.http options, which
+	// are converted from the HTTP annotations, to the generated .proto file.
+	GRPCHTTPRules bool
+	// ProtoPaths are the directories in which protoc searches for imports.
+	ProtoPaths []string
+	// EventWebhook indicates whether to generate the webhook handler for
+	// the event transport.
+	EventWebhook bool
 }
 
 type Generator struct {
@@ -43,7 +57,9 @@ type Generator struct {
 	proto      *proto.Generator
 	grpc       *grpc.Generator
 	event      *eventgenerator.Generator
+	asyncapi   *eventasyncapi.Generator
 	cron       *crongenerator.Generator
+	task       *taskgenerator.Generator
 
 	opts *Options
 }
@@ -76,6 +92,7 @@ func New(opts *Options) *Generator {
 			SchemaPtr: opts.SchemaPtr,
 			SchemaTag: opts.SchemaTag,
 			Formatted: opts.Formatted,
+			HTTPRules: opts.GRPCHTTPRules,
 		}),
 		grpc: grpc.New(&grpc.Options{
 			SchemaPtr: opts.SchemaPtr,
@@ -86,12 +103,23 @@ func New(opts *Options) *Generator {
 			SchemaPtr: opts.SchemaPtr,
 			SchemaTag: opts.SchemaTag,
 			Formatted: opts.Formatted,
+			Webhook:   opts.EventWebhook,
+		}),
+		asyncapi: eventasyncapi.New(&eventasyncapi.Options{
+			SchemaPtr: opts.SchemaPtr,
+			SchemaTag: opts.SchemaTag,
+			Formatted: opts.Formatted,
 		}),
 		cron: crongenerator.New(&crongenerator.Options{
 			SchemaPtr: opts.SchemaPtr,
 			SchemaTag: opts.SchemaTag,
 			Formatted: opts.Formatted,
 		}),
+		task: taskgenerator.New(&taskgenerator.Options{
+			SchemaPtr: opts.SchemaPtr,
+			SchemaTag: opts.SchemaTag,
+			Formatted: opts.Formatted,
+		}),
 		opts: opts,
 	}
 }
@@ -123,7 +151,7 @@ func (g *Generator) Generate(srcFilename, interfaceName string) (files []*genera
 	}
 
 	if transport.Has(docutil.TransportGRPC) {
-		grpcFiles, err := g.generateGRPC(data)
+		grpcFiles, err := g.generateGRPC(data, newSpec)
 		if err != nil {
 			return files, err
 		}
@@ -146,6 +174,14 @@ func (g *Generator) Generate(srcFilename, interfaceName string) (files []*genera
 		files = append(files, cronFiles...)
 	}
 
+	if transport.Has(docutil.TransportTask) {
+		taskFiles, err := g.generateTask(data, spec)
+		if err != nil {
+			return files, err
+		}
+		files = append(files, taskFiles...)
+	}
+
 	return files, nil
 }
 
@@ -209,7 +245,7 @@ func (g *Generator) generateHTTP(data *ifacetool.Data, spec *openapi.Specificati
 }
 
 // generateGRPC generates the gRPC code.
-func (g *Generator) generateGRPC(data *ifacetool.Data) (files []*generator.File, err error) {
+func (g *Generator) generateGRPC(data *ifacetool.Data, httpSpec *httpspec.Specification) (files []*generator.File, err error) {
 	outDir := g.getOutDir("grpc")
 	if err = ensureDir(outDir); err != nil {
 		return files, err
@@ -224,12 +260,30 @@ func (g *Generator) generateGRPC(data *ifacetool.Data) (files []*generator.File,
 	if err != nil {
 		return files, err
 	}
+	if g.opts.GRPCHTTPRules {
+		if err = service.SetHTTPRules(httpSpec); err != nil {
+			return files, err
+		}
+	}
 
 	// Generate the `.proto` file.
 	pbOutDir := filepath.Join(outDir, "pb")
+	if service.Options.Out != "" {
+		pbOutDir = filepath.Join(outDir, service.Options.Out)
+	}
 	if err = ensureDir(pbOutDir); err != nil {
 		return files, err
 	}
+	protoFilename := filepath.Join(pbOutDir, proto.FileName(data, service))
+
+	// Keep the field numbers stable by reading them from the .proto file
+	// generated before, if any.
+	numbers, err := grpcparser.ReadNumbers(protoFilename)
+	if err != nil {
+		return files, err
+	}
+	service.Renumber(numbers)
+
 	f, err := g.proto.Generate(pbOutDir, data, service)
 	if err != nil {
 		return files, err
@@ -242,11 +296,22 @@ func (g *Generator) generateGRPC(data *ifacetool.Data) (files []*generator.File,
 
 	// Compile the `.proto` file to the gRPC definition.
 	// See https://grpc.io/docs/languages/go/basics/#generating-client-and-server-code
-	cmd := exec.Command("protoc",
-		"--go_out=.", "--go_opt=paths=source_relative",
-		"--go-grpc_out=.", "--go-grpc_opt=paths=source_relative",
-		filepath.Join(pbOutDir, data.SrcPkgName+".proto"),
+	root := protoRoot(pbOutDir, service.Options.Package)
+	var args []string
+	if root != "." || len(g.opts.ProtoPaths) > 0 {
+		// The root directory must be searched too, once any import
+		// path is specified.
+		args = append(args, "-I"+root)
+		for _, path := range g.opts.ProtoPaths {
+			args = append(args, "-I"+path)
+		}
+	}
+	args = append(args,
+		"--go_out="+root, "--go_opt=paths=source_relative",
+		"--go-grpc_out="+root, "--go-grpc_opt=paths=source_relative",
+		protoFilename,
 	)
+	cmd := exec.Command("protoc", args...)
 	if out, err := cmd.CombinedOutput(); err != nil {
 		return files, fmt.Errorf("failed to compile proto: %s", out)
 	}
@@ -287,6 +352,13 @@ func (g *Generator) generateEvent(data *ifacetool.Data, spec *openapi.Specificat
 	}
 	files = append(files, f)
 
+	// Generate the helper AsyncAPI code.
+	f, err = g.asyncapi.Generate(pkgInfo, data, eventInfo, spec)
+	if err != nil {
+		return files, err
+	}
+	files = append(files, f)
+
 	return files, nil
 }
 
@@ -318,6 +390,56 @@ func (g *Generator) generateCron(data *ifacetool.Data, spec *openapi.Specificati
 	return files, nil
 }
 
+// generateTask generates the task code.
+func (g *Generator) generateTask(data *ifacetool.Data, spec *openapi.Specification) (files []*generator.File, err error) {
+	outDir := g.getOutDir("task")
+	if err := ensureDir(outDir); err != nil {
+		return files, err
+	}
+	defer func() {
+		for _, f := range files {
+			f.MoveTo(outDir)
+		}
+	}()
+
+	pkgInfo := g.getPkgInfo(outDir)
+
+	tasks, err := taskparser.Parse(data, g.opts.SnakeCase)
+	if err != nil {
+		return files, err
+	}
+
+	f, err := g.task.Generate(pkgInfo, data, tasks, spec)
+	if err != nil {
+		return files, err
+	}
+	files = append(files, f)
+
+	return files, nil
+}
+
+// protoRoot returns the root directory, relative to which the .proto file
+// is imported. If the layout of pbOutDir follows the proto package (e.g.
+// "proto/acme/user/v1" for "acme.user.v1"), the root is the directory
+// containing the package directories (e.g. "proto"). Otherwise, the root
+// is the current directory.
+func protoRoot(pbOutDir, pkg string) string {
+	if pkg == "" {
+		return "."
+	}
+
+	dir := filepath.ToSlash(filepath.Clean(pbOutDir))
+	pkgDir := strings.ReplaceAll(pkg, ".", "/")
+	switch {
+	case dir == pkgDir:
+		return "."
+	case strings.HasSuffix(dir, "/"+pkgDir):
+		return filepath.FromSlash(strings.TrimSuffix(dir, "/"+pkgDir))
+	default:
+		return "."
+	}
+}
+
 func (g *Generator) parseInterface(srcFilename, interfaceName string) (*ifacetool.Data, error) {
 	pkgName := ""
 	if !g.opts.FlatLayout {
@@ -1,6 +1,10 @@
 package grpc
 
 import (
+	"path"
+	"strconv"
+	"strings"
+
 	"github.com/RussellLuo/kun/gen/grpc/parser"
 	"github.com/RussellLuo/kun/gen/util/annotation"
 	"github.com/RussellLuo/kun/gen/util/generator"
@@ -15,22 +19,256 @@ package {{.PkgInfo.CurrentPkgName}}
 
 import (
 	kitgrpc "github.com/go-kit/kit/transport/grpc"
-	"{{.PBPkgPath}}"
+	{{.PBPkgImport}}
+
+	{{- if .HasStreaming}}
+	{{- range .Data.Imports}}
+	{{.ImportString}}
+	{{- end}}
+	{{- end}}
 )
 
 {{- $pbPkgPrefix := .PBPkgPrefix}}
 {{- $endpointPkgPrefix := .PkgInfo.EndpointPkgPrefix}}
 {{- $serviceName := .Data.InterfaceName}}
 
+{{- with .Service.Enums}}
+
+func init() {
+	// Register the mappings from proto enums to Go constants.
+	{{- range .}}
+	grpccodec.RegisterEnum({{$pbPkgPrefix}}{{.Name}}(0), map[int32]interface{}{
+		{{- range .Values}}
+		{{.Num}}: {{.GoValue}},
+		{{- end}}
+	})
+	{{- end}}
+}
+{{- end}}
+
 type grpcServer struct {
 	{{$pbPkgPrefix}}Unimplemented{{$serviceName}}Server
 
+	{{- if .HasStreaming}}
+
+	// Streaming RPCs are served by calling svc directly, since Go kit
+	// endpoints only support unary calls.
+	svc    {{$.Data.SrcPkgQualifier}}{{$serviceName}}
+	codecs grpccodec.Codecs
+	{{- end}}
+
 	{{range .Service.RPCs -}}
+	{{if not .IsStreaming -}}
 	{{lowerFirst .Name}} kitgrpc.Handler
+	{{end -}}
 	{{end -}} {{/* range .Service.RPCs */}}
 }
 
 {{- range .Service.RPCs}}
+{{- if .IsStreaming}}
+
+{{- $streamName := print .Name "Stream"}}
+
+{{- if .ClientStream}}
+
+func (s *grpcServer) {{.Name}}(stream {{$pbPkgPrefix}}{{$serviceName}}_{{.Name}}Server) error {
+{{- else}}
+
+func (s *grpcServer) {{.Name}}(req *{{$pbPkgPrefix}}{{.Request.Name}}, stream {{$pbPkgPrefix}}{{$serviceName}}_{{.Name}}Server) error {
+{{- end}}
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	codec := s.codecs.EncodeDecoder("{{.Name}}")
+
+	{{- if .ClientStream}}
+
+	// The non-stream parameters are decoded from the first request message.
+	first, recvErr := stream.Recv()
+	if recvErr != nil && recvErr != io.EOF {
+		return recvErr
+	}
+
+	{{- if hasParams .}}
+	var in {{$endpointPkgPrefix}}{{.Request.Name}}
+	if first != nil {
+		if err := codec.DecodeRequest(first, &in); err != nil {
+			return err
+		}
+	}
+	{{- template "metadata" .}}
+	{{- end}}
+
+	// On errors, ctx is canceled before recvc is closed, so that the service
+	// can tell a broken stream from a finished one.
+	recvc := make(chan {{.ClientStream.ElemTypeString}})
+	recv := grpccodec.NewStreamReceiver(cancel)
+	defer recv.Stop()
+	go func() {
+		defer recv.Done()
+		defer close(recvc)
+		for msg := first; msg != nil; {
+			// The first message may carry the non-stream parameters only.
+			if msg != first || grpccodec.HasElem(msg, "{{.ClientStream.Field.Name}}") {
+				var elem {{lowerFirst .Name}}StreamRequest
+				if err := codec.DecodeRequest(msg, &elem); err != nil {
+					recv.Fail(err)
+					return
+				}
+				select {
+				case recvc <- elem.{{title .ClientStream.Param.Name}}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			var err error
+			if !recv.Receive(func() { msg, err = stream.Recv() }) {
+				return
+			}
+			if err != nil && err != io.EOF {
+				recv.Fail(err)
+				return
+			}
+		}
+	}()
+
+	{{- else if hasParams .}}
+
+	var in {{$endpointPkgPrefix}}{{.Request.Name}}
+	if err := codec.DecodeRequest(req, &in); err != nil {
+		return err
+	}
+	{{- template "metadata" .}}
+	{{- end}} {{/* if .ClientStream */}}
+
+	{{- if .ServerStream}}
+
+	send := func(elem {{.ServerStream.ElemTypeString}}) error {
+		msg := new({{$pbPkgPrefix}}{{.Response.Name}})
+		if err := codec.EncodeResponse(&{{lowerFirst .Name}}StreamResponse{ {{- title .ServerStream.Field.Name}}: elem}, msg); err != nil {
+			return err
+		}
+		return stream.Send(msg)
+	}
+
+	{{- if eq .ServerStream.Kind.String "send-chan"}}
+
+	// The channel is owned by the service, which may close it when done.
+	// Either way, the stream ends once the service returns.
+	sendc := make(chan {{.ServerStream.ElemTypeString}})
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.svc.{{.Name}}({{callArgs .}})
+	}()
+	var sendErr, svcErr error
+loop:
+	for {
+		select {
+		case elem, ok := <-sendc:
+			switch {
+			case !ok:
+				sendc = nil
+			case sendErr == nil:
+				if sendErr = send(elem); sendErr != nil {
+					// Drain the stream until the service returns.
+					cancel()
+				}
+			}
+		case svcErr = <-errc:
+			break loop
+		}
+	}
+	{{- template "recvErr" .}}
+	if sendErr != nil {
+		return sendErr
+	}
+	if svcErr != nil {
+		return svcErr
+	}
+
+	{{- else if eq .ServerStream.Kind.String "result-chan"}}
+
+	sendc, err := s.svc.{{.Name}}({{callArgs .}})
+	{{- template "recvErr" .}}
+	if err != nil {
+		return err
+	}
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			{{- template "recvErr" .}}
+			return ctx.Err()
+		case elem, ok := <-sendc:
+			if !ok {
+				break loop
+			}
+			if err := send(elem); err != nil {
+				return err
+			}
+		}
+	}
+	{{- template "recvErr" .}}
+
+	{{- else}} {{/* callback */}}
+
+	{{- if .ClientStream}}
+	err := s.svc.{{.Name}}({{callArgs .}})
+	{{- template "recvErr" .}}
+	if err != nil {
+		return err
+	}
+	{{- else}}
+	if err := s.svc.{{.Name}}({{callArgs .}}); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- end}} {{/* if eq .ServerStream.Kind */}}
+
+	return nil
+
+	{{- else}} {{/* if .ServerStream */}}
+
+	{{$returns := getReturns .}}
+	{{- joinName $returns ", "}} := s.svc.{{.Name}}({{callArgs .}})
+	{{- template "recvErr" .}}
+	{{- $errName := getErrName $returns}}
+	if {{$errName}} != nil {
+		return {{$errName}}
+	}
+
+	msg := new({{$pbPkgPrefix}}{{.Response.Name}})
+	resp := &{{$endpointPkgPrefix}}{{.Response.Name}}{
+		{{- range $returns}}
+		{{- if ne .Name $errName}}
+		{{title .Name}}: {{.Name}},
+		{{- end}}
+		{{- end}}
+	}
+	if err := codec.EncodeResponse(resp, msg); err != nil {
+		return err
+	}
+	return stream.SendAndClose(msg)
+	{{- end}} {{/* if .ServerStream */}}
+}
+
+{{- if .ClientStream}}
+
+// {{lowerFirst .Name}}StreamRequest holds a single stream element of {{$pbPkgPrefix}}{{.Request.Name}}.
+type {{lowerFirst .Name}}StreamRequest struct {
+	{{title .ClientStream.Param.Name}} {{.ClientStream.ElemTypeString}} ` + "`" + `json:"{{lowerCamelCase .ClientStream.Param.Name}}"` + "`" + `
+}
+{{- end}}
+
+{{- if .ServerStream}}
+
+// {{lowerFirst .Name}}StreamResponse holds a single stream element of {{$pbPkgPrefix}}{{.Response.Name}}.
+type {{lowerFirst .Name}}StreamResponse struct {
+	{{title .ServerStream.Field.Name}} {{.ServerStream.ElemTypeString}} ` + "`" + `json:"{{lowerCamelCase .ServerStream.Field.Name}}"` + "`" + `
+}
+{{- end}}
+
+{{- else}} {{/* if .IsStreaming */}}
 
 func (s *grpcServer) {{.Name}}(ctx context.Context, req *{{$pbPkgPrefix}}{{.Request.Name}}) (*{{$pbPkgPrefix}}{{.Response.Name}}, error) {
 	_, resp, err := s.{{lowerFirst .Name}}.ServeGRPC(ctx, req)
@@ -39,13 +277,21 @@ func (s *grpcServer) {{.Name}}(ctx context.Context, req *{{$pbPkgPrefix}}{{.Requ
 	}
 	return resp.(*{{$pbPkgPrefix}}{{.Response.Name}}), nil
 }
+{{- end}} {{/* if .IsStreaming */}}
 {{- end}} {{/* range .Service.RPCs */}}
 
 func NewGRPCServer(svc {{$.Data.SrcPkgQualifier}}{{$serviceName}}, codecs grpccodec.Codecs) {{$pbPkgPrefix}}{{$serviceName}}Server {
+	{{- if .HasUnary}}
 	var codec grpccodec.Codec
+	{{- end}}
 	s := new(grpcServer)
 
+	{{- if .HasStreaming}}
+	s.svc, s.codecs = svc, codecs
+	{{- end}}
+
 	{{- range .Service.RPCs}}
+	{{- if not .IsStreaming}}
 
 	codec = codecs.EncodeDecoder("{{.Name}}")
 	s.{{lowerFirst .Name}} = kitgrpc.NewServer(
@@ -53,21 +299,30 @@ func NewGRPCServer(svc {{$.Data.SrcPkgQualifier}}{{$serviceName}}, codecs grpcco
 		decode{{.Request.Name}}(codec),
 		encode{{.Response.Name}}(codec),
 	)
+	{{- end}} {{/* if not .IsStreaming */}}
 	{{- end}} {{/* range .Service.RPCs */}}
 
 	return s
 }
 
 {{- range .Service.RPCs}}
+{{- if not .IsStreaming}}
 
 // decode{{.Request.Name}} converts a gRPC request to an endpoint request.
 func decode{{.Request.Name}}(codec grpccodec.Codec) kitgrpc.DecodeRequestFunc {
-	return func(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return func({{if .Metadata}}ctx{{else}}_{{end}} context.Context, grpcReq interface{}) (interface{}, error) {
 		var req {{$endpointPkgPrefix}}{{.Request.Name}}
 		pb := grpcReq.(*{{$pbPkgPrefix}}{{.Request.Name}})
 		if err := codec.DecodeRequest(pb, &req); err != nil {
 			return nil, err
 		}
+		{{- with .Metadata}}
+
+		// Some arguments are bound to the gRPC metadata.
+		{{- range .}}
+		req.{{title .Param.Name}} = grpccodec.{{if .IsSlice}}MetadataValues{{else}}MetadataValue{{end}}(ctx, "{{.Key}}")
+		{{- end}}
+		{{- end}}
 		return {{ampersand}}req, nil
 	}
 }
@@ -83,7 +338,29 @@ func encode{{.Response.Name}}(codec grpccodec.Codec) kitgrpc.EncodeResponseFunc
 		return pb, nil
 	}
 }
+{{- end}} {{/* if not .IsStreaming */}}
 {{- end}} {{/* range .Service.RPCs */}}
+
+{{- define "recvErr"}}
+{{- if .ClientStream}}
+
+	// The error occurred in receiving, if any, takes precedence, since the
+	// service may have returned due to the broken stream.
+	if err := recv.Stop(); err != nil {
+		return err
+	}
+{{- end}}
+{{- end}}
+
+{{- define "metadata"}}
+{{- with .Metadata}}
+
+	// Some arguments are bound to the gRPC metadata.
+	{{- range .}}
+	in.{{title .Param.Name}} = grpccodec.{{if .IsSlice}}MetadataValues{{else}}MetadataValue{{end}}(ctx, "{{.Key}}")
+	{{- end}}
+{{- end}}
+{{- end}}
 `
 )
 
@@ -102,18 +379,41 @@ func New(opts *Options) *Generator {
 }
 
 func (g *Generator) Generate(pkgInfo *generator.PkgInfo, pbOutDir string, ifaceData *ifacetool.Data, service *parser.Service) (*generator.File, error) {
+	methods := make(map[string]*ifacetool.Method)
+	for _, m := range ifaceData.Methods {
+		methods[m.Name] = m
+	}
+
+	pbPkgPath, pbPkgName := pkgtool.PkgPathFromDir(pbOutDir), pkgtool.PkgNameFromDir(pbOutDir)
+	if service.Options.GoPackage != "" {
+		pbPkgPath, pbPkgName = service.Options.GoImportPath(), service.Options.GoPackageName()
+	}
+
+	hasUnary, hasStreaming := false, false
+	for _, rpc := range service.RPCs {
+		if rpc.IsStreaming() {
+			hasStreaming = true
+		} else {
+			hasUnary = true
+		}
+	}
+
 	data := struct {
-		PBPkgPath   string
-		PBPkgPrefix string
-		Data        *ifacetool.Data
-		PkgInfo     *generator.PkgInfo
-		Service     *parser.Service
+		PBPkgImport  string
+		PBPkgPrefix  string
+		Data         *ifacetool.Data
+		PkgInfo      *generator.PkgInfo
+		Service      *parser.Service
+		HasUnary     bool
+		HasStreaming bool
 	}{
-		PBPkgPath:   pkgtool.PkgPathFromDir(pbOutDir),
-		PBPkgPrefix: pkgtool.PkgNameFromDir(pbOutDir) + ".",
-		Data:        ifaceData,
-		PkgInfo:     pkgInfo,
-		Service:     service,
+		PBPkgImport:  pbPkgImport(pbPkgPath, pbPkgName),
+		PBPkgPrefix:  pbPkgName + ".",
+		Data:         ifaceData,
+		PkgInfo:      pkgInfo,
+		Service:      service,
+		HasUnary:     hasUnary,
+		HasStreaming: hasStreaming,
 	}
 
 	return generator.Generate(template, data, generator.Options{
@@ -130,9 +430,78 @@ func (g *Generator) Generate(pkgInfo *generator.PkgInfo, pbOutDir string, ifaceD
 				}
 				return ""
 			},
-			"lowerFirst": caseconv.LowerFirst,
+			"lowerFirst":     caseconv.LowerFirst,
+			"lowerCamelCase": caseconv.ToLowerCamelCase,
+			"title":          caseconv.UpperFirst,
+			"hasParams": func(rpc *parser.RPC) bool {
+				// Check if there are any non-stream parameters to decode.
+				for _, arg := range streamCallArgs(methods[rpc.Name], rpc) {
+					if strings.HasPrefix(arg, "in.") {
+						return true
+					}
+				}
+				return false
+			},
+			"callArgs": func(rpc *parser.RPC) string {
+				return strings.Join(streamCallArgs(methods[rpc.Name], rpc), ", ")
+			},
+			"getReturns": func(rpc *parser.RPC) []*ifacetool.Param {
+				return methods[rpc.Name].Returns
+			},
+			"getErrName": func(returns []*ifacetool.Param) string {
+				for _, r := range returns {
+					if r.TypeString == "error" {
+						return r.Name
+					}
+				}
+				return ""
+			},
+			"joinName": func(returns []*ifacetool.Param, sep string) string {
+				var names []string
+				for _, r := range returns {
+					names = append(names, r.Name)
+				}
+				return strings.Join(names, sep)
+			},
 		},
 		Formatted:      g.opts.Formatted,
 		TargetFileName: "grpc.go",
 	})
 }
+
+// pbPkgImport returns the import spec of the package generated from the
+// .proto file, with an explicit name if it differs from the last element
+// of the import path.
+func pbPkgImport(pkgPath, pkgName string) string {
+	if path.Base(pkgPath) == pkgName {
+		return strconv.Quote(pkgPath)
+	}
+	return pkgName + " " + strconv.Quote(pkgPath)
+}
+
+// streamCallArgs returns the arguments used to call the streaming method,
+// where the stream parameters are replaced by the local channels (or the
+// local callback) bridged to the gRPC stream.
+func streamCallArgs(method *ifacetool.Method, rpc *parser.RPC) (args []string) {
+	for _, p := range method.Params {
+		switch {
+		case p.TypeString == "context.Context":
+			args = append(args, "ctx")
+		case rpc.ClientStream != nil && rpc.ClientStream.Param == p:
+			args = append(args, "recvc")
+		case rpc.ServerStream != nil && rpc.ServerStream.Param == p:
+			if rpc.ServerStream.Kind == parser.StreamCallback {
+				args = append(args, "send")
+			} else {
+				args = append(args, "sendc")
+			}
+		default:
+			arg := "in." + caseconv.UpperFirst(p.Name)
+			if p.Variadic {
+				arg += "..."
+			}
+			args = append(args, arg)
+		}
+	}
+	return
+}
@@ -0,0 +1,191 @@
+package parser
+
+import (
+	"fmt"
+	"go/types"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/RussellLuo/kun/gen/http/parser"
+	"github.com/RussellLuo/kun/gen/http/spec"
+	"github.com/RussellLuo/kun/pkg/caseconv"
+)
+
+var (
+	// rePathVarName matches path variables, including the ones with regular
+	// expressions in the form of chi (e.g. `{id:[0-9]+}` or `{id:[a-z]{4}}`).
+	rePathVarName = regexp.MustCompile(`{(\w+)(?::(?:[^{}]|{[^{}]*})*)?}`)
+)
+
+// HTTPRule is the HTTP mapping of an RPC, which is used to generate the
+// google.api.http option.
+// See https://cloud.google.com/endpoints/docs/grpc-service-config/reference/rpc/google.api#httprule.
+type HTTPRule struct {
+	Method  string // The HTTP method in lower case, e.g. "get".
+	Pattern string // The URI pattern, where path variables are proto field paths.
+	Body    string // The request field mapped to the HTTP body, or "*" for all fields.
+}
+
+// IsCustom reports whether the HTTP method has no corresponding field in
+// google.api.HttpRule, and thus must be specified in a custom pattern.
+func (r *HTTPRule) IsCustom() bool {
+	switch r.Method {
+	case "get", "put", "post", "delete", "patch":
+		return false
+	default:
+		return true
+	}
+}
+
+// SetHTTPRules sets the HTTP rules of the RPCs of s, according to the HTTP
+// operations defined by //kun:op.
+func (s *Service) SetHTTPRules(httpSpec *spec.Specification) error {
+	for _, rpc := range s.RPCs {
+		if rpc.IsStreaming() {
+			// Streaming RPCs are not supported by HTTP transcoding.
+			continue
+		}
+		for _, op := range httpSpec.Operations {
+			if op.GoMethodName != rpc.Name || op.Method == "" {
+				// Operations without HTTP method are added for gRPC only.
+				continue
+			}
+			rule, err := newHTTPRule(op, rpc.requestParam)
+			if err != nil {
+				return err
+			}
+			rpc.HTTPRules = append(rpc.HTTPRules, rule)
+		}
+	}
+	return nil
+}
+
+// newHTTPRule creates the HTTP rule of op, where requestParam is the method
+// parameter mapped to the request message (if any).
+func newHTTPRule(op *spec.Operation, requestParam string) (*HTTPRule, error) {
+	rule := &HTTPRule{
+		Method: strings.ToLower(op.Method),
+		Pattern: rePathVarName.ReplaceAllStringFunc(op.Pattern, func(s string) string {
+			// The regular expression (if any) is dropped, since it's not
+			// supported by the URI pattern.
+			name := rePathVarName.FindStringSubmatch(s)[1]
+			return "{" + pathVarFieldPath(op.Request, name, requestParam) + "}"
+		}),
+	}
+
+	switch body := op.Request.BodyField; body {
+	case parser.OptionNoRequestBody:
+	case "":
+		if op.Method == http.MethodGet || op.Method == http.MethodDelete {
+			break
+		}
+		body, err := bodyFieldPath(op, requestParam)
+		if err != nil {
+			return nil, err
+		}
+		rule.Body = body
+	case requestParam:
+		// The request message is the body itself.
+		rule.Body = "*"
+	default:
+		rule.Body = caseconv.ToSnakeCase(body)
+	}
+
+	return rule, nil
+}
+
+// bodyFieldPath returns the path of the request field mapped to the HTTP
+// body of op, which has no body field specified.
+//
+// Since "*" maps all the fields not bound to the path variables to the body,
+// it is only used if there are no query parameters. Otherwise, there must be
+// a single argument in the body, whose field is mapped to the body instead.
+func bodyFieldPath(op *spec.Operation, requestParam string) (string, error) {
+	var hasQuery bool
+	var bodyFields []string
+	for _, b := range op.Request.Bindings {
+		if b.IsBlank() || (requestParam != "" && b.Arg.Name != requestParam) {
+			// The other arguments are not in the request message.
+			continue
+		}
+		for _, p := range b.Params {
+			switch p.In {
+			case spec.InQuery:
+				hasQuery = true
+			case spec.InBody:
+				// Only non-aggregate arguments can be in the body.
+				bodyFields = append(bodyFields, caseconv.ToSnakeCase(b.Arg.Name))
+			}
+		}
+	}
+
+	switch {
+	case len(bodyFields) == 0:
+		return "", nil
+	case !hasQuery:
+		return "*", nil
+	case len(bodyFields) == 1:
+		return bodyFields[0], nil
+	default:
+		return "", fmt.Errorf("cannot map %s %s of the method %s to google.api.http: query parameters require a single argument in the body, but got %s", op.Method, op.Pattern, op.GoMethodName, strings.Join(bodyFields, ", "))
+	}
+}
+
+// pathVarFieldPath returns the path of the request field, to which the path
+// variable name is bound. If the argument requestParam is mapped to the
+// request message, its fields are the top-level fields of the message.
+func pathVarFieldPath(req *spec.Request, name, requestParam string) string {
+	for _, b := range req.Bindings {
+		if b.IsBlank() {
+			continue
+		}
+
+		argName := caseconv.ToSnakeCase(b.Arg.Name)
+
+		// For a struct argument, find the struct field bound to the path variable.
+		if st, ok := derefType(b.Arg.Type).Underlying().(*types.Struct); ok {
+			if fieldName := structFieldOfPathVar(st, name); fieldName != "" {
+				if b.Arg.Name == requestParam {
+					return caseconv.ToSnakeCase(fieldName)
+				}
+				return argName + "." + caseconv.ToSnakeCase(fieldName)
+			}
+		}
+
+		if !b.IsAggregate() && b.In() == spec.InPath && b.Name() == name {
+			return argName
+		}
+	}
+
+	// Fall back to the variable name itself.
+	return caseconv.ToSnakeCase(name)
+}
+
+// structFieldOfPathVar returns the name of the field in st, which is bound
+// to the path variable name by the struct tag.
+func structFieldOfPathVar(st *types.Struct, name string) string {
+	for i := 0; i < st.NumFields(); i++ {
+		field := &parser.StructField{
+			Name: st.Field(i).Name(),
+			Tag:  reflect.StructTag(st.Tag(i)),
+		}
+		if err := field.Parse(); err != nil || field.Omitted {
+			continue
+		}
+		for _, p := range field.Params {
+			if p.In == spec.InPath && p.Name == name {
+				return field.Name
+			}
+		}
+	}
+	return ""
+}
+
+func derefType(t types.Type) types.Type {
+	if p, ok := t.(*types.Pointer); ok {
+		return p.Elem()
+	}
+	return t
+}
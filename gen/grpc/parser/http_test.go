@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"go/types"
+	"reflect"
+	"testing"
+
+	"github.com/RussellLuo/kun/gen/http/spec"
+	"github.com/RussellLuo/kun/pkg/ifacetool"
+)
+
+func TestService_SetHTTPRules(t *testing.T) {
+	str := types.Typ[types.String]
+	keyType := types.NewStruct([]*types.Var{
+		types.NewField(0, nil, "UserID", str, false),
+		types.NewField(0, nil, "MessageID", str, false),
+	}, []string{
+		`kun:"in=path name=userID"`,
+		`kun:"in=path name=messageID"`,
+	})
+
+	newOp := func(goMethodName, method, pattern, bodyField string, bindings ...*spec.Binding) *spec.Operation {
+		op := spec.NewOperation(goMethodName, goMethodName, "")
+		op.Method, op.Pattern = method, pattern
+		op.Request.BodyField = bodyField
+		op.Request.Bindings = bindings
+		return op
+	}
+	bind := func(name string, typ types.Type, params ...*spec.Parameter) *spec.Binding {
+		return &spec.Binding{
+			Arg:    &ifacetool.Param{Name: name, Type: typ},
+			Params: params,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		inRPC      *RPC
+		inOps      []*spec.Operation
+		wantRules  []*HTTPRule
+		wantErrStr string
+	}{
+		{
+			name:  "body with all fields",
+			inRPC: &RPC{Name: "CreateUser"},
+			inOps: []*spec.Operation{
+				newOp("CreateUser", "POST", "/users", "",
+					bind("name", str, &spec.Parameter{In: spec.InBody, Name: "name"}),
+				),
+			},
+			wantRules: []*HTTPRule{{Method: "post", Pattern: "/users", Body: "*"}},
+		},
+		{
+			name:  "body with a single field",
+			inRPC: &RPC{Name: "UpdateUser"},
+			inOps: []*spec.Operation{
+				newOp("UpdateUser", "PUT", "/users/{userID}", "user",
+					bind("userID", str, &spec.Parameter{In: spec.InPath, Name: "userID"}),
+					bind("user", str, &spec.Parameter{In: spec.InBody, Name: "user"}),
+				),
+			},
+			wantRules: []*HTTPRule{{Method: "put", Pattern: "/users/{user_id}", Body: "user"}},
+		},
+		{
+			name:  "body with query parameters",
+			inRPC: &RPC{Name: "CreateUser"},
+			inOps: []*spec.Operation{
+				newOp("CreateUser", "POST", "/users", "",
+					bind("user", str, &spec.Parameter{In: spec.InBody, Name: "user"}),
+					bind("dryRun", str, &spec.Parameter{In: spec.InQuery, Name: "dryRun"}),
+				),
+			},
+			wantRules: []*HTTPRule{{Method: "post", Pattern: "/users", Body: "user"}},
+		},
+		{
+			name:  "multiple body arguments with query parameters",
+			inRPC: &RPC{Name: "CreateUser"},
+			inOps: []*spec.Operation{
+				newOp("CreateUser", "POST", "/users", "",
+					bind("name", str, &spec.Parameter{In: spec.InBody, Name: "name"}),
+					bind("age", str, &spec.Parameter{In: spec.InBody, Name: "age"}),
+					bind("dryRun", str, &spec.Parameter{In: spec.InQuery, Name: "dryRun"}),
+				),
+			},
+			wantErrStr: "cannot map POST /users of the method CreateUser to google.api.http: query parameters require a single argument in the body, but got name, age",
+		},
+		{
+			name:  "no body",
+			inRPC: &RPC{Name: "DeleteUser"},
+			inOps: []*spec.Operation{
+				newOp("DeleteUser", "DELETE", "/users/{id}", "",
+					bind("id", str, &spec.Parameter{In: spec.InPath, Name: "id"}),
+				),
+			},
+			wantRules: []*HTTPRule{{Method: "delete", Pattern: "/users/{id}"}},
+		},
+		{
+			name:  "additional bindings with struct fields",
+			inRPC: &RPC{Name: "GetMessage"},
+			inOps: []*spec.Operation{
+				newOp("GetMessage", "GET", "/messages/{messageID}", "",
+					bind("req", keyType, &spec.Parameter{In: spec.InPath, Name: "messageID"}),
+				),
+				newOp("GetMessage", "GET", "/users/{userID}/messages/{messageID}", "",
+					bind("req", keyType,
+						&spec.Parameter{In: spec.InPath, Name: "userID"},
+						&spec.Parameter{In: spec.InPath, Name: "messageID"},
+					),
+				),
+			},
+			wantRules: []*HTTPRule{
+				{Method: "get", Pattern: "/messages/{req.message_id}"},
+				{Method: "get", Pattern: "/users/{req.user_id}/messages/{req.message_id}"},
+			},
+		},
+		{
+			name:  "path variables with regular expressions",
+			inRPC: &RPC{Name: "GetMessage"},
+			inOps: []*spec.Operation{
+				newOp("GetMessage", "GET", "/users/{userID:[0-9]+}/messages/{messageID:[a-z]{4}}", "",
+					bind("userID", str, &spec.Parameter{In: spec.InPath, Name: "userID"}),
+					bind("messageID", str, &spec.Parameter{In: spec.InPath, Name: "messageID"}),
+				),
+			},
+			wantRules: []*HTTPRule{{Method: "get", Pattern: "/users/{user_id}/messages/{message_id}"}},
+		},
+		{
+			name:  "request bound to a struct argument",
+			inRPC: &RPC{Name: "UpdateMessage", requestParam: "req"},
+			inOps: []*spec.Operation{
+				newOp("UpdateMessage", "PUT", "/users/{userID}/messages/{messageID}", "req",
+					bind("req", keyType,
+						&spec.Parameter{In: spec.InPath, Name: "userID"},
+						&spec.Parameter{In: spec.InPath, Name: "messageID"},
+					),
+				),
+			},
+			wantRules: []*HTTPRule{{Method: "put", Pattern: "/users/{user_id}/messages/{message_id}", Body: "*"}},
+		},
+		{
+			name:  "gRPC only",
+			inRPC: &RPC{Name: "Ping"},
+			inOps: []*spec.Operation{
+				newOp("Ping", "", "", ""),
+			},
+		},
+		{
+			name:  "streaming",
+			inRPC: &RPC{Name: "Watch", ServerStream: &Stream{}},
+			inOps: []*spec.Operation{
+				newOp("Watch", "GET", "/watch", ""),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{RPCs: []*RPC{tt.inRPC}}
+			err := s.SetHTTPRules(&spec.Specification{Operations: tt.inOps})
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if !reflect.DeepEqual(tt.inRPC.HTTPRules, tt.wantRules) {
+				t.Fatalf("HTTPRules: got (%+v), want (%+v)", tt.inRPC.HTTPRules, tt.wantRules)
+			}
+		})
+	}
+}
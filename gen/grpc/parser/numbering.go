@@ -0,0 +1,210 @@
+package parser
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RussellLuo/kun/pkg/caseconv"
+)
+
+var (
+	reBlockStart = regexp.MustCompile(`^\s*(message|enum)\s+(\w+)\s*{`)
+	reBlockEnd   = regexp.MustCompile(`^\s*}`)
+	reField      = regexp.MustCompile(`^\s*(?:optional\s+|repeated\s+)?(?:map<[^>]*>|[\w.]+)\s+(\w+)\s*=\s*(-?\d+)\s*;`)
+	reEnumValue  = regexp.MustCompile(`^\s*(\w+)\s*=\s*(-?\d+)\s*;`)
+	reReserved   = regexp.MustCompile(`^\s*reserved\s+([^;]+);`)
+)
+
+// Numbers holds the numbers already assigned to the fields of the messages
+// (and the values of the enums) in a previously generated .proto file.
+//
+// Numbers are used to keep field numbers stable across regenerations, since
+// changing the number of an existing field breaks the wire compatibility.
+type Numbers struct {
+	// Blocks maps a message (or enum) name to its numbers.
+	Blocks map[string]*BlockNumbers
+}
+
+type BlockNumbers struct {
+	// Fields maps a field (or enum value) name to its number.
+	Fields map[string]int
+	// Reserved holds the numbers of the removed fields.
+	Reserved []int
+}
+
+// ReadNumbers reads the numbers from the .proto file generated before. An
+// empty Numbers is returned if the file does not exist.
+func ReadNumbers(filename string) (*Numbers, error) {
+	numbers := &Numbers{Blocks: make(map[string]*BlockNumbers)}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return numbers, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var block *BlockNumbers
+	var isEnum bool
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if block == nil {
+			if m := reBlockStart.FindStringSubmatch(line); m != nil {
+				block = &BlockNumbers{Fields: make(map[string]int)}
+				isEnum = m[1] == "enum"
+				numbers.Blocks[m[2]] = block
+			}
+			continue
+		}
+
+		if reBlockEnd.MatchString(line) {
+			block = nil
+			continue
+		}
+
+		if m := reReserved.FindStringSubmatch(line); m != nil {
+			for _, s := range strings.Split(m[1], ",") {
+				// Reserved names and ranges are not generated, thus ignored.
+				if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+					block.Reserved = append(block.Reserved, n)
+				}
+			}
+			continue
+		}
+
+		re := reField
+		if isEnum {
+			re = reEnumValue
+		}
+		if m := re.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			block.Fields[m[1]] = n
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return numbers, nil
+}
+
+// Renumber reassigns the numbers of all the message fields and enum values
+// of s according to prev, the numbers generated before:
+//
+//   - Existing fields keep their numbers.
+//   - New fields get fresh numbers, which have never been used before.
+//   - The numbers of removed fields are reserved.
+//
+// Note that the numbers of enum values are always the ones specified (i.e.
+// the values of integer constants, or the numbers of string constants given
+// by the directive), only the removed ones are reserved.
+func (s *Service) Renumber(prev *Numbers) {
+	if prev == nil || len(prev.Blocks) == 0 {
+		return
+	}
+
+	for _, rpc := range s.RPCs {
+		rpc.Request.Reserved = renumberFields(prev.Blocks[rpc.Request.Name], rpc.Request.Fields)
+		rpc.Response.Reserved = renumberFields(prev.Blocks[rpc.Response.Name], rpc.Response.Fields)
+	}
+
+	s.walkTypes(func(t *Type) {
+		if len(t.Fields) > 0 {
+			t.Reserved = renumberFields(prev.Blocks[t.Name], t.Fields)
+		}
+		if t.Enum != nil {
+			t.Enum.Reserved = renumberEnum(prev.Blocks[t.Enum.Name], t.Enum)
+		}
+	})
+}
+
+func renumberFields(prev *BlockNumbers, fields []*Field) (reserved []int) {
+	if prev == nil {
+		return nil
+	}
+
+	var names []string
+	for _, f := range fields {
+		names = append(names, caseconv.ToSnakeCase(f.Name))
+	}
+
+	next := nextNumber(prev)
+	for i, f := range fields {
+		if n, ok := prev.Fields[names[i]]; ok {
+			f.Num = n
+		} else {
+			f.Num = next
+			next++
+		}
+	}
+
+	return reservedNumbers(prev, names)
+}
+
+func renumberEnum(prev *BlockNumbers, e *Enum) (reserved []int) {
+	if prev == nil {
+		return nil
+	}
+
+	var names []string
+	for _, v := range e.Values {
+		names = append(names, v.Name)
+	}
+
+	// An enum value may reuse a reserved number, since the numbers are
+	// specified explicitly.
+	used := make(map[int]bool)
+	for _, v := range e.Values {
+		used[v.Num] = true
+	}
+	for _, n := range reservedNumbers(prev, names) {
+		if !used[n] {
+			reserved = append(reserved, n)
+		}
+	}
+	return reserved
+}
+
+// nextNumber returns the smallest number greater than any number used in prev.
+func nextNumber(prev *BlockNumbers) int {
+	max := 0
+	for _, n := range prev.Fields {
+		if n > max {
+			max = n
+		}
+	}
+	for _, n := range prev.Reserved {
+		if n > max {
+			max = n
+		}
+	}
+	return max + 1
+}
+
+// reservedNumbers returns the numbers reserved in prev, along with the numbers
+// of the fields removed from prev (i.e. not in names), in ascending order.
+func reservedNumbers(prev *BlockNumbers, names []string) (reserved []int) {
+	current := make(map[string]bool)
+	for _, name := range names {
+		current[name] = true
+	}
+
+	reserved = append(reserved, prev.Reserved...)
+	for name, n := range prev.Fields {
+		if !current[name] {
+			reserved = append(reserved, n)
+		}
+	}
+	sort.Ints(reserved)
+
+	return reserved
+}
@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const prevProto = `syntax = "proto3";
+
+package pb;
+
+service Service {
+  rpc Create (CreateRequest) returns (CreateResponse) {}
+}
+
+// The request message of Create.
+message CreateRequest {
+  reserved 3;
+  string name = 1;
+  optional int64 age = 2;
+  map<string, string> labels = 4;
+  repeated google.protobuf.StringValue tags = 5;
+}
+
+// The response message of Create.
+message CreateResponse {
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_ACTIVE = 1;
+  STATUS_INACTIVE = 2;
+}
+`
+
+func TestReadNumbers(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "service.proto")
+	if err := os.WriteFile(filename, []byte(prevProto), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadNumbers(filename)
+	if err != nil {
+		t.Fatalf("Err: got (%#v), want (nil)", err)
+	}
+
+	want := &Numbers{Blocks: map[string]*BlockNumbers{
+		"CreateRequest": {
+			Fields:   map[string]int{"name": 1, "age": 2, "labels": 4, "tags": 5},
+			Reserved: []int{3},
+		},
+		"CreateResponse": {
+			Fields: map[string]int{},
+		},
+		"Status": {
+			Fields: map[string]int{"STATUS_UNSPECIFIED": 0, "STATUS_ACTIVE": 1, "STATUS_INACTIVE": 2},
+		},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Numbers: got (%#v), want (%#v)", got, want)
+	}
+
+	got, err = ReadNumbers(filepath.Join(t.TempDir(), "nonexistent.proto"))
+	if err != nil || len(got.Blocks) != 0 {
+		t.Fatalf("Numbers: got (%#v, %#v), want empty", got, err)
+	}
+}
+
+func TestService_Renumber(t *testing.T) {
+	prev := &Numbers{Blocks: map[string]*BlockNumbers{
+		"CreateRequest": {
+			Fields:   map[string]int{"name": 1, "age": 2, "labels": 4},
+			Reserved: []int{3},
+		},
+		"Status": {
+			Fields: map[string]int{"STATUS_UNSPECIFIED": 0, "STATUS_ACTIVE": 1, "STATUS_INACTIVE": 2},
+		},
+		"Level": {
+			Fields: map[string]int{"LEVEL_LOW": 0, "LEVEL_MIDDLE": 1, "LEVEL_HIGH": 2},
+		},
+	}}
+
+	status := &Enum{
+		Name: "Status",
+		Values: []*EnumValue{
+			{Name: "STATUS_UNSPECIFIED", Num: 0},
+			{Name: "STATUS_ACTIVE", Num: 1},
+			{Name: "STATUS_PENDING", Num: 3},
+		},
+	}
+	level := &Enum{
+		Name: "Level",
+		Values: []*EnumValue{
+			{Name: "LEVEL_LOW", Num: 0},
+			{Name: "LEVEL_HIGH", Num: 2},
+		},
+	}
+	s := &Service{RPCs: []*RPC{{
+		Name: "Create",
+		Request: &Message{
+			Name: "CreateRequest",
+			Fields: []*Field{
+				{Name: "level", Type: &Type{Name: "Level", Enum: level}, Num: 1},
+				{Name: "Labels", Type: &Type{Name: "string", MapKey: "string"}, Num: 2},
+				{Name: "name", Type: &Type{Name: "string"}, Num: 3},
+				{Name: "status", Type: &Type{Name: "Status", Enum: status}, Num: 4},
+			},
+		},
+		Response: &Message{Name: "CreateResponse"},
+	}}}
+
+	s.Renumber(prev)
+
+	req := s.RPCs[0].Request
+	gotNums := map[string]int{}
+	for _, f := range req.Fields {
+		gotNums[f.Name] = f.Num
+	}
+	wantNums := map[string]int{"level": 5, "Labels": 4, "name": 1, "status": 6}
+	if !reflect.DeepEqual(gotNums, wantNums) {
+		t.Fatalf("Fields: got (%v), want (%v)", gotNums, wantNums)
+	}
+	if want := []int{2, 3}; !reflect.DeepEqual(req.Reserved, want) {
+		t.Fatalf("Reserved: got (%v), want (%v)", req.Reserved, want)
+	}
+
+	gotValues := map[string]int{}
+	for _, v := range status.Values {
+		gotValues[v.Name] = v.Num
+	}
+	wantValues := map[string]int{"STATUS_UNSPECIFIED": 0, "STATUS_PENDING": 3, "STATUS_ACTIVE": 1}
+	if !reflect.DeepEqual(gotValues, wantValues) {
+		t.Fatalf("Status: got (%v), want (%v)", gotValues, wantValues)
+	}
+	if want := []int{2}; !reflect.DeepEqual(status.Reserved, want) {
+		t.Fatalf("Status.Reserved: got (%v), want (%v)", status.Reserved, want)
+	}
+	if want := []int{1}; !reflect.DeepEqual(level.Reserved, want) {
+		t.Fatalf("Level.Reserved: got (%v), want (%v)", level.Reserved, want)
+	}
+}
@@ -2,10 +2,18 @@ package parser
 
 import (
 	"fmt"
+	"go/constant"
+	"go/token"
 	"go/types"
+	"math"
+	"path"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/RussellLuo/kun/gen/http/parser"
 	"github.com/RussellLuo/kun/gen/util/annotation"
@@ -19,23 +27,88 @@ var (
 	scalarTypes = map[string]string{
 		"float64": "double",
 		"float32": "float",
-		"int32":   "int32",  // sint32, sfixed32
-		"int64":   "int64",  // sint64, sfixed64
+		"int8":    "int32",
+		"int16":   "int32",
+		"int32":   "int32", // sint32, sfixed32
+		"int64":   "int64", // sint64, sfixed64
+		"uint8":   "uint32",
+		"uint16":  "uint32",
 		"uint32":  "uint32", // fixed32
 		"uint64":  "uint64", // fixed64
 		"int":     "int64",
+		"uint":    "uint64",
 		"bool":    "bool",
 		"string":  "string",
 		"[]byte":  "bytes",
 	}
 
+	// .proto scalar type -> wrapper type
+	// see https://github.com/protocolbuffers/protobuf/blob/main/src/google/protobuf/wrappers.proto
+	wrapperTypes = map[string]string{
+		"double": "google.protobuf.DoubleValue",
+		"float":  "google.protobuf.FloatValue",
+		"int64":  "google.protobuf.Int64Value",
+		"uint64": "google.protobuf.UInt64Value",
+		"int32":  "google.protobuf.Int32Value",
+		"uint32": "google.protobuf.UInt32Value",
+		"bool":   "google.protobuf.BoolValue",
+		"string": "google.protobuf.StringValue",
+		"bytes":  "google.protobuf.BytesValue",
+	}
+
 	reGRPC = regexp.MustCompile(`^` + annotation.DirectiveGRPC.String() + `(.*)$`)
+
+	// See https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md.
+	reMetadataKey = regexp.MustCompile(`^[0-9a-z_.-]+$`)
+
+	reProtoPackage = regexp.MustCompile(`^[A-Za-z_]\w*(\.[A-Za-z_]\w*)*$`)
 )
 
 type Service struct {
 	Name         string
 	RPCs         []*RPC
 	Descriptions []string
+
+	// Options are specified by the interface-level //kun:grpc directive.
+	Options ServiceOptions
+}
+
+// ServiceOptions are the options for the generated .proto file.
+type ServiceOptions struct {
+	// Package is the proto package, e.g. "acme.user.v1".
+	Package string
+	// GoPackage is the Go import path (optionally followed by a semicolon
+	// and the package name) of the code generated from the .proto file.
+	GoPackage string
+	// Out is the output directory of the .proto file, relative to the
+	// output directory of the gRPC code. It must not be absolute or contain
+	// "..", so that the file is always generated within the output directory.
+	Out string
+	// File is the file name of the .proto file.
+	File string
+}
+
+// GoImportPath returns the Go import path specified in GoPackage.
+func (o ServiceOptions) GoImportPath() string {
+	if i := strings.Index(o.GoPackage, ";"); i >= 0 {
+		return o.GoPackage[:i]
+	}
+	return o.GoPackage
+}
+
+// GoPackageName returns the Go package name specified in GoPackage, or
+// derived from the import path if not specified.
+func (o ServiceOptions) GoPackageName() string {
+	if i := strings.Index(o.GoPackage, ";"); i >= 0 {
+		return o.GoPackage[i+1:]
+	}
+	name := path.Base(o.GoPackage)
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
 }
 
 type RPC struct {
@@ -43,11 +116,90 @@ type RPC struct {
 	Request      *Message
 	Response     *Message
 	Descriptions []string
+
+	// ClientStream is non-nil if the client sends a stream of request messages.
+	ClientStream *Stream
+	// ServerStream is non-nil if the server sends a stream of response messages.
+	ServerStream *Stream
+
+	// Metadata are the method parameters bound to the gRPC metadata, which
+	// are excluded from the request message.
+	Metadata []*Metadata
+
+	// HTTPRules are the HTTP mappings of the RPC, if any. The first one is the
+	// primary rule, while the others are additional bindings.
+	HTTPRules []*HTTPRule
+
+	// requestParam is the method parameter mapped to the request message
+	// (i.e. by request=), if any.
+	requestParam string
+}
+
+// IsStreaming reports whether the RPC is a streaming one (i.e. server-streaming,
+// client-streaming or bidirectional streaming).
+func (r *RPC) IsStreaming() bool {
+	return r.ClientStream != nil || r.ServerStream != nil
+}
+
+// Metadata is a binding from a method parameter to a gRPC metadata key.
+type Metadata struct {
+	Param *ifacetool.Param
+	Key   string
+}
+
+// IsSlice reports whether the parameter holds all the values of the key,
+// instead of the first one.
+func (m *Metadata) IsSlice() bool {
+	return m.Param.TypeString == "[]string"
+}
+
+type StreamKind int
+
+const (
+	// StreamRecvChan is a parameter of type `<-chan T`, from which the
+	// service receives the messages sent by the client.
+	StreamRecvChan StreamKind = iota + 1
+	// StreamSendChan is a parameter of type `chan<- T`, to which the
+	// service sends the messages to the client.
+	StreamSendChan
+	// StreamResultChan is a result of type `<-chan T`, from which the
+	// messages to the client are received.
+	StreamResultChan
+	// StreamCallback is a parameter of type `func(T) error`, which the
+	// service calls to send each message to the client.
+	StreamCallback
+)
+
+func (k StreamKind) String() string {
+	switch k {
+	case StreamRecvChan:
+		return "recv-chan"
+	case StreamSendChan:
+		return "send-chan"
+	case StreamResultChan:
+		return "result-chan"
+	case StreamCallback:
+		return "callback"
+	default:
+		return ""
+	}
+}
+
+// Stream describes one direction of a streaming RPC.
+type Stream struct {
+	Kind StreamKind
+	// Param is the method parameter (or result) that carries the stream.
+	Param *ifacetool.Param
+	// ElemTypeString is the Go type of a single stream element.
+	ElemTypeString string
+	// Field is the message field holding a single stream element.
+	Field *Field
 }
 
 type Message struct {
-	Name   string
-	Fields []*Field
+	Name     string
+	Fields   []*Field
+	Reserved []int // numbers of the removed fields
 }
 
 type Field struct {
@@ -61,6 +213,31 @@ type Type struct {
 	Repeated bool     // true for slice: []Type
 	MapKey   string   // non-empty for map: map[key]Type
 	Fields   []*Field // non-empty for struct
+	Optional bool     // true for pointer to scalar or enum: *Type
+	Enum     *Enum    // non-nil for enum
+	Import   string   // non-empty for well-known type, e.g. "google/protobuf/timestamp.proto"
+	Reserved []int    // numbers of the removed fields, for struct only
+}
+
+// Enum is a proto enum converted from a Go named type, whose values are
+// declared as constants.
+type Enum struct {
+	Name     string
+	Values   []*EnumValue
+	Reserved []int // numbers of the removed values
+}
+
+type EnumValue struct {
+	Name string
+	Num  int
+	// GoValue is the Go literal of the constant value.
+	GoValue string
+}
+
+// isScalar reports whether t is a singular scalar type.
+func (t *Type) isScalar() bool {
+	_, ok := wrapperTypes[t.Name]
+	return ok && !t.Repeated && t.MapKey == ""
 }
 
 // Squash does a pre-order walk of t and returns all the composite types
@@ -78,10 +255,59 @@ func (t *Type) Squash() (types []*Type) {
 	return
 }
 
+// Enums returns all the enums used by the RPCs of s.
+func (s *Service) Enums() (enums []*Enum) {
+	seen := make(map[string]bool)
+	s.walkTypes(func(t *Type) {
+		if t.Enum != nil && !seen[t.Enum.Name] {
+			seen[t.Enum.Name] = true
+			enums = append(enums, t.Enum)
+		}
+	})
+	return
+}
+
+// Imports returns the .proto files, which define the well-known types used
+// by the RPCs of s.
+func (s *Service) Imports() (imports []string) {
+	seen := make(map[string]bool)
+	s.walkTypes(func(t *Type) {
+		if t.Import != "" && !seen[t.Import] {
+			seen[t.Import] = true
+			imports = append(imports, t.Import)
+		}
+	})
+	sort.Strings(imports)
+	return
+}
+
+func (s *Service) walkTypes(f func(*Type)) {
+	walk := func(fields []*Field) {
+		for _, field := range fields {
+			f(field.Type)
+			for _, t := range field.Type.Squash() {
+				for _, tf := range t.Fields {
+					f(tf.Type)
+				}
+			}
+		}
+	}
+	for _, rpc := range s.RPCs {
+		walk(rpc.Request.Fields)
+		walk(rpc.Response.Fields)
+	}
+}
+
 func Parse(data *ifacetool.Data) (*Service, error) {
+	opts, enums, err := parseServiceOptions(data.InterfaceDoc)
+	if err != nil {
+		return nil, err
+	}
+
 	s := &Service{
 		Name:         data.InterfaceName,
 		Descriptions: getDescriptionsFromDoc(data.InterfaceDoc),
+		Options:      opts,
 	}
 
 	for _, m := range data.Methods {
@@ -89,7 +315,12 @@ func Parse(data *ifacetool.Data) (*Service, error) {
 			continue
 		}
 
-		rpcFields, err := parseRPCFields(m)
+		clientStream, serverStream, err := parseStreams(m)
+		if err != nil {
+			return nil, err
+		}
+
+		rpcFields, err := parseRPCFields(enums, m, clientStream, serverStream)
 		if err != nil {
 			return nil, err
 		}
@@ -105,21 +336,34 @@ func Parse(data *ifacetool.Data) (*Service, error) {
 				Name:   m.Name + "Response",
 				Fields: rpcFields.Response,
 			},
+			ClientStream: clientStream,
+			ServerStream: serverStream,
+			Metadata:     rpcFields.Metadata,
+			requestParam: rpcFields.RequestParam,
 		})
 	}
 
+	for name, spec := range enums {
+		if !spec.used {
+			return nil, fmt.Errorf("enum %s is not used by any RPC", name)
+		}
+	}
+
 	return s, nil
 }
 
-func parse(params []*ifacetool.Param) ([]*Field, error) {
+func parse(enums enumSpecs, params []*ifacetool.Param, exclude *Stream) ([]*Field, error) {
 	var fields []*Field
 	var i int
 	for _, p := range params {
 		if p.TypeString == "context.Context" || p.TypeString == "error" {
 			continue
 		}
+		if exclude != nil && exclude.Param == p {
+			continue
+		}
 
-		typ, err := parseType(p.Name, p.Type)
+		typ, err := parseType(enums, p.Name, p.Type)
 		if err != nil {
 			return nil, err
 		}
@@ -134,13 +378,29 @@ func parse(params []*ifacetool.Param) ([]*Field, error) {
 	return fields, nil
 }
 
-func parseType(name string, typ types.Type) (*Type, error) {
+func parseType(enums enumSpecs, name string, typ types.Type) (*Type, error) {
+	if named, ok := typ.(*types.Named); ok {
+		// Well-known types must be checked first, since time.Duration
+		// also looks like an enum.
+		if wt := parseWellKnownType(named); wt != nil {
+			return wt, nil
+		}
+		if spec, ok := enums[named.Obj().Name()]; ok {
+			e, err := parseEnum(named, spec)
+			if err != nil {
+				return nil, err
+			}
+			spec.used = true
+			return &Type{Name: e.Name, Enum: e}, nil
+		}
+	}
+
 	switch t := typ.Underlying().(type) {
 	case *types.Basic:
 		return parseBasicType(t), nil
 
 	case *types.Slice:
-		st, err := parseSliceType(name, t)
+		st, err := parseSliceType(enums, name, t)
 		if err != nil {
 			return nil, err
 		}
@@ -155,19 +415,22 @@ func parseType(name string, typ types.Type) (*Type, error) {
 		kt := parseBasicType(bt)
 
 		// TODO: Add support for map[string]interface{}?
-		vt, err := parseType("", t.Elem())
+		vt, err := parseType(enums, "", t.Elem())
 		if err != nil {
 			return nil, err
 		}
+		vt = wrapOptional(vt)
 
 		return &Type{
 			Name:   vt.Name,
 			MapKey: kt.Name,   // type name of the map key
 			Fields: vt.Fields, // possible fields from the map value.
+			Enum:   vt.Enum,
+			Import: vt.Import,
 		}, nil
 
 	case *types.Struct:
-		st, err := parseStructType(name, typ, t)
+		st, err := parseStructType(enums, name, typ, t)
 		if err != nil {
 			return nil, err
 		}
@@ -175,12 +438,20 @@ func parseType(name string, typ types.Type) (*Type, error) {
 
 	case *types.Pointer:
 		// Dereference the pointer to parse the element type.
-		et, err := parseType(name, t.Elem())
+		et, err := parseType(enums, name, t.Elem())
 		if err != nil {
 			return nil, err
 		}
+		if et.isScalar() || et.Enum != nil {
+			// A pointer to scalar (or enum) has explicit presence.
+			et.Optional = true
+		}
 		return et, nil
 
+	case *types.Chan:
+		// A channel is a stream, whose message holds a single element.
+		return parseType(enums, name, t.Elem())
+
 	default:
 		return nil, fmt.Errorf("unsupported %T", t)
 	}
@@ -190,21 +461,165 @@ func parseBasicType(t *types.Basic) *Type {
 	return &Type{Name: scalarTypes[t.Name()]}
 }
 
-func parseSliceType(name string, t *types.Slice) (*Type, error) {
-	typ, err := parseType(name, t.Elem())
+func parseSliceType(enums enumSpecs, name string, t *types.Slice) (*Type, error) {
+	if bt, ok := t.Elem().(*types.Basic); ok && bt.Kind() == types.Byte {
+		// Go: []byte => proto: bytes
+		return &Type{Name: "bytes"}, nil
+	}
+
+	typ, err := parseType(enums, name, t.Elem())
 	if err != nil {
 		return nil, err
 	}
+	typ = wrapOptional(typ)
 
-	if typ.Name == "byte" {
-		// Go: []byte => proto: bytes
-		return &Type{Name: "bytes"}, nil
+	return &Type{
+		Name:     typ.Name,
+		Repeated: true,
+		Fields:   typ.Fields,
+		Enum:     typ.Enum,
+		Import:   typ.Import,
+	}, nil
+}
+
+// wrapOptional converts an optional scalar type, which is not allowed as
+// a repeated element or a map value, to the corresponding wrapper type.
+func wrapOptional(t *Type) *Type {
+	if !t.Optional {
+		return t
 	}
+	if t.Enum != nil {
+		// There is no wrapper type for enums, so the presence is lost.
+		return &Type{Name: t.Name, Enum: t.Enum}
+	}
+	return &Type{
+		Name:   wrapperTypes[t.Name],
+		Import: "google/protobuf/wrappers.proto",
+	}
+}
 
-	return &Type{Name: typ.Name, Repeated: true}, nil
+// parseWellKnownType converts some Go standard types to the corresponding
+// well-known types. See https://protobuf.dev/reference/protobuf/google.protobuf/.
+func parseWellKnownType(t *types.Named) *Type {
+	obj := t.Obj()
+	if obj.Pkg() == nil || obj.Pkg().Path() != "time" {
+		return nil
+	}
+	switch obj.Name() {
+	case "Time":
+		return &Type{Name: "google.protobuf.Timestamp", Import: "google/protobuf/timestamp.proto"}
+	case "Duration":
+		return &Type{Name: "google.protobuf.Duration", Import: "google/protobuf/duration.proto"}
+	default:
+		return nil
+	}
 }
 
-func parseStructType(name string, typ types.Type, t *types.Struct) (*Type, error) {
+// parseEnum converts t to an enum as specified by spec. The underlying type
+// of t must be an integer or a string, and there must be constants of type t
+// declared in the same package.
+//
+// Integer constants are mapped to enum values with the same numbers, while
+// string constants are mapped to the numbers specified by spec. An
+// additional zero value (i.e. <NAME>_UNSPECIFIED) is added if there is no
+// constant whose value is the zero value of t.
+func parseEnum(t *types.Named, spec *enumSpec) (*Enum, error) {
+	name := t.Obj().Name()
+	bt, ok := t.Underlying().(*types.Basic)
+	if !ok || bt.Info()&(types.IsInteger|types.IsString) == 0 {
+		return nil, fmt.Errorf("enum %s must be of an integer or string type", name)
+	}
+	isString := bt.Info()&types.IsString != 0
+	if isString && spec.nums == nil {
+		return nil, fmt.Errorf("the numbers of the string enum %s must be specified, e.g. enum=%s:<const>=<num>,...", name, name)
+	}
+	if !isString && spec.nums != nil {
+		return nil, fmt.Errorf("the numbers of the integer enum %s must not be specified, since they are the values of the constants", name)
+	}
+
+	var consts []*types.Const
+	if pkg := t.Obj().Pkg(); pkg != nil {
+		scope := pkg.Scope()
+		for _, n := range scope.Names() {
+			c, ok := scope.Lookup(n).(*types.Const)
+			if ok && types.Identical(c.Type(), t) {
+				consts = append(consts, c)
+			}
+		}
+	}
+	if len(consts) == 0 {
+		return nil, fmt.Errorf("no constants of the enum %s", name)
+	}
+	sort.SliceStable(consts, func(i, j int) bool {
+		return consts[i].Pos() < consts[j].Pos()
+	})
+
+	known := make(map[string]bool)
+	for _, c := range consts {
+		known[c.Name()] = true
+	}
+	for n := range spec.nums {
+		if !known[n] {
+			return nil, fmt.Errorf("unknown constant %s of the enum %s", n, name)
+		}
+	}
+
+	prefix := strings.ToUpper(caseconv.ToSnakeCase(name))
+	zero := "0"
+	if isString {
+		zero = `""`
+	}
+
+	seen := make(map[string]bool)
+	nums := make(map[int]string) // enum number -> constant name
+	var values []*EnumValue
+	for _, c := range consts {
+		goValue := c.Val().ExactString()
+		if seen[goValue] {
+			// Skip aliases of the same value.
+			continue
+		}
+		seen[goValue] = true
+
+		v := &EnumValue{
+			Name:    prefix + "_" + strings.ToUpper(caseconv.ToSnakeCase(strings.TrimPrefix(c.Name(), name))),
+			GoValue: goValue,
+		}
+		switch {
+		case goValue == zero:
+			v.Num = 0
+		case isString:
+			n, ok := spec.nums[c.Name()]
+			if !ok {
+				return nil, fmt.Errorf("missing the number of the constant %s of the enum %s", c.Name(), name)
+			}
+			v.Num = n
+		default:
+			n, ok := constant.Int64Val(c.Val())
+			if !ok || n < math.MinInt32 || n > math.MaxInt32 {
+				return nil, fmt.Errorf("the value of the constant %s of the enum %s is out of the int32 range", c.Name(), name)
+			}
+			v.Num = int(n)
+		}
+		if other, ok := nums[v.Num]; ok {
+			return nil, fmt.Errorf("the constants %s and %s of the enum %s have the same number %d", other, c.Name(), name, v.Num)
+		}
+		nums[v.Num] = c.Name()
+		values = append(values, v)
+	}
+
+	if !seen[zero] {
+		// The first enum value must be zero in proto3.
+		values = append(values, &EnumValue{Name: prefix + "_UNSPECIFIED", Num: 0, GoValue: zero})
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Num < values[j].Num
+	})
+
+	return &Enum{Name: caseconv.ToUpperCamelCase(name), Values: values}, nil
+}
+
+func parseStructType(enums enumSpecs, name string, typ types.Type, t *types.Struct) (*Type, error) {
 	// Try to get the actual type name if typ is a named type.
 	named, ok := typ.(*types.Named)
 	if ok {
@@ -219,7 +634,7 @@ func parseStructType(name string, typ types.Type, t *types.Struct) (*Type, error
 			return nil, nil
 		}
 
-		fieldType, err := parseType(fieldName, t.Field(i).Type())
+		fieldType, err := parseType(enums, fieldName, t.Field(i).Type())
 		if err != nil {
 			return nil, err
 		}
@@ -258,6 +673,121 @@ func getDescriptionsFromDoc(doc []string) (comments []string) {
 	return
 }
 
+// enumSpec specifies how a Go named type is converted to an enum.
+type enumSpec struct {
+	// nums maps the names of the constants to the enum numbers, which must
+	// be specified for string types only.
+	nums map[string]int
+	// used is true if the enum is used by any RPC.
+	used bool
+}
+
+// enumSpecs maps the names of Go named types to their enum specifications.
+type enumSpecs map[string]*enumSpec
+
+// parseServiceOptions parses the options from the interface-level //kun:grpc
+// directive, for example:
+//
+//	//kun:grpc package=acme.user.v1 go_package=github.com/acme/schemas/gen/go/acme/user/v1;userv1
+//	//kun:grpc enum=Level enum=Kind:KindGauge=1,KindCounter=2
+func parseServiceOptions(doc []string) (opts ServiceOptions, enums enumSpecs, err error) {
+	enums = make(enumSpecs)
+	for _, comment := range doc {
+		if annotation.Directive(comment).Dialect() != annotation.DialectGRPC {
+			continue
+		}
+
+		result := reGRPC.FindStringSubmatch(comment)
+		if len(result) != 2 {
+			return opts, nil, fmt.Errorf("invalid %s directive: %s", annotation.DirectiveGRPC, comment)
+		}
+
+		for _, f := range strings.Fields(result[1]) {
+			k, v, ok := strings.Cut(f, "=")
+			if !ok || (strings.Contains(v, "=") && k != "enum") {
+				return opts, nil, fmt.Errorf(`%q does not match the expected format: <key>=<value>`, f)
+			}
+
+			switch k {
+			case "package":
+				if !reProtoPackage.MatchString(v) {
+					return opts, nil, fmt.Errorf("invalid proto package %q", v)
+				}
+				opts.Package = v
+			case "go_package":
+				opts.GoPackage = v
+			case "out":
+				if !isLocalPath(v) {
+					return opts, nil, fmt.Errorf("invalid output directory %q: must be a relative path without \"..\"", v)
+				}
+				opts.Out = v
+			case "file":
+				if !strings.HasSuffix(v, ".proto") || strings.ContainsAny(v, `/\`) {
+					return opts, nil, fmt.Errorf("invalid proto file name %q", v)
+				}
+				opts.File = v
+			case "enum":
+				name, spec, err := parseEnumSpec(v)
+				if err != nil {
+					return opts, nil, err
+				}
+				if _, ok := enums[name]; ok {
+					return opts, nil, fmt.Errorf("duplicate enum %s", name)
+				}
+				enums[name] = spec
+			default:
+				return opts, nil, fmt.Errorf(`unrecognized %s key "%s" in comment: %s`, annotation.Name, k, comment)
+			}
+		}
+	}
+	return opts, enums, nil
+}
+
+// parseEnumSpec parses the value of the key "enum", which is in the form of
+// <type> for integer types, or <type>:<const>=<num>,... for string types.
+func parseEnumSpec(value string) (name string, spec *enumSpec, err error) {
+	name, list, hasNums := strings.Cut(value, ":")
+	if !token.IsIdentifier(name) {
+		return "", nil, fmt.Errorf("invalid enum type %q", name)
+	}
+
+	spec = new(enumSpec)
+	if !hasNums {
+		return name, spec, nil
+	}
+
+	spec.nums = make(map[string]int)
+	for _, pair := range strings.Split(list, ",") {
+		c, n, ok := strings.Cut(pair, "=")
+		if !ok || !token.IsIdentifier(c) {
+			return "", nil, fmt.Errorf("%q of the enum %s does not match the expected format: <const>=<num>", pair, name)
+		}
+		num, err := strconv.Atoi(n)
+		if err != nil || num < 1 || num > math.MaxInt32 {
+			return "", nil, fmt.Errorf("invalid number %q of the constant %s of the enum %s: must be a positive int32", n, c, name)
+		}
+		if _, ok := spec.nums[c]; ok {
+			return "", nil, fmt.Errorf("duplicate constant %s of the enum %s", c, name)
+		}
+		spec.nums[c] = num
+	}
+	return name, spec, nil
+}
+
+// isLocalPath reports whether p is a relative path, which stays within the
+// directory it is relative to.
+func isLocalPath(p string) bool {
+	if filepath.IsAbs(p) || filepath.VolumeName(p) != "" || strings.HasPrefix(p, "/") || strings.HasPrefix(p, `\`) {
+		return false
+	}
+	for _, elem := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if elem == ".." {
+			return false
+		}
+	}
+	return true
+}
+
 func hasGRPCAnnotation(doc []string) bool {
 	for _, comment := range doc {
 		if annotation.Directive(comment).Dialect() == annotation.DialectGRPC {
@@ -267,18 +797,151 @@ func hasGRPCAnnotation(doc []string) bool {
 	return false
 }
 
+// parseStreams finds out the parameters and results, which carry streams,
+// from the signature of method.
+//
+// The following types are recognized:
+//
+//   - `<-chan T` parameter: the client sends a stream of T.
+//   - `chan<- T` parameter: the server sends a stream of T.
+//   - `<-chan T` result: the server sends a stream of T.
+//   - `func(T) error` parameter: the server sends a stream of T.
+func parseStreams(method *ifacetool.Method) (client, server *Stream, err error) {
+	setStream := func(ptr **Stream, s *Stream) error {
+		if *ptr != nil {
+			return fmt.Errorf("method %s has more than one stream in the same direction: `%s` and `%s`", method.Name, (*ptr).Param.Name, s.Param.Name)
+		}
+		*ptr = s
+		return nil
+	}
+
+	for _, p := range method.Params {
+		switch t := p.Type.(type) {
+		case *types.Chan:
+			switch t.Dir() {
+			case types.RecvOnly:
+				err = setStream(&client, newStream(StreamRecvChan, p))
+			case types.SendOnly:
+				err = setStream(&server, newStream(StreamSendChan, p))
+			default:
+				err = fmt.Errorf("channel param `%s` in the method %s must be either receive-only or send-only", p.Name, method.Name)
+			}
+		case *types.Signature:
+			if !isCallback(t) {
+				err = fmt.Errorf("func param `%s` in the method %s must be of the form `func(T) error`", p.Name, method.Name)
+				break
+			}
+			err = setStream(&server, newStream(StreamCallback, p))
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, r := range method.Returns {
+		t, ok := r.Type.(*types.Chan)
+		if !ok {
+			continue
+		}
+		if t.Dir() != types.RecvOnly {
+			return nil, nil, fmt.Errorf("channel result `%s` in the method %s must be receive-only", r.Name, method.Name)
+		}
+		if err := setStream(&server, newStream(StreamResultChan, r)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if client == nil && server == nil {
+		return nil, nil, nil
+	}
+
+	if n := len(method.Returns); n == 0 || method.Returns[n-1].TypeString != "error" {
+		return nil, nil, fmt.Errorf("streaming method %s must return an error as its last result", method.Name)
+	}
+	if server != nil {
+		for _, r := range method.Returns {
+			if r != server.Param && r.TypeString != "error" {
+				return nil, nil, fmt.Errorf("server-streaming method %s cannot have non-stream result `%s`", method.Name, r.Name)
+			}
+		}
+	}
+
+	return client, server, nil
+}
+
+func newStream(kind StreamKind, param *ifacetool.Param) *Stream {
+	return &Stream{
+		Kind:           kind,
+		Param:          param,
+		ElemTypeString: elemTypeString(kind, param),
+	}
+}
+
+// ElemType returns the Go type of a single stream element.
+func (s *Stream) ElemType() types.Type {
+	switch t := s.Param.Type.(type) {
+	case *types.Chan:
+		return t.Elem()
+	case *types.Signature:
+		return t.Params().At(0).Type()
+	default:
+		return t
+	}
+}
+
+// elemTypeString extracts the string representation of the element type
+// from the type string of param, to keep the same package qualifiers.
+func elemTypeString(kind StreamKind, param *ifacetool.Param) string {
+	s := param.TypeString
+	switch kind {
+	case StreamRecvChan, StreamResultChan:
+		return strings.TrimPrefix(s, "<-chan ")
+	case StreamSendChan:
+		return strings.TrimPrefix(s, "chan<- ")
+	case StreamCallback:
+		s = strings.TrimSuffix(strings.TrimPrefix(s, "func("), ") error")
+		if name := param.Type.(*types.Signature).Params().At(0).Name(); name != "" {
+			s = strings.TrimPrefix(s, name+" ")
+		}
+		return s
+	default:
+		return s
+	}
+}
+
+// ElemName returns the name of a single stream element, which defaults to
+// the name of the stream parameter (or result). For a callback, the name of
+// its own parameter, if any, takes precedence.
+func (s *Stream) ElemName() string {
+	if t, ok := s.Param.Type.(*types.Signature); ok {
+		if name := t.Params().At(0).Name(); name != "" && name != "_" {
+			return name
+		}
+	}
+	return s.Param.Name
+}
+
+func isCallback(t *types.Signature) bool {
+	if t.Params().Len() != 1 || t.Variadic() || t.Results().Len() != 1 {
+		return false
+	}
+	return t.Results().At(0).Type().String() == "error"
+}
+
 type rpcFields struct {
-	Request  []*Field
-	Response []*Field
+	Request      []*Field
+	Response     []*Field
+	Metadata     []*Metadata
+	RequestParam string
 }
 
-func parseRPCFields(method *ifacetool.Method) (*rpcFields, error) {
-	reqFields, err := parse(method.Params)
+func parseRPCFields(enums enumSpecs, method *ifacetool.Method, clientStream, serverStream *Stream) (*rpcFields, error) {
+	reqFields, err := parse(enums, method.Params, serverStream)
 	if err != nil {
 		return nil, err
 	}
 
-	respFields, err := parse(method.Returns)
+	respFields, err := parse(enums, method.Returns, serverStream)
 	if err != nil {
 		return nil, err
 	}
@@ -288,14 +951,39 @@ func parseRPCFields(method *ifacetool.Method) (*rpcFields, error) {
 		Response: respFields,
 	}
 
-	if err := rpcFields.manipulateByComments(method); err != nil {
+	if clientStream != nil {
+		// Each request message of a client-streaming RPC holds a single
+		// stream element, along with all the other (non-stream) parameters.
+		for _, f := range reqFields {
+			if f.Name == clientStream.Param.Name {
+				clientStream.Field = f
+			}
+		}
+	}
+
+	if serverStream != nil {
+		// The response message of a server-streaming RPC holds a single
+		// stream element only.
+		typ, err := parseType(enums, serverStream.Param.Name, serverStream.ElemType())
+		if err != nil {
+			return nil, err
+		}
+		serverStream.Field = &Field{
+			Name: serverStream.ElemName(),
+			Type: typ,
+			Num:  1,
+		}
+		rpcFields.Response = []*Field{serverStream.Field}
+	}
+
+	if err := rpcFields.manipulateByComments(enums, method, clientStream != nil || serverStream != nil); err != nil {
 		return nil, err
 	}
 
 	return rpcFields, nil
 }
 
-func (rf *rpcFields) manipulateByComments(method *ifacetool.Method) error {
+func (rf *rpcFields) manipulateByComments(enums enumSpecs, method *ifacetool.Method, streaming bool) error {
 	params := make(map[string]*ifacetool.Param)
 	for _, p := range method.Params {
 		params[p.Name] = p
@@ -328,6 +1016,10 @@ func (rf *rpcFields) manipulateByComments(method *ifacetool.Method) error {
 			}
 			k, v := parts[0], parts[1]
 
+			if streaming && (k == "request" || k == "response") {
+				return fmt.Errorf("%s key %q is not supported by the streaming method %s", annotation.DirectiveGRPC, k, method.Name)
+			}
+
 			switch k {
 			case "request":
 				p, ok := params[v]
@@ -338,11 +1030,12 @@ func (rf *rpcFields) manipulateByComments(method *ifacetool.Method) error {
 					return fmt.Errorf("non-struct param `%s` in the method %s cannot be mapped to a gRPC request", v, method.Name)
 				}
 
-				structType, err := parseType(p.Name, p.Type)
+				structType, err := parseType(enums, p.Name, p.Type)
 				if err != nil {
 					return err
 				}
 				rf.Request = structType.Fields
+				rf.RequestParam = p.Name
 
 			case "response":
 				p, ok := returns[v]
@@ -353,21 +1046,85 @@ func (rf *rpcFields) manipulateByComments(method *ifacetool.Method) error {
 					return fmt.Errorf("non-struct result `%s` in the method %s cannot be mapped to a gRPC response", v, method.Name)
 				}
 
-				structType, err := parseType(p.Name, p.Type)
+				structType, err := parseType(enums, p.Name, p.Type)
 				if err != nil {
 					return err
 				}
 				rf.Response = structType.Fields
 
+			case "metadata":
+				md, err := parseMetadata(v, params, method.Name)
+				if err != nil {
+					return err
+				}
+				rf.Metadata = append(rf.Metadata, md...)
+
 			default:
 				return fmt.Errorf(`unrecognized %s key "%s" in comment: %s`, annotation.Name, k, comment)
 			}
 		}
 	}
 
+	if len(rf.Metadata) > 0 {
+		rf.Request = excludeMetadata(rf.Request, rf.Metadata)
+	}
+
 	return nil
 }
 
+// parseMetadata parses the value of the key "metadata", which is a
+// comma-separated list of <param>:<key>. The metadata key defaults to
+// the param name in kebab case if omitted.
+func parseMetadata(value string, params map[string]*ifacetool.Param, methodName string) (mds []*Metadata, err error) {
+	for _, item := range strings.Split(value, ",") {
+		name, key := item, ""
+		if i := strings.Index(item, ":"); i >= 0 {
+			name, key = item[:i], item[i+1:]
+		}
+
+		p, ok := params[name]
+		if !ok {
+			return nil, fmt.Errorf("no param `%s` declared in the method %s", name, methodName)
+		}
+		if p.TypeString != "string" && p.TypeString != "[]string" {
+			return nil, fmt.Errorf("param `%s` in the method %s must be of type string or []string to be bound to metadata", name, methodName)
+		}
+
+		if key == "" {
+			key = strings.ReplaceAll(caseconv.ToSnakeCase(name), "_", "-")
+		}
+		// Metadata keys are case insensitive, and are converted to lowercase.
+		key = strings.ToLower(key)
+		if !reMetadataKey.MatchString(key) || strings.HasSuffix(key, "-bin") {
+			return nil, fmt.Errorf("invalid metadata key %q for param `%s` in the method %s", key, name, methodName)
+		}
+
+		mds = append(mds, &Metadata{Param: p, Key: key})
+	}
+	return mds, nil
+}
+
+// excludeMetadata removes the fields bound to metadata, and renumbers the
+// remaining fields in order.
+//
+// Note that the numbers of the removed fields are only reserved if they
+// exist in the .proto file generated before (see Service.Renumber).
+func excludeMetadata(fields []*Field, mds []*Metadata) (remaining []*Field) {
+	excluded := make(map[string]bool)
+	for _, md := range mds {
+		excluded[md.Param.Name] = true
+	}
+
+	for _, f := range fields {
+		if excluded[f.Name] {
+			continue
+		}
+		remaining = append(remaining, f)
+		f.Num = len(remaining)
+	}
+	return remaining
+}
+
 func isStructType(typ types.Type) bool {
 	switch t := typ.Underlying().(type) {
 	case *types.Struct:
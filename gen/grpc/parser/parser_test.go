@@ -0,0 +1,689 @@
+package parser
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/RussellLuo/kun/pkg/ifacetool"
+)
+
+var (
+	ctxParam = &ifacetool.Param{
+		Name:       "ctx",
+		TypeString: "context.Context",
+		Type:       types.NewNamed(types.NewTypeName(0, nil, "Context", nil), types.NewInterfaceType(nil, nil), nil),
+	}
+	errParam = &ifacetool.Param{
+		Name:       "err",
+		TypeString: "error",
+		Type:       types.Universe.Lookup("error").Type(),
+	}
+)
+
+func newParam(name, typeString string, typ types.Type) *ifacetool.Param {
+	return &ifacetool.Param{
+		Name:       name,
+		TypeString: typeString,
+		Type:       typ,
+	}
+}
+
+func newCallback(paramName string, typ types.Type) *types.Signature {
+	params := types.NewTuple(types.NewVar(0, nil, paramName, typ))
+	results := types.NewTuple(types.NewVar(0, nil, "", types.Universe.Lookup("error").Type()))
+	return types.NewSignatureType(nil, nil, nil, params, results, false)
+}
+
+func TestParseStreams(t *testing.T) {
+	str := types.Typ[types.String]
+
+	tests := []struct {
+		name             string
+		inMethod         *ifacetool.Method
+		wantClient       *Stream
+		wantServer       *Stream
+		wantServerField  string
+		wantRequestNames []string
+		wantErrStr       string
+	}{
+		{
+			name: "unary",
+			inMethod: &ifacetool.Method{
+				Name:    "Get",
+				Params:  []*ifacetool.Param{ctxParam, newParam("id", "string", str)},
+				Returns: []*ifacetool.Param{newParam("name", "string", str), errParam},
+			},
+			wantRequestNames: []string{"id"},
+		},
+		{
+			name: "client streaming",
+			inMethod: &ifacetool.Method{
+				Name: "Ingest",
+				Params: []*ifacetool.Param{
+					ctxParam,
+					newParam("metric", "string", str),
+					newParam("values", "<-chan string", types.NewChan(types.RecvOnly, str)),
+				},
+				Returns: []*ifacetool.Param{newParam("count", "int", types.Typ[types.Int]), errParam},
+			},
+			wantClient:       &Stream{Kind: StreamRecvChan, ElemTypeString: "string"},
+			wantRequestNames: []string{"metric", "values"},
+		},
+		{
+			name: "server streaming by send-only channel",
+			inMethod: &ifacetool.Method{
+				Name: "Watch",
+				Params: []*ifacetool.Param{
+					ctxParam,
+					newParam("key", "string", str),
+					newParam("values", "chan<- string", types.NewChan(types.SendOnly, str)),
+				},
+				Returns: []*ifacetool.Param{errParam},
+			},
+			wantServer:       &Stream{Kind: StreamSendChan, ElemTypeString: "string"},
+			wantServerField:  "values",
+			wantRequestNames: []string{"key"},
+		},
+		{
+			name: "server streaming by result channel",
+			inMethod: &ifacetool.Method{
+				Name:   "Watch",
+				Params: []*ifacetool.Param{ctxParam, newParam("key", "string", str)},
+				Returns: []*ifacetool.Param{
+					newParam("values", "<-chan string", types.NewChan(types.RecvOnly, str)),
+					errParam,
+				},
+			},
+			wantServer:       &Stream{Kind: StreamResultChan, ElemTypeString: "string"},
+			wantServerField:  "values",
+			wantRequestNames: []string{"key"},
+		},
+		{
+			name: "server streaming by callback",
+			inMethod: &ifacetool.Method{
+				Name: "List",
+				Params: []*ifacetool.Param{
+					ctxParam,
+					newParam("yield", "func(value string) error", newCallback("value", str)),
+				},
+				Returns: []*ifacetool.Param{errParam},
+			},
+			wantServer:      &Stream{Kind: StreamCallback, ElemTypeString: "string"},
+			wantServerField: "value",
+		},
+		{
+			name: "bidirectional streaming",
+			inMethod: &ifacetool.Method{
+				Name: "Echo",
+				Params: []*ifacetool.Param{
+					ctxParam,
+					newParam("in", "<-chan string", types.NewChan(types.RecvOnly, str)),
+					newParam("out", "chan<- string", types.NewChan(types.SendOnly, str)),
+				},
+				Returns: []*ifacetool.Param{errParam},
+			},
+			wantClient:       &Stream{Kind: StreamRecvChan, ElemTypeString: "string"},
+			wantServer:       &Stream{Kind: StreamSendChan, ElemTypeString: "string"},
+			wantServerField:  "out",
+			wantRequestNames: []string{"in"},
+		},
+		{
+			name: "bidirectional channel",
+			inMethod: &ifacetool.Method{
+				Name:    "Echo",
+				Params:  []*ifacetool.Param{newParam("values", "chan string", types.NewChan(types.SendRecv, str))},
+				Returns: []*ifacetool.Param{errParam},
+			},
+			wantErrStr: "channel param `values` in the method Echo must be either receive-only or send-only",
+		},
+		{
+			name: "more than one server stream",
+			inMethod: &ifacetool.Method{
+				Name:   "Watch",
+				Params: []*ifacetool.Param{newParam("a", "chan<- string", types.NewChan(types.SendOnly, str))},
+				Returns: []*ifacetool.Param{
+					newParam("b", "<-chan string", types.NewChan(types.RecvOnly, str)),
+					errParam,
+				},
+			},
+			wantErrStr: "method Watch has more than one stream in the same direction: `a` and `b`",
+		},
+		{
+			name: "no error result",
+			inMethod: &ifacetool.Method{
+				Name:   "Ingest",
+				Params: []*ifacetool.Param{newParam("values", "<-chan string", types.NewChan(types.RecvOnly, str))},
+			},
+			wantErrStr: "streaming method Ingest must return an error as its last result",
+		},
+		{
+			name: "server streaming with non-stream results",
+			inMethod: &ifacetool.Method{
+				Name:   "Watch",
+				Params: []*ifacetool.Param{newParam("values", "chan<- string", types.NewChan(types.SendOnly, str))},
+				Returns: []*ifacetool.Param{
+					newParam("total", "int", types.Typ[types.Int]),
+					errParam,
+				},
+			},
+			wantErrStr: "server-streaming method Watch cannot have non-stream result `total`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.inMethod.Doc = []string{"//kun:grpc"}
+			s, err := Parse(&ifacetool.Data{
+				InterfaceName: "Service",
+				Methods:       []*ifacetool.Method{tt.inMethod},
+			})
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if err != nil {
+				return
+			}
+
+			rpc := s.RPCs[0]
+			assertStream(t, "ClientStream", rpc.ClientStream, tt.wantClient)
+			assertStream(t, "ServerStream", rpc.ServerStream, tt.wantServer)
+
+			if tt.wantServer != nil {
+				fields := rpc.Response.Fields
+				if len(fields) != 1 || fields[0].Name != tt.wantServerField || fields[0] != rpc.ServerStream.Field {
+					t.Fatalf("Response.Fields: got (%#v), want the single field %q", fields, tt.wantServerField)
+				}
+			}
+
+			var reqNames []string
+			for _, f := range rpc.Request.Fields {
+				reqNames = append(reqNames, f.Name)
+			}
+			if len(reqNames) != len(tt.wantRequestNames) {
+				t.Fatalf("Request.Fields: got (%v), want (%v)", reqNames, tt.wantRequestNames)
+			}
+			for i := range reqNames {
+				if reqNames[i] != tt.wantRequestNames[i] {
+					t.Fatalf("Request.Fields: got (%v), want (%v)", reqNames, tt.wantRequestNames)
+				}
+			}
+		})
+	}
+}
+
+func assertStream(t *testing.T, name string, got, want *Stream) {
+	if (got == nil) != (want == nil) {
+		t.Fatalf("%s: got (%#v), want (%#v)", name, got, want)
+	}
+	if got == nil {
+		return
+	}
+	if got.Kind != want.Kind || got.ElemTypeString != want.ElemTypeString {
+		t.Fatalf("%s: got (%s, %s), want (%s, %s)", name, got.Kind, got.ElemTypeString, want.Kind, want.ElemTypeString)
+	}
+	if got.Field == nil {
+		t.Fatalf("%s: got nil Field", name)
+	}
+}
+
+func TestParseType(t *testing.T) {
+	timePkg := types.NewPackage("time", "time")
+	timeType := types.NewNamed(types.NewTypeName(0, timePkg, "Time", nil), types.NewStruct(nil, nil), nil)
+	durationType := types.NewNamed(types.NewTypeName(0, timePkg, "Duration", nil), types.Typ[types.Int64], nil)
+	timePkg.Scope().Insert(types.NewConst(0, timePkg, "Second", durationType, constant.MakeInt64(1e9)))
+
+	pkg := types.NewPackage("example.com/svc", "svc")
+	newEnum := func(name string, underlying types.Type, values map[string]constant.Value, order []string) *types.Named {
+		typ := types.NewNamed(types.NewTypeName(0, pkg, name, nil), underlying, nil)
+		for i, n := range order {
+			pkg.Scope().Insert(types.NewConst(token.Pos(i+1), pkg, n, typ, values[n]))
+		}
+		return typ
+	}
+	statusType := newEnum("Status", types.Typ[types.String], map[string]constant.Value{
+		"StatusInactive": constant.MakeString("inactive"),
+		"StatusActive":   constant.MakeString("active"),
+	}, []string{"StatusInactive", "StatusActive"})
+	levelType := newEnum("Level", types.Typ[types.Int], map[string]constant.Value{
+		"LevelLow":  constant.MakeInt64(0),
+		"LevelHigh": constant.MakeInt64(2),
+	}, []string{"LevelLow", "LevelHigh"})
+	weightType := newEnum("Weight", types.Typ[types.Int], map[string]constant.Value{
+		"DefaultWeight": constant.MakeInt64(10),
+	}, []string{"DefaultWeight"})
+	noConstType := types.NewNamed(types.NewTypeName(0, pkg, "Name", nil), types.Typ[types.String], nil)
+
+	enums := enumSpecs{
+		"Status": {nums: map[string]int{"StatusInactive": 2, "StatusActive": 1}},
+		"Level":  {},
+	}
+
+	tests := []struct {
+		name    string
+		inType  types.Type
+		wantStr string
+	}{
+		{
+			name:    "int8",
+			inType:  types.Typ[types.Int8],
+			wantStr: "int32",
+		},
+		{
+			name:    "bytes",
+			inType:  types.NewSlice(types.Universe.Lookup("byte").Type()),
+			wantStr: "bytes",
+		},
+		{
+			name:    "time.Time",
+			inType:  timeType,
+			wantStr: "google.protobuf.Timestamp (google/protobuf/timestamp.proto)",
+		},
+		{
+			name:    "time.Duration",
+			inType:  durationType,
+			wantStr: "google.protobuf.Duration (google/protobuf/duration.proto)",
+		},
+		{
+			name:    "pointer to scalar",
+			inType:  types.NewPointer(types.Typ[types.Int]),
+			wantStr: "optional int64",
+		},
+		{
+			name:    "slice of pointers to scalar",
+			inType:  types.NewSlice(types.NewPointer(types.Typ[types.String])),
+			wantStr: "repeated google.protobuf.StringValue (google/protobuf/wrappers.proto)",
+		},
+		{
+			name:    "map of pointers to scalar",
+			inType:  types.NewMap(types.Typ[types.String], types.NewPointer(types.Typ[types.Bool])),
+			wantStr: "map<string, google.protobuf.BoolValue> (google/protobuf/wrappers.proto)",
+		},
+		{
+			name:    "string enum",
+			inType:  statusType,
+			wantStr: `Status {STATUS_UNSPECIFIED = 0 (""), STATUS_ACTIVE = 1 ("active"), STATUS_INACTIVE = 2 ("inactive")}`,
+		},
+		{
+			name:    "int enum",
+			inType:  types.NewPointer(levelType),
+			wantStr: `optional Level {LEVEL_LOW = 0 (0), LEVEL_HIGH = 2 (2)}`,
+		},
+		{
+			name:    "named int not specified as enum",
+			inType:  weightType,
+			wantStr: "int64",
+		},
+		{
+			name:    "named string without constants",
+			inType:  noConstType,
+			wantStr: "string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ, err := parseType(enums, "", tt.inType)
+			if err != nil {
+				t.Fatalf("Err: got (%#v), want (nil)", err)
+			}
+			if got := typeString(typ); got != tt.wantStr {
+				t.Fatalf("Type: got (%s), want (%s)", got, tt.wantStr)
+			}
+		})
+	}
+}
+
+func typeString(t *Type) string {
+	s := t.Name
+	if t.MapKey != "" {
+		s = fmt.Sprintf("map<%s, %s>", t.MapKey, s)
+	}
+	if t.Repeated {
+		s = "repeated " + s
+	}
+	if t.Optional {
+		s = "optional " + s
+	}
+	if t.Import != "" {
+		s += " (" + t.Import + ")"
+	}
+	if t.Enum != nil {
+		var values []string
+		for _, v := range t.Enum.Values {
+			values = append(values, fmt.Sprintf("%s = %d (%s)", v.Name, v.Num, v.GoValue))
+		}
+		s += " {" + strings.Join(values, ", ") + "}"
+	}
+	return s
+}
+
+func TestParseEnum(t *testing.T) {
+	pkg := types.NewPackage("example.com/svc", "svc")
+	newEnum := func(name string, underlying types.Type, values ...constant.Value) *types.Named {
+		typ := types.NewNamed(types.NewTypeName(0, pkg, name, nil), underlying, nil)
+		for i, v := range values {
+			pkg.Scope().Insert(types.NewConst(token.Pos(i+1), pkg, fmt.Sprintf("%s%d", name, i), typ, v))
+		}
+		return typ
+	}
+	colorType := newEnum("Color", types.Typ[types.String], constant.MakeString("red"), constant.MakeString("blue"))
+	sizeType := newEnum("Size", types.Typ[types.Int64], constant.MakeInt64(1), constant.MakeInt64(1<<31))
+	ratioType := newEnum("Ratio", types.Typ[types.Float64], constant.MakeFloat64(0.5))
+	noConstType := types.NewNamed(types.NewTypeName(0, pkg, "Name", nil), types.Typ[types.String], nil)
+
+	tests := []struct {
+		name       string
+		inType     *types.Named
+		inSpec     *enumSpec
+		wantErrStr string
+	}{
+		{
+			name:       "string enum without numbers",
+			inType:     colorType,
+			inSpec:     &enumSpec{},
+			wantErrStr: "the numbers of the string enum Color must be specified, e.g. enum=Color:<const>=<num>,...",
+		},
+		{
+			name:       "missing number",
+			inType:     colorType,
+			inSpec:     &enumSpec{nums: map[string]int{"Color0": 1}},
+			wantErrStr: "missing the number of the constant Color1 of the enum Color",
+		},
+		{
+			name:       "unknown constant",
+			inType:     colorType,
+			inSpec:     &enumSpec{nums: map[string]int{"Color0": 1, "Color1": 2, "Green": 3}},
+			wantErrStr: "unknown constant Green of the enum Color",
+		},
+		{
+			name:       "duplicate numbers",
+			inType:     colorType,
+			inSpec:     &enumSpec{nums: map[string]int{"Color0": 1, "Color1": 1}},
+			wantErrStr: "the constants Color0 and Color1 of the enum Color have the same number 1",
+		},
+		{
+			name:       "integer enum with numbers",
+			inType:     sizeType,
+			inSpec:     &enumSpec{nums: map[string]int{"Size0": 1}},
+			wantErrStr: "the numbers of the integer enum Size must not be specified, since they are the values of the constants",
+		},
+		{
+			name:       "integer out of int32 range",
+			inType:     sizeType,
+			inSpec:     &enumSpec{},
+			wantErrStr: "the value of the constant Size1 of the enum Size is out of the int32 range",
+		},
+		{
+			name:       "non-integer or string type",
+			inType:     ratioType,
+			inSpec:     &enumSpec{},
+			wantErrStr: "enum Ratio must be of an integer or string type",
+		},
+		{
+			name:       "no constants",
+			inType:     noConstType,
+			inSpec:     &enumSpec{nums: map[string]int{}},
+			wantErrStr: "no constants of the enum Name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseEnum(tt.inType, tt.inSpec)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+		})
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	str := types.Typ[types.String]
+	strs := types.NewSlice(str)
+
+	tests := []struct {
+		name            string
+		inDoc           string
+		inPrev          *Numbers // The numbers generated before, if any.
+		wantMetadata    map[string]string
+		wantRequestNums map[string]int
+		wantReserved    []int
+		wantErrStr      string
+	}{
+		{
+			name:            "explicit key",
+			inDoc:           "//kun:grpc metadata=tenantID:X-Tenant-ID",
+			wantMetadata:    map[string]string{"tenantID": "x-tenant-id"},
+			wantRequestNums: map[string]int{"id": 1, "tags": 2, "count": 3},
+		},
+		{
+			name:            "default key",
+			inDoc:           "//kun:grpc metadata=tenantID,tags",
+			wantMetadata:    map[string]string{"tenantID": "tenant-id", "tags": "tags"},
+			wantRequestNums: map[string]int{"id": 1, "count": 2},
+		},
+		{
+			name:  "bound on regeneration",
+			inDoc: "//kun:grpc metadata=tenantID,tags",
+			inPrev: &Numbers{Blocks: map[string]*BlockNumbers{
+				"GetRequest": {Fields: map[string]int{"tenant_id": 1, "id": 2, "tags": 3, "count": 4}},
+			}},
+			wantMetadata:    map[string]string{"tenantID": "tenant-id", "tags": "tags"},
+			wantRequestNums: map[string]int{"id": 2, "count": 4},
+			wantReserved:    []int{1, 3},
+		},
+		{
+			name:       "no param",
+			inDoc:      "//kun:grpc metadata=userID",
+			wantErrStr: "no param `userID` declared in the method Get",
+		},
+		{
+			name:       "unsupported type",
+			inDoc:      "//kun:grpc metadata=count",
+			wantErrStr: "param `count` in the method Get must be of type string or []string to be bound to metadata",
+		},
+		{
+			name:       "binary key",
+			inDoc:      "//kun:grpc metadata=tenantID:tenant-bin",
+			wantErrStr: "invalid metadata key \"tenant-bin\" for param `tenantID` in the method Get",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(&ifacetool.Data{
+				InterfaceName: "Service",
+				Methods: []*ifacetool.Method{{
+					Name: "Get",
+					Doc:  []string{tt.inDoc},
+					Params: []*ifacetool.Param{
+						ctxParam,
+						newParam("tenantID", "string", str),
+						newParam("id", "string", str),
+						newParam("tags", "[]string", strs),
+						newParam("count", "int", types.Typ[types.Int]),
+					},
+					Returns: []*ifacetool.Param{errParam},
+				}},
+			})
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if err != nil {
+				return
+			}
+
+			s.Renumber(tt.inPrev)
+
+			rpc := s.RPCs[0]
+			gotMetadata := make(map[string]string)
+			for _, md := range rpc.Metadata {
+				gotMetadata[md.Param.Name] = md.Key
+			}
+			if !reflect.DeepEqual(gotMetadata, tt.wantMetadata) {
+				t.Fatalf("Metadata: got (%v), want (%v)", gotMetadata, tt.wantMetadata)
+			}
+
+			gotNums := make(map[string]int)
+			for _, f := range rpc.Request.Fields {
+				gotNums[f.Name] = f.Num
+			}
+			if !reflect.DeepEqual(gotNums, tt.wantRequestNums) {
+				t.Fatalf("Request.Fields: got (%v), want (%v)", gotNums, tt.wantRequestNums)
+			}
+			if !reflect.DeepEqual(rpc.Request.Reserved, tt.wantReserved) {
+				t.Fatalf("Request.Reserved: got (%v), want (%v)", rpc.Request.Reserved, tt.wantReserved)
+			}
+		})
+	}
+}
+
+func TestParseServiceOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		inDoc      []string
+		wantOpts   ServiceOptions
+		wantName   string
+		wantErrStr string
+	}{
+		{
+			name:     "no options",
+			inDoc:    []string{"// Service is a service."},
+			wantOpts: ServiceOptions{},
+		},
+		{
+			name: "all options",
+			inDoc: []string{
+				"// Service is a service.",
+				"//kun:grpc package=acme.user.v1 go_package=github.com/acme/schemas/gen/go/acme/user/v1;userv1",
+				"//kun:grpc out=proto/acme/user/v1 file=user_service.proto",
+			},
+			wantOpts: ServiceOptions{
+				Package:   "acme.user.v1",
+				GoPackage: "github.com/acme/schemas/gen/go/acme/user/v1;userv1",
+				Out:       "proto/acme/user/v1",
+				File:      "user_service.proto",
+			},
+			wantName: "userv1",
+		},
+		{
+			name:     "go package name derived from import path",
+			inDoc:    []string{"//kun:grpc go_package=github.com/acme/user-api"},
+			wantOpts: ServiceOptions{GoPackage: "github.com/acme/user-api"},
+			wantName: "user_api",
+		},
+		{
+			name:       "invalid package",
+			inDoc:      []string{"//kun:grpc package=acme..v1"},
+			wantErrStr: `invalid proto package "acme..v1"`,
+		},
+		{
+			name:       "invalid file",
+			inDoc:      []string{"//kun:grpc file=user/service.proto"},
+			wantErrStr: `invalid proto file name "user/service.proto"`,
+		},
+		{
+			name:       "absolute output directory",
+			inDoc:      []string{"//kun:grpc out=/etc/proto"},
+			wantErrStr: `invalid output directory "/etc/proto": must be a relative path without ".."`,
+		},
+		{
+			name:       "output directory out of the tree",
+			inDoc:      []string{"//kun:grpc out=proto/../../proto"},
+			wantErrStr: `invalid output directory "proto/../../proto": must be a relative path without ".."`,
+		},
+		{
+			name:       "unrecognized key",
+			inDoc:      []string{"//kun:grpc request=req"},
+			wantErrStr: `unrecognized kun key "request" in comment: //kun:grpc request=req`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, _, err := parseServiceOptions(tt.inDoc)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if err != nil {
+				return
+			}
+			if opts != tt.wantOpts {
+				t.Fatalf("Options: got (%#v), want (%#v)", opts, tt.wantOpts)
+			}
+			if tt.wantName != "" && opts.GoPackageName() != tt.wantName {
+				t.Fatalf("GoPackageName: got (%s), want (%s)", opts.GoPackageName(), tt.wantName)
+			}
+		})
+	}
+}
+
+func TestParseEnumSpec(t *testing.T) {
+	tests := []struct {
+		name       string
+		inValue    string
+		wantName   string
+		wantNums   map[string]int
+		wantErrStr string
+	}{
+		{
+			name:     "integer enum",
+			inValue:  "Level",
+			wantName: "Level",
+		},
+		{
+			name:     "string enum",
+			inValue:  "Kind:KindGauge=1,KindCounter=2",
+			wantName: "Kind",
+			wantNums: map[string]int{"KindGauge": 1, "KindCounter": 2},
+		},
+		{
+			name:       "invalid type",
+			inValue:    "pkg.Kind",
+			wantErrStr: `invalid enum type "pkg.Kind"`,
+		},
+		{
+			name:       "invalid format",
+			inValue:    "Kind:KindGauge",
+			wantErrStr: `"KindGauge" of the enum Kind does not match the expected format: <const>=<num>`,
+		},
+		{
+			name:       "zero number",
+			inValue:    "Kind:KindGauge=0",
+			wantErrStr: `invalid number "0" of the constant KindGauge of the enum Kind: must be a positive int32`,
+		},
+		{
+			name:       "number out of int32 range",
+			inValue:    "Kind:KindGauge=2147483648",
+			wantErrStr: `invalid number "2147483648" of the constant KindGauge of the enum Kind: must be a positive int32`,
+		},
+		{
+			name:       "duplicate constant",
+			inValue:    "Kind:KindGauge=1,KindGauge=2",
+			wantErrStr: "duplicate constant KindGauge of the enum Kind",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, spec, err := parseEnumSpec(tt.inValue)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if err != nil {
+				return
+			}
+			if name != tt.wantName {
+				t.Fatalf("Name: got (%s), want (%s)", name, tt.wantName)
+			}
+			if !reflect.DeepEqual(spec.nums, tt.wantNums) {
+				t.Fatalf("Nums: got (%v), want (%v)", spec.nums, tt.wantNums)
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package proto
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/RussellLuo/kun/gen/grpc/parser"
 	"github.com/RussellLuo/kun/gen/util/annotation"
@@ -18,6 +21,11 @@ syntax = "proto3";
 option go_package = "{{.PkgPath}}";
 
 package {{.PkgName}};
+{{- if .Imports}}
+{{range .Imports}}
+import "{{.}}";
+{{- end}}
+{{- end}}
 
 {{range .Service.Descriptions -}}
 {{.}}
@@ -27,7 +35,13 @@ service {{.Service.Name}} {
   {{- range .Descriptions}}
   {{.}}
   {{- end}} {{/* range .Description */}}
-  rpc {{.Name}} ({{.Request.Name}}) returns ({{.Response.Name}}) {}
+  rpc {{.Name}} ({{if .ClientStream}}stream {{end}}{{.Request.Name}}) returns ({{if .ServerStream}}stream {{end}}{{.Response.Name}}) {
+  {{- if and $.HTTPRules .HTTPRules}}
+    option (google.api.http) = {
+{{httpRule .HTTPRules "      "}}
+    };
+  {{end -}}
+  }
   {{- end}} {{/* range .Service.RPCs */}}
 }
 
@@ -35,6 +49,9 @@ service {{.Service.Name}} {
 
 // The request message of {{.Name}}.
 message {{.Request.Name}} {
+  {{- with .Request.Reserved}}
+  reserved {{joinInts .}};
+  {{- end}}
   {{- range .Request.Fields}}
   {{fullTypeName .Type}} {{snakeCase .Name}} = {{.Num}};
   {{- end}} {{/* range .Request.Fields */}}
@@ -42,6 +59,9 @@ message {{.Request.Name}} {
 
 // The response message of {{.Name}}.
 message {{.Response.Name}} {
+  {{- with .Response.Reserved}}
+  reserved {{joinInts .}};
+  {{- end}}
   {{- range .Response.Fields}}
   {{fullTypeName .Type}} {{snakeCase .Name}} = {{.Num}};
   {{- end}} {{/* range .Response.Fields */}}
@@ -52,6 +72,9 @@ message {{.Response.Name}} {
 
 {{if .Fields -}}
 message {{.Name}} {
+  {{- with .Reserved}}
+  reserved {{joinInts .}};
+  {{- end}}
   {{- range .Fields}}
   {{fullTypeName .Type}} {{snakeCase .Name}} = {{.Num}};
   {{- end}} {{/* range .Fields */}}
@@ -59,6 +82,18 @@ message {{.Name}} {
 {{- end}}{{/* if .Fields */}}
 
 {{- end}} {{/* range .Messages */}}
+
+{{- range .Service.Enums}}
+
+enum {{.Name}} {
+  {{- with .Reserved}}
+  reserved {{joinInts .}};
+  {{- end}}
+  {{- range .Values}}
+  {{.Name}} = {{.Num}};
+  {{- end}} {{/* range .Values */}}
+}
+{{- end}}{{/* range .Service.Enums */}}
 `
 )
 
@@ -66,6 +101,8 @@ type Options struct {
 	SchemaPtr bool
 	SchemaTag string
 	Formatted bool
+	// HTTPRules indicates whether to add google.api.http options to RPCs.
+	HTTPRules bool
 }
 
 type Generator struct {
@@ -77,21 +114,39 @@ func New(opts *Options) *Generator {
 }
 
 func (g *Generator) Generate(outDir string, ifaceData *ifacetool.Data, service *parser.Service) (*generator.File, error) {
+	imports := service.Imports()
+	if g.opts.HTTPRules && hasHTTPRules(service) {
+		imports = append(imports, "google/api/annotations.proto")
+		sort.Strings(imports)
+	}
+
 	data := struct {
-		PkgPath  string
-		PkgName  string
-		Service  *parser.Service
-		Messages map[string]*parser.Type
+		PkgPath   string
+		PkgName   string
+		Imports   []string
+		Service   *parser.Service
+		Messages  map[string]*parser.Type
+		HTTPRules bool
 	}{
-		PkgPath:  pkgtool.PkgPathFromDir(outDir),
-		PkgName:  pkgtool.PkgNameFromDir(outDir),
-		Service:  service,
-		Messages: getMessages(service),
+		PkgPath:   GoPackage(outDir, service),
+		PkgName:   Package(outDir, service),
+		Imports:   imports,
+		Service:   service,
+		Messages:  getMessages(service),
+		HTTPRules: g.opts.HTTPRules,
 	}
 
 	return generator.Generate(template, data, generator.Options{
 		Funcs: map[string]interface{}{
 			"snakeCase": caseconv.ToSnakeCase,
+			"joinInts": func(nums []int) string {
+				var s []string
+				for _, n := range nums {
+					s = append(s, strconv.Itoa(n))
+				}
+				return strings.Join(s, ", ")
+			},
+			"httpRule": formatHTTPRules,
 			"fullTypeName": func(typ *parser.Type) string {
 				name := typ.Name
 
@@ -103,13 +158,84 @@ func (g *Generator) Generate(outDir string, ifaceData *ifacetool.Data, service *
 					name = "repeated " + name
 				}
 
+				if typ.Optional {
+					name = "optional " + name
+				}
+
 				return name
 			},
 		},
-		TargetFileName: ifaceData.SrcPkgName + ".proto",
+		TargetFileName: FileName(ifaceData, service),
 	})
 }
 
+// Package returns the proto package of the .proto file, which defaults to
+// the name of its output directory.
+func Package(outDir string, s *parser.Service) string {
+	if s.Options.Package != "" {
+		return s.Options.Package
+	}
+	return pkgtool.PkgNameFromDir(outDir)
+}
+
+// GoPackage returns the go_package option of the .proto file, which
+// defaults to the import path of its output directory.
+func GoPackage(outDir string, s *parser.Service) string {
+	if s.Options.GoPackage != "" {
+		return s.Options.GoPackage
+	}
+	return pkgtool.PkgPathFromDir(outDir)
+}
+
+// FileName returns the name of the .proto file, which defaults to the name
+// of the source package.
+func FileName(ifaceData *ifacetool.Data, s *parser.Service) string {
+	if s.Options.File != "" {
+		return s.Options.File
+	}
+	return ifaceData.SrcPkgName + ".proto"
+}
+
+func hasHTTPRules(s *parser.Service) bool {
+	for _, rpc := range s.RPCs {
+		if len(rpc.HTTPRules) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// formatHTTPRules formats the fields of a google.api.HttpRule, where the
+// first rule is the primary one and the others are additional bindings.
+func formatHTTPRules(rules []*parser.HTTPRule, indent string) string {
+	var lines []string
+
+	addRule := func(rule *parser.HTTPRule, indent string) {
+		if rule.IsCustom() {
+			lines = append(lines,
+				indent+"custom {",
+				fmt.Sprintf("%s  kind: %q", indent, strings.ToUpper(rule.Method)),
+				fmt.Sprintf("%s  path: %q", indent, rule.Pattern),
+				indent+"}",
+			)
+		} else {
+			lines = append(lines, fmt.Sprintf("%s%s: %q", indent, rule.Method, rule.Pattern))
+		}
+		if rule.Body != "" {
+			lines = append(lines, fmt.Sprintf("%sbody: %q", indent, rule.Body))
+		}
+	}
+
+	addRule(rules[0], indent)
+	for _, rule := range rules[1:] {
+		lines = append(lines, indent+"additional_bindings {")
+		addRule(rule, indent+"  ")
+		lines = append(lines, indent+"}")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 func getMessages(s *parser.Service) map[string]*parser.Type {
 	// TODO: Import another .proto's definitions, if necessary, for best reusability.
 
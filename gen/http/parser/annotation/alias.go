@@ -23,7 +23,8 @@ func ParseAliases(doc []string) (Aliases, error) {
 	a := make(map[string]string)
 
 	for _, comment := range doc {
-		if !annotation.Directive(comment).IsValid() {
+		if annotation.Directive(comment).Dialect() != annotation.DialectHTTP {
+			// Ignore non-HTTP directives, e.g. the interface-level //kun:grpc.
 			continue
 		}
 
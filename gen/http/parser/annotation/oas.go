@@ -22,7 +22,8 @@ func ParseMetadata(doc []string) (*spec.Metadata, error) {
 	}
 
 	for _, comment := range doc {
-		if !annotation.Directive(comment).IsValid() {
+		if annotation.Directive(comment).Dialect() != annotation.DialectHTTP {
+			// Ignore non-HTTP directives, e.g. the interface-level //kun:grpc.
 			continue
 		}
 
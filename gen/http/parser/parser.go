@@ -44,6 +44,7 @@ func Parse(data *ifacetool.Data, snakeCase bool) (*spec.Specification, docutil.T
 
 	for _, m := range data.Methods {
 		doc := docutil.Doc(m.Doc).JoinComments()
+		m.DocPos = docutil.Doc(m.Doc).JoinPositions(m.DocPos)
 		m.Doc = doc // Replace the original doc with joined doc.
 
 		t := doc.Transport()
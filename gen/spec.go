@@ -0,0 +1,78 @@
+package gen
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	cronparser "github.com/RussellLuo/kun/gen/cron/parser"
+	httpparser "github.com/RussellLuo/kun/gen/http/parser"
+	"github.com/RussellLuo/kun/gen/util/docutil"
+	"github.com/RussellLuo/kun/pkg/cronjob"
+)
+
+// Spec writes the human-readable specification of the interface into w, for
+// reviewers to sanity-check the annotations.
+//
+// Currently, the specification consists of the cron jobs, along with the
+// next n fire times of each job after now. The cron expressions without
+// timezones are interpreted in UTC, which is the default timezone of
+// cronjob.Scheduler.
+func (g *Generator) Spec(w io.Writer, srcFilename, interfaceName string, now time.Time, n int) error {
+	data, err := g.parseInterface(srcFilename, interfaceName)
+	if err != nil {
+		return err
+	}
+
+	// Parse the HTTP annotations first to join backslash-continued comments,
+	// just as Generate does.
+	_, transport, err := httpparser.Parse(data, g.opts.SnakeCase)
+	if err != nil {
+		return err
+	}
+
+	if !transport.Has(docutil.TransportCron) {
+		_, err := fmt.Fprintln(w, "No cron jobs.")
+		return err
+	}
+
+	cronSpec, err := cronparser.Parse(data, g.opts.SnakeCase)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("Cron jobs:\n")
+	for _, m := range data.Methods {
+		for _, job := range cronSpec[m.Name] {
+			tz := job.Timezone
+			if tz == "" {
+				tz = "UTC"
+			}
+			schedule, err := cronjob.Parse(cronjob.TimezonePrefix + tz + " " + job.Expr)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(&b, "\n%s (method %s)\n", job.Name, m.Name)
+			fmt.Fprintf(&b, "  expr:     %s\n", job.Expr)
+			fmt.Fprintf(&b, "  timezone: %s\n", tz)
+
+			next := now
+			for i := 0; i < n; i++ {
+				if next = schedule.Next(next); next.IsZero() {
+					break
+				}
+				label := "next:"
+				if i > 0 {
+					label = ""
+				}
+				fmt.Fprintf(&b, "  %-9s %s\n", label, next.Format(time.RFC3339))
+			}
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
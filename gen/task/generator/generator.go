@@ -0,0 +1,164 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/RussellLuo/kun/gen/task/parser"
+	utilannotation "github.com/RussellLuo/kun/gen/util/annotation"
+	"github.com/RussellLuo/kun/gen/util/generator"
+	"github.com/RussellLuo/kun/gen/util/openapi"
+	"github.com/RussellLuo/kun/pkg/caseconv"
+	"github.com/RussellLuo/kun/pkg/ifacetool"
+)
+
+var (
+	template = utilannotation.FileHeader + `
+{{- $srcPkgName := .Data.SrcPkgName}}
+{{- $endpointPkgPrefix := .PkgInfo.EndpointPkgPrefix}}
+
+package {{.PkgInfo.CurrentPkgName}}
+
+import (
+	"context"
+	"encoding/json"
+
+	{{- range .Data.Imports}}
+	{{.ImportString}}
+	{{- end}}
+	"github.com/RussellLuo/kun/pkg/taskqueue"
+	"github.com/RussellLuo/kun/pkg/werror"
+	"github.com/RussellLuo/kun/pkg/werror/gcode"
+
+	{{- if .PkgInfo.EndpointPkgPath}}
+	"{{.PkgInfo.EndpointPkgPath}}"
+	{{- end}}
+)
+
+// NewTaskHandler creates a handler, which executes the tasks by calling the
+// corresponding methods of svc.
+func NewTaskHandler(svc {{$.Data.SrcPkgQualifier}}{{$.Data.InterfaceName}}) taskqueue.Handler {
+	handlerSet := taskqueue.NewHandlerSet()
+
+	{{- range .Spec.Operations}}
+	{{- $op := .}}
+	{{- $nonCtxParams := nonCtxParams .Request.Params}}
+	{{- with getTask .GoMethodName}}
+	handlerSet.Add("{{.Name}}", taskqueue.HandlerFunc(func(ctx context.Context, task *taskqueue.Task) error {
+		{{- if $nonCtxParams}}
+		var req {{$endpointPkgPrefix}}{{$op.GoMethodName}}Request
+		if err := json.Unmarshal(task.Payload, &req); err != nil {
+			return werror.Wrap(gcode.ErrInvalidArgument, err)
+		}
+		return svc.{{$op.GoMethodName}}(ctx{{range $nonCtxParams}}, req.{{title .Name}}{{end}})
+		{{- else}}
+		return svc.{{$op.GoMethodName}}(ctx)
+		{{- end}}
+	}))
+	{{- end}} {{- /* with getTask .GoMethodName */}}
+	{{- end}} {{- /* range .Spec.Operations */}}
+
+	return handlerSet
+}
+
+// TaskClient implements {{$.Data.SrcPkgQualifier}}{{$.Data.InterfaceName}} by enqueuing tasks, which will be
+// executed by the workers asynchronously. The time to execute a task can be
+// specified by the context (see taskqueue.WithDelay and taskqueue.WithRunAt).
+type TaskClient struct {
+	queue taskqueue.Queue
+}
+
+func NewTaskClient(queue taskqueue.Queue) *TaskClient {
+	return &TaskClient{queue: queue}
+}
+
+{{- range .Spec.Operations}}
+{{- $op := .}}
+{{- $nonCtxParams := nonCtxParams .Request.Params}}
+{{- $method := getMethod .GoMethodName}}
+{{- with getTask .GoMethodName}}
+
+func (c *TaskClient) {{$method.Name}}({{$method.ArgList}}) {{$method.ReturnArgNamedValueList}} {
+	{{- if $nonCtxParams}}
+	payload, err := json.Marshal(&{{$endpointPkgPrefix}}{{$op.GoMethodName}}Request{
+		{{- range $nonCtxParams}}
+		{{title .Name}}: {{.Name}},
+		{{- end}}
+	})
+	if err != nil {
+		return err
+	}
+	return taskqueue.Enqueue({{getCtxArg $op.GoMethodName}}, c.queue, "{{.Queue}}", "{{.Name}}", payload)
+	{{- else}}
+	return taskqueue.Enqueue({{getCtxArg $op.GoMethodName}}, c.queue, "{{.Queue}}", "{{.Name}}", []byte("{}"))
+	{{- end}}
+}
+
+{{- end}} {{- /* with getTask .GoMethodName */}}
+{{- end}} {{- /* range .Spec.Operations */}}
+`
+)
+
+type Options struct {
+	SchemaPtr bool
+	SchemaTag string
+	Formatted bool
+}
+
+type Generator struct {
+	opts *Options
+}
+
+func New(opts *Options) *Generator {
+	return &Generator{opts: opts}
+}
+
+func (g *Generator) Generate(pkgInfo *generator.PkgInfo, ifaceData *ifacetool.Data, tasks map[string]*parser.Task, spec *openapi.Specification) (*generator.File, error) {
+	data := struct {
+		PkgInfo *generator.PkgInfo
+		Data    *ifacetool.Data
+		Spec    *openapi.Specification
+	}{
+		PkgInfo: pkgInfo,
+		Data:    ifaceData,
+		Spec:    spec,
+	}
+
+	methodMap := make(map[string]*ifacetool.Method)
+	for _, method := range ifaceData.Methods {
+		methodMap[method.Name] = method
+	}
+
+	return generator.Generate(template, data, generator.Options{
+		Funcs: map[string]interface{}{
+			"title": caseconv.UpperFirst,
+			"getTask": func(methodName string) *parser.Task {
+				return tasks[methodName]
+			},
+			"nonCtxParams": func(params []*openapi.Param) (out []*openapi.Param) {
+				for _, p := range params {
+					if p.Type != "context.Context" {
+						out = append(out, p)
+					}
+				}
+				return
+			},
+			"getMethod": func(methodName string) *ifacetool.Method {
+				method, ok := methodMap[methodName]
+				if !ok {
+					panic(fmt.Errorf("no method named %q", methodName))
+				}
+				return method
+			},
+			"getCtxArg": func(methodName string) string {
+				for _, p := range methodMap[methodName].Params {
+					if p.TypeString == "context.Context" {
+						return p.Name
+					}
+				}
+				return "context.Background()"
+			},
+		},
+		Formatted:      g.opts.Formatted,
+		TargetFileName: "task.go",
+	})
+}
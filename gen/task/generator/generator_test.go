@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/RussellLuo/kun/gen/task/parser"
+	"github.com/RussellLuo/kun/gen/util/generator"
+	"github.com/RussellLuo/kun/gen/util/openapi"
+	"github.com/RussellLuo/kun/pkg/ifacetool"
+)
+
+func TestGenerator_Generate(t *testing.T) {
+	ctxType := types.NewNamed(
+		types.NewTypeName(0, types.NewPackage("context", "context"), "Context", nil),
+		types.NewInterfaceType(nil, nil).Complete(),
+		nil,
+	)
+	ctx := &ifacetool.Param{Name: "ctx", TypeString: "context.Context", Type: ctxType}
+	to := &ifacetool.Param{Name: "to", TypeString: "string", Type: types.Typ[types.String]}
+	err := &ifacetool.Param{Name: "err", TypeString: "error", Type: types.Universe.Lookup("error").Type()}
+
+	spec := &openapi.Specification{
+		Operations: []*openapi.Operation{
+			{
+				Name:         "CleanUp",
+				GoMethodName: "CleanUp",
+				Request: openapi.Request{
+					Params: []*openapi.Param{{Name: "ctx", Type: "context.Context"}},
+				},
+			},
+			{
+				Name:         "SendEmail",
+				GoMethodName: "SendEmail",
+				Request: openapi.Request{
+					Params: []*openapi.Param{
+						{Name: "ctx", Type: "context.Context"},
+						{Name: "to", Type: "string"},
+					},
+				},
+			},
+		},
+	}
+	data := &ifacetool.Data{
+		InterfaceName: "Service",
+		Imports:       []*ifacetool.Import{{Path: "context"}},
+		Methods: []*ifacetool.Method{
+			{Name: "CleanUp", Params: []*ifacetool.Param{ctx}, Returns: []*ifacetool.Param{err}},
+			{Name: "SendEmail", Params: []*ifacetool.Param{ctx, to}, Returns: []*ifacetool.Param{err}},
+		},
+	}
+	tasks := map[string]*parser.Task{
+		"CleanUp":   {Name: "cleanup", Queue: "maintenance"},
+		"SendEmail": {Name: "send_email", Queue: "emails"},
+	}
+
+	g := New(&Options{Formatted: true})
+	file, e := g.Generate(&generator.PkgInfo{CurrentPkgName: "tasksvc"}, data, tasks, spec)
+	if e != nil {
+		t.Fatalf("err: %v", e)
+	}
+
+	want := `// Code generated by kun; DO NOT EDIT.
+// github.com/RussellLuo/kun
+
+package tasksvc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/RussellLuo/kun/pkg/taskqueue"
+	"github.com/RussellLuo/kun/pkg/werror"
+	"github.com/RussellLuo/kun/pkg/werror/gcode"
+)
+
+// NewTaskHandler creates a handler, which executes the tasks by calling the
+// corresponding methods of svc.
+func NewTaskHandler(svc Service) taskqueue.Handler {
+	handlerSet := taskqueue.NewHandlerSet()
+	handlerSet.Add("cleanup", taskqueue.HandlerFunc(func(ctx context.Context, task *taskqueue.Task) error {
+		return svc.CleanUp(ctx)
+	}))
+	handlerSet.Add("send_email", taskqueue.HandlerFunc(func(ctx context.Context, task *taskqueue.Task) error {
+		var req SendEmailRequest
+		if err := json.Unmarshal(task.Payload, &req); err != nil {
+			return werror.Wrap(gcode.ErrInvalidArgument, err)
+		}
+		return svc.SendEmail(ctx, req.To)
+	}))
+
+	return handlerSet
+}
+
+// TaskClient implements Service by enqueuing tasks, which will be
+// executed by the workers asynchronously. The time to execute a task can be
+// specified by the context (see taskqueue.WithDelay and taskqueue.WithRunAt).
+type TaskClient struct {
+	queue taskqueue.Queue
+}
+
+func NewTaskClient(queue taskqueue.Queue) *TaskClient {
+	return &TaskClient{queue: queue}
+}
+
+func (c *TaskClient) CleanUp(ctx context.Context) (err error) {
+	return taskqueue.Enqueue(ctx, c.queue, "maintenance", "cleanup", []byte("{}"))
+}
+
+func (c *TaskClient) SendEmail(ctx context.Context, to string) (err error) {
+	payload, err := json.Marshal(&SendEmailRequest{
+		To: to,
+	})
+	if err != nil {
+		return err
+	}
+	return taskqueue.Enqueue(ctx, c.queue, "emails", "send_email", payload)
+}
+`
+	if got := string(file.Content); got != want {
+		t.Fatalf("Content: got (%s), want (%s)", got, want)
+	}
+}
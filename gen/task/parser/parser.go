@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/RussellLuo/kun/gen/util/annotation"
+	"github.com/RussellLuo/kun/gen/util/parser"
+	"github.com/RussellLuo/kun/pkg/caseconv"
+	"github.com/RussellLuo/kun/pkg/ifacetool"
+)
+
+var (
+	reTask = regexp.MustCompile(`^` + annotation.DirectiveTask.String() + `(.*)$`)
+)
+
+type Task struct {
+	// Name is the name of the task, by which the task is dispatched to the
+	// method on the worker side.
+	Name string
+
+	// Queue is the name of the queue, into which the task is enqueued.
+	Queue string
+}
+
+// Parse parses the task of each method, which must be annotated by
+// //kun:task directive since the generated TaskClient implements the whole
+// interface.
+//
+// The errors of the directives are prefixed by their source positions, if
+// known.
+func Parse(data *ifacetool.Data, snakeCase bool) (map[string]*Task, error) {
+	tasks := make(map[string]*Task)
+	names := make(map[string]string) // task name -> method name
+
+	for _, m := range data.Methods {
+		for i, comment := range m.Doc {
+			if annotation.Directive(comment).Dialect() != annotation.DialectTask {
+				continue
+			}
+
+			if !parser.ValidateSignature(m) {
+				return nil, fmt.Errorf("the signature of method %s must be `func(context.Context, ...) error` when annotated by %s directive", m.Name, annotation.DirectiveTask)
+			}
+			if _, ok := tasks[m.Name]; ok {
+				return nil, parser.WithPos(m, i, fmt.Errorf("duplicate %s directive of the method %s", annotation.DirectiveTask, m.Name))
+			}
+
+			task, err := parseTask(m, comment, snakeCase)
+			if err != nil {
+				return nil, parser.WithPos(m, i, err)
+			}
+
+			if method, ok := names[task.Name]; ok {
+				return nil, parser.WithPos(m, i, fmt.Errorf("duplicate task name %q of the methods %s and %s, which must be specified by key \"name\"", task.Name, method, m.Name))
+			}
+			names[task.Name] = m.Name
+
+			tasks[m.Name] = task
+		}
+
+		if _, ok := tasks[m.Name]; !ok {
+			return nil, fmt.Errorf("the method %s must be annotated by %s directive, since the task client implements the whole interface", m.Name, annotation.DirectiveTask)
+		}
+	}
+
+	return tasks, nil
+}
+
+func parseTask(m *ifacetool.Method, comment string, snakeCase bool) (*Task, error) {
+	result := reTask.FindStringSubmatch(comment)
+	if len(result) != 2 {
+		return nil, fmt.Errorf("invalid %s directive: %s", annotation.DirectiveTask, comment)
+	}
+
+	pairs, err := parser.ParseOptionPairs(result[1])
+	if err != nil {
+		return nil, err
+	}
+
+	task := new(Task)
+
+	for _, pair := range pairs {
+		switch pair.Key {
+		case "name":
+			task.Name = pair.Value
+		case "queue":
+			task.Queue = pair.Value
+		default:
+			return nil, fmt.Errorf(`unrecognized %s key "%s" in comment: %s`, annotation.DirectiveTask, pair.Key, comment)
+		}
+	}
+
+	if task.Name == "" {
+		task.Name = caseconv.ToLowerCamelCase(m.Name)
+		if snakeCase {
+			task.Name = caseconv.ToSnakeCase(m.Name)
+		}
+	}
+
+	if task.Queue == "" {
+		return nil, fmt.Errorf(`missing key "queue" for %s directive in comment: %s`, annotation.DirectiveTask, comment)
+	}
+
+	return task, nil
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+
+	"github.com/RussellLuo/kun/pkg/ifacetool"
+)
+
+func TestParse(t *testing.T) {
+	ctxType := types.NewNamed(
+		types.NewTypeName(0, types.NewPackage("context", "context"), "Context", nil),
+		types.NewInterfaceType(nil, nil).Complete(),
+		nil,
+	)
+	ctxParam := &ifacetool.Param{Name: "ctx", TypeString: "context.Context", Type: ctxType}
+	errReturn := &ifacetool.Param{TypeString: "error", Type: types.Universe.Lookup("error").Type()}
+	to := &ifacetool.Param{Name: "to", TypeString: "string", Type: types.Typ[types.String]}
+	newMethod := func(name string, doc ...string) *ifacetool.Method {
+		return &ifacetool.Method{
+			Name:    name,
+			Doc:     doc,
+			Params:  []*ifacetool.Param{ctxParam, to},
+			Returns: []*ifacetool.Param{errReturn},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		inMethods  []*ifacetool.Method
+		wantTasks  map[string]*Task
+		wantErrStr string
+	}{
+		{
+			name: "ok",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", "// SendEmail sends an email.", "//kun:task queue=emails"),
+				newMethod("SendSMS", "//kun:task name=sms queue=messages"),
+			},
+			wantTasks: map[string]*Task{
+				"SendEmail": {Name: "send_email", Queue: "emails"},
+				"SendSMS":   {Name: "sms", Queue: "messages"},
+			},
+		},
+		{
+			name: "unannotated method",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", "//kun:task queue=emails"),
+				newMethod("Ping", "// Ping checks the health."),
+			},
+			wantErrStr: `the method Ping must be annotated by //kun:task directive, since the task client implements the whole interface`,
+		},
+		{
+			name: "missing queue",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", "//kun:task name=email"),
+			},
+			wantErrStr: `missing key "queue" for //kun:task directive in comment: //kun:task name=email`,
+		},
+		{
+			name: "unrecognized key",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", "//kun:task queue=emails delay=1m"),
+			},
+			wantErrStr: `unrecognized //kun:task key "delay" in comment: //kun:task queue=emails delay=1m`,
+		},
+		{
+			name: "duplicate directives",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", "//kun:task queue=emails", "//kun:task queue=others"),
+			},
+			wantErrStr: `duplicate //kun:task directive of the method SendEmail`,
+		},
+		{
+			name: "duplicate task names",
+			inMethods: []*ifacetool.Method{
+				newMethod("SendEmail", "//kun:task name=send queue=emails"),
+				newMethod("SendSMS", "//kun:task name=send queue=messages"),
+			},
+			wantErrStr: `duplicate task name "send" of the methods SendEmail and SendSMS, which must be specified by key "name"`,
+		},
+		{
+			name: "invalid signature",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:    "SendEmail",
+					Doc:     []string{"//kun:task queue=emails"},
+					Params:  []*ifacetool.Param{to},
+					Returns: []*ifacetool.Param{errReturn},
+				},
+			},
+			wantErrStr: "the signature of method SendEmail must be `func(context.Context, ...) error` when annotated by //kun:task directive",
+		},
+		{
+			name: "error with position",
+			inMethods: []*ifacetool.Method{
+				{
+					Name:    "SendEmail",
+					Doc:     []string{"//kun:task"},
+					DocPos:  []token.Position{{Filename: "service.go", Line: 11, Column: 2}},
+					Params:  []*ifacetool.Param{ctxParam},
+					Returns: []*ifacetool.Param{errReturn},
+				},
+			},
+			wantErrStr: `service.go:11:2: missing key "queue" for //kun:task directive in comment: //kun:task`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := Parse(&ifacetool.Data{Methods: tt.inMethods}, true)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(tasks, tt.wantTasks) {
+				t.Fatalf("Tasks: got (%#v), want (%#v)", tasks, tt.wantTasks)
+			}
+		})
+	}
+}
@@ -27,6 +27,8 @@ var (
 	DirectiveEvent = FromSubDirective("event")
 
 	DirectiveCron = FromSubDirective("cron")
+
+	DirectiveTask = FromSubDirective("task")
 )
 
 type Directive string
@@ -51,6 +53,8 @@ func (d Directive) Dialect() Dialect {
 		return DialectEvent
 	case DirectiveCron.SubDirective():
 		return DialectCron
+	case DirectiveTask.SubDirective():
+		return DialectTask
 	default:
 		return DialectHTTP
 	}
@@ -81,4 +85,5 @@ const (
 	DialectGRPC    Dialect = "grpc"
 	DialectEvent   Dialect = "event"
 	DialectCron    Dialect = "cron"
+	DialectTask    Dialect = "task"
 )
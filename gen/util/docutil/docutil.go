@@ -1,6 +1,7 @@
 package docutil
 
 import (
+	"go/token"
 	"strings"
 
 	"github.com/RussellLuo/kun/gen/util/annotation"
@@ -17,6 +18,7 @@ const (
 	TransportGRPC  Transport = 0b0010
 	TransportEvent Transport = 0b0100
 	TransportCron  Transport = 0b1000
+	TransportTask  Transport = 0b10000
 )
 
 type Doc []string
@@ -32,6 +34,8 @@ func (d Doc) Transport() (t Transport) {
 			t = t | TransportEvent
 		case annotation.DialectCron:
 			t = t | TransportCron
+		case annotation.DialectTask:
+			t = t | TransportTask
 		}
 	}
 	return t
@@ -39,14 +43,41 @@ func (d Doc) Transport() (t Transport) {
 
 // JoinComments joins backslash-continued comments.
 func (d Doc) JoinComments() (joined Doc) {
+	joined, _ = d.join()
+	return
+}
+
+// JoinPositions returns the positions of the comments joined by JoinComments,
+// given the positions pos of the original comments. The position of a joined
+// comment is the one of its first line. It returns nil if pos does not match
+// the original comments.
+func (d Doc) JoinPositions(pos []token.Position) []token.Position {
+	if len(pos) != len(d) {
+		return nil
+	}
+
+	_, starts := d.join()
+	joined := make([]token.Position, len(starts))
+	for i, start := range starts {
+		joined[i] = pos[start]
+	}
+	return joined
+}
+
+// join joins backslash-continued comments, and returns the joined comments
+// along with the indexes of their first lines.
+func (d Doc) join() (joined Doc, starts []int) {
 	incompleteComment := ""
+	start := 0
 
-	for _, comment := range d {
+	for i, comment := range d {
 		if incompleteComment == "" {
 			if HasContinuationLine(comment) {
 				incompleteComment = strings.TrimSuffix(comment, `\`)
+				start = i
 			} else {
 				joined = append(joined, comment)
+				starts = append(starts, i)
 			}
 			continue
 		}
@@ -58,6 +89,7 @@ func (d Doc) JoinComments() (joined Doc) {
 			incompleteComment = strings.TrimSuffix(c, `\`)
 		} else {
 			joined = append(joined, c)
+			starts = append(starts, start)
 			incompleteComment = ""
 		}
 	}
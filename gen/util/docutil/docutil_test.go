@@ -1,6 +1,7 @@
 package docutil_test
 
 import (
+	"go/token"
 	"reflect"
 	"testing"
 
@@ -47,3 +48,43 @@ func TestDoc_JoinLines(t *testing.T) {
 		})
 	}
 }
+
+func TestDoc_JoinPositions(t *testing.T) {
+	pos := func(line int) token.Position {
+		return token.Position{Filename: "service.go", Line: line, Column: 2}
+	}
+
+	tests := []struct {
+		name  string
+		in    docutil.Doc
+		inPos []token.Position
+		want  []token.Position
+	}{
+		{
+			name: "has backslash",
+			in: []string{
+				"//kun:op POST /logs",
+				`//kun:param ip in=header name=X-Forwarded-For, \`,
+				"//             in=request name=RemoteAddr",
+				"//kun:success statusCode=204",
+			},
+			inPos: []token.Position{pos(1), pos(2), pos(3), pos(4)},
+			want:  []token.Position{pos(1), pos(2), pos(4)},
+		},
+		{
+			name:  "mismatched positions",
+			in:    []string{"//kun:op POST /logs"},
+			inPos: nil,
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.in.JoinPositions(tt.inPos)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Positions: got (%#v), want (%#v)", got, tt.want)
+			}
+		})
+	}
+}
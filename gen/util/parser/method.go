@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"fmt"
+	"go/types"
+
+	"github.com/RussellLuo/kun/pkg/ifacetool"
+)
+
+// WithPos prefixes err with the source position of the i-th comment of the
+// method m, if known.
+func WithPos(m *ifacetool.Method, i int, err error) error {
+	if i >= len(m.DocPos) || !m.DocPos[i].IsValid() {
+		return err
+	}
+	return fmt.Errorf("%s: %w", m.DocPos[i], err)
+}
+
+// ValidateSignature reports whether the method m accepts a context.Context
+// as its first parameter and returns a single error.
+func ValidateSignature(m *ifacetool.Method) bool {
+	if len(m.Params) < 1 || len(m.Returns) != 1 {
+		return false
+	}
+
+	p := m.Params[0]
+	if _, ok := p.Type.Underlying().(*types.Interface); !ok || p.TypeString != "context.Context" {
+		return false
+	}
+
+	r := m.Returns[0]
+	if _, ok := r.Type.Underlying().(*types.Interface); !ok || r.TypeString != "error" {
+		return false
+	}
+
+	return true
+}
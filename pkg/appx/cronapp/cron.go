@@ -0,0 +1,96 @@
+package cronapp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/RussellLuo/appx"
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/micron"
+)
+
+// LockerFunc creates the locker used by a scheduler application, when the
+// scheduler application is initialized.
+type LockerFunc func(ctx appx.Context) (micron.Locker, error)
+
+// Locker returns a LockerFunc, which always uses locker.
+func Locker(locker micron.Locker) LockerFunc {
+	return func(appx.Context) (micron.Locker, error) {
+		return locker, nil
+	}
+}
+
+// SQLDB is the interface that a database application must implement.
+type SQLDB interface {
+	DB() *sql.DB
+}
+
+// SQLLocker returns a LockerFunc, which creates a cronjob.SQLLocker on top of
+// the database provided by the application named db. Note that the scheduler
+// application must require db.
+func SQLLocker(db string, opts ...cronjob.SQLLockerOption) LockerFunc {
+	return func(ctx appx.Context) (micron.Locker, error) {
+		d, err := getSQLDB(ctx.MustLoad(db))
+		if err != nil {
+			return nil, err
+		}
+		return cronjob.NewSQLLocker(d, opts...), nil
+	}
+}
+
+// Cron is a scheduler application backed by micron.Cron, whose locker is
+// configurable. It implements CronScheduler.
+type Cron struct {
+	newLocker LockerFunc
+	opts      *micron.Options
+
+	c *micron.Cron
+}
+
+// NewCron creates a scheduler application, which uses the locker created
+// by newLocker. If newLocker is nil, micron.NilLocker will be used.
+func NewCron(newLocker LockerFunc, opts *micron.Options) *Cron {
+	if newLocker == nil {
+		newLocker = Locker(micron.NewNilLocker())
+	}
+	return &Cron{
+		newLocker: newLocker,
+		opts:      opts,
+	}
+}
+
+func (c *Cron) Scheduler() Scheduler {
+	return c.c
+}
+
+func (c *Cron) Init(ctx appx.Context) error {
+	locker, err := c.newLocker(ctx)
+	if err != nil {
+		return err
+	}
+	c.c = micron.New(locker, c.opts)
+	return nil
+}
+
+func (c *Cron) Start(ctx context.Context) error {
+	c.c.Start()
+	return nil
+}
+
+func (c *Cron) Stop(ctx context.Context) error {
+	c.c.Stop()
+	return nil
+}
+
+func getSQLDB(instance interface{}) (*sql.DB, error) {
+	r, ok := instance.(SQLDB)
+	if !ok {
+		return nil, fmt.Errorf("instance %#v does not implement cronapp.SQLDB", instance)
+	}
+	result := r.DB()
+	if result == nil {
+		return nil, fmt.Errorf("method DB() of instance %#v returns nil", instance)
+	}
+	return result, nil
+}
@@ -0,0 +1,51 @@
+package cronapp
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type sqlDB struct {
+	db *sql.DB
+}
+
+func (d *sqlDB) DB() *sql.DB {
+	return d.db
+}
+
+func TestGetSQLDB(t *testing.T) {
+	db := new(sql.DB)
+
+	cases := []struct {
+		in      interface{}
+		wantDB  *sql.DB
+		wantErr error
+	}{
+		{
+			in:      nil,
+			wantDB:  nil,
+			wantErr: errors.New("instance <nil> does not implement cronapp.SQLDB"),
+		},
+		{
+			in:      &sqlDB{db: nil},
+			wantDB:  nil,
+			wantErr: errors.New("method DB() of instance &cronapp.sqlDB{db:(*sql.DB)(nil)} returns nil"),
+		},
+		{
+			in:      &sqlDB{db: db},
+			wantDB:  db,
+			wantErr: nil,
+		},
+	}
+	for _, c := range cases {
+		got, err := getSQLDB(c.in)
+		if got != c.wantDB {
+			t.Fatalf("DB: got (%#v), want (%#v)", got, c.wantDB)
+		}
+		if !reflect.DeepEqual(err, c.wantErr) {
+			t.Fatalf("Error: got (%#v), want (%#v)", err, c.wantErr)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/RussellLuo/appx"
+	"github.com/RussellLuo/kun/pkg/cronjob"
 	"github.com/RussellLuo/micron"
 )
 
@@ -40,8 +41,15 @@ func (m middleware) Init(ctx appx.Context) error {
 		return err
 	}
 
-	_ = scheduler.Add(m.name, m.expression, job.Task)
-	return nil
+	if _, ok := scheduler.(*micron.Cron); ok {
+		// Fail with an error, instead of a panic, on the expressions not
+		// supported by micron.Cron (e.g. those with timezones).
+		if err := cronjob.CheckMicron(micron.Job{Name: m.name, Expr: m.expression}); err != nil {
+			return err
+		}
+	}
+
+	return scheduler.Add(m.name, m.expression, job.Task)
 }
 
 // Scheduler represents a cron scheduler.
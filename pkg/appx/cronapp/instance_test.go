@@ -1,10 +1,13 @@
 package cronapp
 
 import (
+	"context"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/RussellLuo/appx"
 	"github.com/RussellLuo/micron"
 )
 
@@ -111,3 +114,19 @@ func TestGetCronJob(t *testing.T) {
 		}
 	}
 }
+
+func TestScheduledBy_MicronTimezone(t *testing.T) {
+	r := appx.NewRegistry()
+	r.MustRegister(New("scheduler", NewCron(nil, nil)).App)
+	r.MustRegister(New("greeter", &cronJob{job: &job{task: func() {}}}).ScheduledBy("scheduler", "CRON_TZ=Asia/Shanghai 0 0 9 * * * *").App)
+
+	err := r.Install(context.Background())
+	if err == nil {
+		r.Uninstall()
+		t.Fatal("want an error, got nil")
+	}
+	wantErrStr := "cronjob: job greeter has a timezone, which is not supported by micron.Cron (use cronjob.Scheduler instead)"
+	if !strings.Contains(err.Error(), wantErrStr) {
+		t.Fatalf("Err: got (%v), want (%v)", err, wantErrStr)
+	}
+}
@@ -0,0 +1,58 @@
+package cronapp2
+
+import (
+	"context"
+
+	"github.com/RussellLuo/appx"
+	"github.com/RussellLuo/kun/pkg/appx/cronapp"
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/micron"
+)
+
+// Cron is a scheduler application backed by cronjob.Scheduler, whose locker
+// is configurable. It implements CronScheduler.
+//
+// Unlike cronapp.Cron, it supports the jobs with timezones, and it can be
+// managed by cronadmin.
+type Cron struct {
+	newLocker cronapp.LockerFunc
+	opts      []cronjob.SchedulerOption
+
+	s *cronjob.Scheduler
+}
+
+// NewCron creates a scheduler application, which uses the locker created
+// by newLocker (e.g. cronapp.SQLLocker). If newLocker is nil,
+// micron.NilLocker will be used.
+func NewCron(newLocker cronapp.LockerFunc, opts ...cronjob.SchedulerOption) *Cron {
+	if newLocker == nil {
+		newLocker = cronapp.Locker(micron.NewNilLocker())
+	}
+	return &Cron{
+		newLocker: newLocker,
+		opts:      opts,
+	}
+}
+
+func (c *Cron) Scheduler() cronapp.Scheduler {
+	return c.s
+}
+
+func (c *Cron) Init(ctx appx.Context) error {
+	locker, err := c.newLocker(ctx)
+	if err != nil {
+		return err
+	}
+	c.s, err = cronjob.NewScheduler(locker, c.opts...)
+	return err
+}
+
+func (c *Cron) Start(ctx context.Context) error {
+	c.s.Start()
+	return nil
+}
+
+func (c *Cron) Stop(ctx context.Context) error {
+	c.s.Stop()
+	return nil
+}
@@ -0,0 +1,211 @@
+package cronapp2_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/appx"
+	"github.com/RussellLuo/kun/pkg/appx/cronapp"
+	"github.com/RussellLuo/kun/pkg/appx/cronapp2"
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/micron"
+	_ "modernc.org/sqlite"
+)
+
+type database struct {
+	db *sql.DB
+}
+
+func (d *database) DB() *sql.DB {
+	return d.db
+}
+
+func (d *database) Init(ctx appx.Context) (err error) {
+	d.db, err = sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return err
+	}
+	// Use a single connection to share the in-memory database.
+	d.db.SetMaxOpenConns(1)
+
+	_, err = d.db.Exec(`CREATE TABLE cron_locks (
+		job        VARCHAR(255) PRIMARY KEY,
+		owner      VARCHAR(255) NOT NULL,
+		expires_at BIGINT NOT NULL
+	)`)
+	return err
+}
+
+func (d *database) Clean() error {
+	return d.db.Close()
+}
+
+// manualClock is a clock, whose timers only fire when told to.
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+
+	waiting chan struct{} // Signaled each time the scheduler starts waiting.
+	fire    chan time.Time
+}
+
+func newManualClock(now time.Time) *manualClock {
+	return &manualClock{
+		now:     now,
+		waiting: make(chan struct{}, 10),
+		fire:    make(chan time.Time),
+	}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	c.waiting <- struct{}{}
+	return c.fire
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Tick fires the pending timer, and waits until the scheduler has handled it
+// (i.e. has tried to obtain the lock) and starts waiting again.
+func (c *manualClock) Tick(t *testing.T) {
+	c.fire <- c.Now()
+	select {
+	case <-c.waiting:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the scheduler")
+	}
+}
+
+type ticker struct {
+	owner string
+	runs  chan string
+}
+
+func (tk *ticker) Jobs() []micron.Job {
+	return []micron.Job{
+		{
+			Name: "tick",
+			Expr: "0 0 9 * * * *",
+			Task: func() { tk.runs <- tk.owner },
+		},
+	}
+}
+
+func TestCron_SQLLocker(t *testing.T) {
+	db := new(database)
+	runs := make(chan string, 10)
+	now := time.Date(2022, 1, 1, 9, 0, 0, 0, time.UTC)
+	clockA, clockB := newManualClock(now), newManualClock(now)
+
+	// Two scheduler instances share the same lock table.
+	newCron := func(owner string, clock *manualClock) *cronapp2.Cron {
+		return cronapp2.NewCron(
+			cronapp.SQLLocker("db", cronjob.LockOwner(owner), cronjob.LockerClock(clock)),
+			cronjob.SchedulerClock(clock),
+		)
+	}
+
+	r := appx.NewRegistry()
+	r.MustRegister(appx.New("db", db))
+	r.MustRegister(cronapp2.New("schedulerA", newCron("a", clockA)).Require("db").App)
+	r.MustRegister(cronapp2.New("schedulerB", newCron("b", clockB)).Require("db").App)
+	r.MustRegister(cronapp2.New("tickerA", &ticker{owner: "a", runs: runs}).ScheduledBy("schedulerA").App)
+	r.MustRegister(cronapp2.New("tickerB", &ticker{owner: "b", runs: runs}).ScheduledBy("schedulerB").App)
+
+	if err := r.Install(context.Background()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer r.Uninstall()
+
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer r.Stop(context.Background())
+
+	// Wait for both schedulers to start waiting.
+	<-clockA.waiting
+	<-clockB.waiting
+
+	wantRun := func(want string) {
+		select {
+		case got := <-runs:
+			if got != want {
+				t.Fatalf("Run: got (%q), want (%q)", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the job to run")
+		}
+	}
+	wantOwner := func(want string) {
+		var owner string
+		if err := db.db.QueryRow("SELECT owner FROM cron_locks WHERE job = ?", "tick").Scan(&owner); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if owner != want {
+			t.Fatalf("Owner: got (%q), want (%q)", owner, want)
+		}
+	}
+
+	// A obtains the lock, and runs the job.
+	clockA.Tick(t)
+	wantRun("a")
+	wantOwner("a")
+
+	// B fails to obtain the lock, which is held by A.
+	clockB.Tick(t)
+	wantOwner("a")
+
+	// B takes over the lock after it expires (the TTL defaults to 1s).
+	clockA.Advance(2 * time.Second)
+	clockB.Advance(2 * time.Second)
+	clockB.Tick(t)
+	wantRun("b")
+	wantOwner("b")
+
+	select {
+	case got := <-runs:
+		t.Fatalf("Run: got unexpected run by (%q)", got)
+	default:
+	}
+}
+
+type shanghaiJobs struct{}
+
+func (shanghaiJobs) Jobs() []micron.Job {
+	return []micron.Job{
+		cronjob.Wrap(micron.Job{
+			Name: "greet",
+			Expr: "0 0 9 * * * *",
+			Task: func() {},
+		}, cronjob.Timezone("Asia/Shanghai")),
+	}
+}
+
+func TestScheduledBy_MicronTimezone(t *testing.T) {
+	r := appx.NewRegistry()
+	r.MustRegister(cronapp.New("scheduler", cronapp.NewCron(nil, nil)).App)
+	r.MustRegister(cronapp2.New("greeter", shanghaiJobs{}).ScheduledBy("scheduler").App)
+
+	err := r.Install(context.Background())
+	if err == nil {
+		r.Uninstall()
+		t.Fatal("want an error, got nil")
+	}
+	wantErrStr := "cronjob: job greet has a timezone, which is not supported by micron.Cron (use cronjob.Scheduler instead)"
+	if !strings.Contains(err.Error(), wantErrStr) {
+		t.Fatalf("Err: got (%v), want (%v)", err, wantErrStr)
+	}
+}
@@ -0,0 +1,46 @@
+package cronadmin
+
+import (
+	"fmt"
+
+	"github.com/RussellLuo/appx"
+	"github.com/RussellLuo/kun/pkg/appx/cronapp2"
+	"github.com/RussellLuo/kun/pkg/appx/httpapp"
+	"github.com/RussellLuo/kun/pkg/httpcodec"
+	"github.com/go-chi/chi"
+)
+
+// New creates an HTTP application named name, which serves the admin API
+// of the jobs scheduled by the scheduler application named scheduler. The
+// scheduler must implement Manager (e.g. cronjob.Scheduler).
+//
+// Like any other HTTP application, it can be mounted by MountOn:
+//
+//	cronadmin.New("cronadmin", "scheduler").MountOn("server", "/cron")
+func New(name, scheduler string) *httpapp.App {
+	return httpapp.New(name, &instance{scheduler: scheduler}).Require(scheduler)
+}
+
+type instance struct {
+	scheduler string
+	router    chi.Router
+}
+
+func (i *instance) Router() chi.Router {
+	return i.router
+}
+
+func (i *instance) Init(ctx appx.Context) error {
+	s, ok := ctx.MustLoad(i.scheduler).(cronapp2.CronScheduler)
+	if !ok {
+		return fmt.Errorf("instance of %s does not implement cronapp2.CronScheduler", i.scheduler)
+	}
+
+	m, ok := s.Scheduler().(Manager)
+	if !ok {
+		return fmt.Errorf("scheduler of %s does not implement cronadmin.Manager", i.scheduler)
+	}
+
+	i.router = NewHTTPRouter(NewAdmin(m), httpcodec.NewDefaultCodecs(nil))
+	return nil
+}
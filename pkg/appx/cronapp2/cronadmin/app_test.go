@@ -0,0 +1,144 @@
+package cronadmin_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/appx"
+	"github.com/RussellLuo/kun/pkg/appx/cronapp"
+	"github.com/RussellLuo/kun/pkg/appx/cronapp2"
+	"github.com/RussellLuo/kun/pkg/appx/cronapp2/cronadmin"
+	"github.com/RussellLuo/kun/pkg/appx/httpapp"
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/micron"
+	"github.com/go-chi/chi"
+)
+
+type scheduler struct {
+	s *cronjob.Scheduler
+}
+
+func (s *scheduler) Scheduler() cronapp.Scheduler {
+	return s.s
+}
+
+func (s *scheduler) Init(ctx appx.Context) (err error) {
+	s.s, err = cronjob.NewScheduler(micron.NewNilLocker())
+	return err
+}
+
+type jobs struct {
+	runC chan string
+}
+
+func (j *jobs) Jobs() []micron.Job {
+	return []micron.Job{
+		{
+			Name: "hi",
+			Expr: "@every 1h",
+			Task: func() { j.runC <- "hi" },
+		},
+	}
+}
+
+type server struct {
+	router chi.Router
+}
+
+func (s *server) Router() chi.Router {
+	return s.router
+}
+
+func (s *server) Init(ctx appx.Context) error {
+	s.router = chi.NewRouter()
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	runC := make(chan string, 1)
+	srv := new(server)
+
+	r := appx.NewRegistry()
+	r.MustRegister(httpapp.New("server", srv).App)
+	r.MustRegister(cronapp2.New("scheduler", new(scheduler)).App)
+	r.MustRegister(cronapp2.New("jobs", &jobs{runC: runC}).ScheduledBy("scheduler").App)
+	r.MustRegister(cronadmin.New("cronadmin", "scheduler").MountOn("server", "/cron").Require("jobs").App)
+	if err := r.Install(context.Background()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer r.Uninstall()
+
+	ts := httptest.NewServer(srv.router)
+	defer ts.Close()
+
+	do := func(method, path string) (int, string) {
+		req, _ := http.NewRequest(method, ts.URL+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	getJob := func() cronjob.JobInfo {
+		status, body := do("GET", "/cron/jobs/hi")
+		if status != http.StatusOK {
+			t.Fatalf("Status: got (%d), want (%d)", status, http.StatusOK)
+		}
+		var job cronjob.JobInfo
+		if err := json.Unmarshal([]byte(body), &job); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return job
+	}
+
+	// List the jobs.
+	status, body := do("GET", "/cron/jobs")
+	if status != http.StatusOK || !strings.Contains(body, `"name":"hi","expr":"@every 1h","paused":false`) {
+		t.Fatalf("List: got (%d, %s)", status, body)
+	}
+
+	// Trigger the job.
+	if status, _ := do("POST", "/cron/jobs/hi/trigger"); status != http.StatusAccepted {
+		t.Fatalf("Trigger: got (%d), want (%d)", status, http.StatusAccepted)
+	}
+	<-runC
+	deadline := time.Now().Add(time.Second)
+	for getJob().LastRun == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if run := getJob().LastRun; run == nil || !run.Manual || run.Outcome != cronjob.OutcomeSuccess {
+		t.Fatalf("LastRun: got (%#v)", run)
+	}
+
+	// Pause and resume the job.
+	if status, _ := do("POST", "/cron/jobs/hi/pause"); status != http.StatusNoContent {
+		t.Fatalf("Pause: got (%d), want (%d)", status, http.StatusNoContent)
+	}
+	if !getJob().Paused {
+		t.Fatal("Paused: got (false), want (true)")
+	}
+	if status, _ := do("POST", "/cron/jobs/hi/resume"); status != http.StatusNoContent {
+		t.Fatalf("Resume: got (%d), want (%d)", status, http.StatusNoContent)
+	}
+	if getJob().Paused {
+		t.Fatal("Paused: got (true), want (false)")
+	}
+
+	// Unknown jobs.
+	if status, _ := do("POST", "/cron/jobs/bye/trigger"); status != http.StatusNotFound {
+		t.Fatalf("Trigger: got (%d), want (%d)", status, http.StatusNotFound)
+	}
+
+	// The OAS document.
+	if status, body := do("GET", "/cron/api"); status != http.StatusOK || !strings.Contains(body, `title: "Cron-Admin-API"`) {
+		t.Fatalf("OAS: got (%d, %s)", status, body)
+	}
+}
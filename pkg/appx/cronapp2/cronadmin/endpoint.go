@@ -0,0 +1,178 @@
+// Code generated by kun; DO NOT EDIT.
+// github.com/RussellLuo/kun
+
+package cronadmin
+
+import (
+	"context"
+
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/kun/pkg/httpoption"
+	"github.com/RussellLuo/validating/v3"
+	"github.com/go-kit/kit/endpoint"
+)
+
+type GetJobRequest struct {
+	Name string `json:"-"`
+}
+
+// ValidateGetJobRequest creates a validator for GetJobRequest.
+func ValidateGetJobRequest(newSchema func(*GetJobRequest) validating.Schema) httpoption.Validator {
+	return httpoption.FuncValidator(func(value interface{}) error {
+		req := value.(*GetJobRequest)
+		return httpoption.Validate(newSchema(req))
+	})
+}
+
+type GetJobResponse struct {
+	Job cronjob.JobInfo `json:"job"`
+	Err error           `json:"-"`
+}
+
+func (r *GetJobResponse) Body() interface{} { return &r.Job }
+
+// Failed implements endpoint.Failer.
+func (r *GetJobResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfGetJob creates the endpoint for s.GetJob.
+func MakeEndpointOfGetJob(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*GetJobRequest)
+		job, err := s.GetJob(
+			ctx,
+			req.Name,
+		)
+		return &GetJobResponse{
+			Job: job,
+			Err: err,
+		}, nil
+	}
+}
+
+type ListJobsResponse struct {
+	Jobs []cronjob.JobInfo `json:"jobs"`
+	Err  error             `json:"-"`
+}
+
+func (r *ListJobsResponse) Body() interface{} { return r }
+
+// Failed implements endpoint.Failer.
+func (r *ListJobsResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfListJobs creates the endpoint for s.ListJobs.
+func MakeEndpointOfListJobs(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		jobs, err := s.ListJobs(
+			ctx,
+		)
+		return &ListJobsResponse{
+			Jobs: jobs,
+			Err:  err,
+		}, nil
+	}
+}
+
+type PauseJobRequest struct {
+	Name string `json:"-"`
+}
+
+// ValidatePauseJobRequest creates a validator for PauseJobRequest.
+func ValidatePauseJobRequest(newSchema func(*PauseJobRequest) validating.Schema) httpoption.Validator {
+	return httpoption.FuncValidator(func(value interface{}) error {
+		req := value.(*PauseJobRequest)
+		return httpoption.Validate(newSchema(req))
+	})
+}
+
+type PauseJobResponse struct {
+	Err error `json:"-"`
+}
+
+func (r *PauseJobResponse) Body() interface{} { return r }
+
+// Failed implements endpoint.Failer.
+func (r *PauseJobResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfPauseJob creates the endpoint for s.PauseJob.
+func MakeEndpointOfPauseJob(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*PauseJobRequest)
+		err := s.PauseJob(
+			ctx,
+			req.Name,
+		)
+		return &PauseJobResponse{
+			Err: err,
+		}, nil
+	}
+}
+
+type ResumeJobRequest struct {
+	Name string `json:"-"`
+}
+
+// ValidateResumeJobRequest creates a validator for ResumeJobRequest.
+func ValidateResumeJobRequest(newSchema func(*ResumeJobRequest) validating.Schema) httpoption.Validator {
+	return httpoption.FuncValidator(func(value interface{}) error {
+		req := value.(*ResumeJobRequest)
+		return httpoption.Validate(newSchema(req))
+	})
+}
+
+type ResumeJobResponse struct {
+	Err error `json:"-"`
+}
+
+func (r *ResumeJobResponse) Body() interface{} { return r }
+
+// Failed implements endpoint.Failer.
+func (r *ResumeJobResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfResumeJob creates the endpoint for s.ResumeJob.
+func MakeEndpointOfResumeJob(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*ResumeJobRequest)
+		err := s.ResumeJob(
+			ctx,
+			req.Name,
+		)
+		return &ResumeJobResponse{
+			Err: err,
+		}, nil
+	}
+}
+
+type TriggerJobRequest struct {
+	Name string `json:"-"`
+}
+
+// ValidateTriggerJobRequest creates a validator for TriggerJobRequest.
+func ValidateTriggerJobRequest(newSchema func(*TriggerJobRequest) validating.Schema) httpoption.Validator {
+	return httpoption.FuncValidator(func(value interface{}) error {
+		req := value.(*TriggerJobRequest)
+		return httpoption.Validate(newSchema(req))
+	})
+}
+
+type TriggerJobResponse struct {
+	Err error `json:"-"`
+}
+
+func (r *TriggerJobResponse) Body() interface{} { return r }
+
+// Failed implements endpoint.Failer.
+func (r *TriggerJobResponse) Failed() error { return r.Err }
+
+// MakeEndpointOfTriggerJob creates the endpoint for s.TriggerJob.
+func MakeEndpointOfTriggerJob(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*TriggerJobRequest)
+		err := s.TriggerJob(
+			ctx,
+			req.Name,
+		)
+		return &TriggerJobResponse{
+			Err: err,
+		}, nil
+	}
+}
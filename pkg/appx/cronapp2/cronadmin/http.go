@@ -0,0 +1,172 @@
+// Code generated by kun; DO NOT EDIT.
+// github.com/RussellLuo/kun
+
+package cronadmin
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/RussellLuo/kun/pkg/httpcodec"
+	"github.com/RussellLuo/kun/pkg/httpoption"
+	"github.com/RussellLuo/kun/pkg/oas2"
+	"github.com/go-chi/chi"
+	kithttp "github.com/go-kit/kit/transport/http"
+)
+
+func NewHTTPRouter(svc Service, codecs httpcodec.Codecs, opts ...httpoption.Option) chi.Router {
+	r := chi.NewRouter()
+	options := httpoption.NewOptions(opts...)
+
+	r.Method("GET", "/api", oas2.Handler(OASv2APIDoc, options.ResponseSchema()))
+
+	var codec httpcodec.Codec
+	var validator httpoption.Validator
+	var kitOptions []kithttp.ServerOption
+
+	codec = codecs.EncodeDecoder("GetJob")
+	validator = options.RequestValidator("GetJob")
+	r.Method(
+		"GET", "/jobs/{name}",
+		kithttp.NewServer(
+			MakeEndpointOfGetJob(svc),
+			decodeGetJobRequest(codec, validator),
+			httpcodec.MakeResponseEncoder(codec, 200),
+			append(kitOptions,
+				kithttp.ServerErrorEncoder(httpcodec.MakeErrorEncoder(codec)),
+			)...,
+		),
+	)
+
+	codec = codecs.EncodeDecoder("ListJobs")
+	validator = options.RequestValidator("ListJobs")
+	r.Method(
+		"GET", "/jobs",
+		kithttp.NewServer(
+			MakeEndpointOfListJobs(svc),
+			decodeListJobsRequest(codec, validator),
+			httpcodec.MakeResponseEncoder(codec, 200),
+			append(kitOptions,
+				kithttp.ServerErrorEncoder(httpcodec.MakeErrorEncoder(codec)),
+			)...,
+		),
+	)
+
+	codec = codecs.EncodeDecoder("PauseJob")
+	validator = options.RequestValidator("PauseJob")
+	r.Method(
+		"POST", "/jobs/{name}/pause",
+		kithttp.NewServer(
+			MakeEndpointOfPauseJob(svc),
+			decodePauseJobRequest(codec, validator),
+			httpcodec.MakeResponseEncoder(codec, 204),
+			append(kitOptions,
+				kithttp.ServerErrorEncoder(httpcodec.MakeErrorEncoder(codec)),
+			)...,
+		),
+	)
+
+	codec = codecs.EncodeDecoder("ResumeJob")
+	validator = options.RequestValidator("ResumeJob")
+	r.Method(
+		"POST", "/jobs/{name}/resume",
+		kithttp.NewServer(
+			MakeEndpointOfResumeJob(svc),
+			decodeResumeJobRequest(codec, validator),
+			httpcodec.MakeResponseEncoder(codec, 204),
+			append(kitOptions,
+				kithttp.ServerErrorEncoder(httpcodec.MakeErrorEncoder(codec)),
+			)...,
+		),
+	)
+
+	codec = codecs.EncodeDecoder("TriggerJob")
+	validator = options.RequestValidator("TriggerJob")
+	r.Method(
+		"POST", "/jobs/{name}/trigger",
+		kithttp.NewServer(
+			MakeEndpointOfTriggerJob(svc),
+			decodeTriggerJobRequest(codec, validator),
+			httpcodec.MakeResponseEncoder(codec, 202),
+			append(kitOptions,
+				kithttp.ServerErrorEncoder(httpcodec.MakeErrorEncoder(codec)),
+			)...,
+		),
+	)
+
+	return r
+}
+
+func decodeGetJobRequest(codec httpcodec.Codec, validator httpoption.Validator) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		var _req GetJobRequest
+
+		name := []string{chi.URLParam(r, "name")}
+		if err := codec.DecodeRequestParam("name", name, &_req.Name); err != nil {
+			return nil, err
+		}
+
+		if err := validator.Validate(&_req); err != nil {
+			return nil, err
+		}
+
+		return &_req, nil
+	}
+}
+
+func decodeListJobsRequest(codec httpcodec.Codec, validator httpoption.Validator) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		return nil, nil
+	}
+}
+
+func decodePauseJobRequest(codec httpcodec.Codec, validator httpoption.Validator) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		var _req PauseJobRequest
+
+		name := []string{chi.URLParam(r, "name")}
+		if err := codec.DecodeRequestParam("name", name, &_req.Name); err != nil {
+			return nil, err
+		}
+
+		if err := validator.Validate(&_req); err != nil {
+			return nil, err
+		}
+
+		return &_req, nil
+	}
+}
+
+func decodeResumeJobRequest(codec httpcodec.Codec, validator httpoption.Validator) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		var _req ResumeJobRequest
+
+		name := []string{chi.URLParam(r, "name")}
+		if err := codec.DecodeRequestParam("name", name, &_req.Name); err != nil {
+			return nil, err
+		}
+
+		if err := validator.Validate(&_req); err != nil {
+			return nil, err
+		}
+
+		return &_req, nil
+	}
+}
+
+func decodeTriggerJobRequest(codec httpcodec.Codec, validator httpoption.Validator) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		var _req TriggerJobRequest
+
+		name := []string{chi.URLParam(r, "name")}
+		if err := codec.DecodeRequestParam("name", name, &_req.Name); err != nil {
+			return nil, err
+		}
+
+		if err := validator.Validate(&_req); err != nil {
+			return nil, err
+		}
+
+		return &_req, nil
+	}
+}
@@ -0,0 +1,220 @@
+// Code generated by kun; DO NOT EDIT.
+// github.com/RussellLuo/kun
+
+package cronadmin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/kun/pkg/httpcodec"
+)
+
+type HTTPClient struct {
+	codecs     httpcodec.Codecs
+	httpClient *http.Client
+	scheme     string
+	host       string
+	pathPrefix string
+}
+
+func NewHTTPClient(codecs httpcodec.Codecs, httpClient *http.Client, baseURL string) (*HTTPClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	return &HTTPClient{
+		codecs:     codecs,
+		httpClient: httpClient,
+		scheme:     u.Scheme,
+		host:       u.Host,
+		pathPrefix: strings.TrimSuffix(u.Path, "/"),
+	}, nil
+}
+
+func (c *HTTPClient) GetJob(ctx context.Context, name string) (job cronjob.JobInfo, err error) {
+	codec := c.codecs.EncodeDecoder("GetJob")
+
+	path := fmt.Sprintf("/jobs/%s",
+		codec.EncodeRequestParam("name", name)[0],
+	)
+	u := &url.URL{
+		Scheme: c.scheme,
+		Host:   c.host,
+		Path:   c.pathPrefix + path,
+	}
+
+	_req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return cronjob.JobInfo{}, err
+	}
+
+	_resp, err := c.httpClient.Do(_req)
+	if err != nil {
+		return cronjob.JobInfo{}, err
+	}
+	defer _resp.Body.Close()
+
+	if _resp.StatusCode < http.StatusOK || _resp.StatusCode > http.StatusNoContent {
+		var respErr error
+		err := codec.DecodeFailureResponse(_resp.Body, &respErr)
+		if err == nil {
+			err = respErr
+		}
+		return cronjob.JobInfo{}, err
+	}
+
+	respBody := &GetJobResponse{}
+	err = codec.DecodeSuccessResponse(_resp.Body, respBody.Body())
+	if err != nil {
+		return cronjob.JobInfo{}, err
+	}
+	return respBody.Job, nil
+}
+
+func (c *HTTPClient) ListJobs(ctx context.Context) (jobs []cronjob.JobInfo, err error) {
+	codec := c.codecs.EncodeDecoder("ListJobs")
+
+	path := "/jobs"
+	u := &url.URL{
+		Scheme: c.scheme,
+		Host:   c.host,
+		Path:   c.pathPrefix + path,
+	}
+
+	_req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_resp, err := c.httpClient.Do(_req)
+	if err != nil {
+		return nil, err
+	}
+	defer _resp.Body.Close()
+
+	if _resp.StatusCode < http.StatusOK || _resp.StatusCode > http.StatusNoContent {
+		var respErr error
+		err := codec.DecodeFailureResponse(_resp.Body, &respErr)
+		if err == nil {
+			err = respErr
+		}
+		return nil, err
+	}
+
+	respBody := &ListJobsResponse{}
+	err = codec.DecodeSuccessResponse(_resp.Body, respBody.Body())
+	if err != nil {
+		return nil, err
+	}
+	return respBody.Jobs, nil
+}
+
+func (c *HTTPClient) PauseJob(ctx context.Context, name string) (err error) {
+	codec := c.codecs.EncodeDecoder("PauseJob")
+
+	path := fmt.Sprintf("/jobs/%s/pause",
+		codec.EncodeRequestParam("name", name)[0],
+	)
+	u := &url.URL{
+		Scheme: c.scheme,
+		Host:   c.host,
+		Path:   c.pathPrefix + path,
+	}
+
+	_req, err := http.NewRequestWithContext(ctx, "POST", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_resp, err := c.httpClient.Do(_req)
+	if err != nil {
+		return err
+	}
+	defer _resp.Body.Close()
+
+	if _resp.StatusCode < http.StatusOK || _resp.StatusCode > http.StatusNoContent {
+		var respErr error
+		err := codec.DecodeFailureResponse(_resp.Body, &respErr)
+		if err == nil {
+			err = respErr
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (c *HTTPClient) ResumeJob(ctx context.Context, name string) (err error) {
+	codec := c.codecs.EncodeDecoder("ResumeJob")
+
+	path := fmt.Sprintf("/jobs/%s/resume",
+		codec.EncodeRequestParam("name", name)[0],
+	)
+	u := &url.URL{
+		Scheme: c.scheme,
+		Host:   c.host,
+		Path:   c.pathPrefix + path,
+	}
+
+	_req, err := http.NewRequestWithContext(ctx, "POST", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_resp, err := c.httpClient.Do(_req)
+	if err != nil {
+		return err
+	}
+	defer _resp.Body.Close()
+
+	if _resp.StatusCode < http.StatusOK || _resp.StatusCode > http.StatusNoContent {
+		var respErr error
+		err := codec.DecodeFailureResponse(_resp.Body, &respErr)
+		if err == nil {
+			err = respErr
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (c *HTTPClient) TriggerJob(ctx context.Context, name string) (err error) {
+	codec := c.codecs.EncodeDecoder("TriggerJob")
+
+	path := fmt.Sprintf("/jobs/%s/trigger",
+		codec.EncodeRequestParam("name", name)[0],
+	)
+	u := &url.URL{
+		Scheme: c.scheme,
+		Host:   c.host,
+		Path:   c.pathPrefix + path,
+	}
+
+	_req, err := http.NewRequestWithContext(ctx, "POST", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_resp, err := c.httpClient.Do(_req)
+	if err != nil {
+		return err
+	}
+	defer _resp.Body.Close()
+
+	if _resp.StatusCode < http.StatusOK || _resp.StatusCode > http.StatusNoContent {
+		var respErr error
+		err := codec.DecodeFailureResponse(_resp.Body, &respErr)
+		if err == nil {
+			err = respErr
+		}
+		return err
+	}
+
+	return nil
+}
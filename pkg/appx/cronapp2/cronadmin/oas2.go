@@ -0,0 +1,131 @@
+// Code generated by kun; DO NOT EDIT.
+// github.com/RussellLuo/kun
+
+package cronadmin
+
+import (
+	"github.com/RussellLuo/kun/pkg/oas2"
+)
+
+var (
+	base = `swagger: "2.0"
+info:
+  title: "Cron-Admin-API"
+  version: "1.0.0"
+  description: "Service is used for managing cron jobs."
+  license:
+    name: "MIT"
+host: "example.com"
+basePath: "/"
+schemes:
+  - "https"
+consumes:
+  - "application/json"
+produces:
+  - "application/json"
+`
+
+	paths = `
+paths:
+  /jobs/{name}:
+    get:
+      description: "gets the job by name."
+      summary: "gets the job by name."
+      operationId: "GetJob"
+      tags:
+        - cron
+      parameters:
+        - name: name
+          in: path
+          required: true
+          type: string
+          description: ""
+      %s
+  /jobs:
+    get:
+      description: "lists all the jobs, along with their next runs and last runs."
+      summary: "lists all the jobs, along with their next runs and last runs."
+      operationId: "ListJobs"
+      tags:
+        - cron
+      %s
+  /jobs/{name}/pause:
+    post:
+      description: "pauses the job, whose scheduled runs will be skipped until resumed."
+      summary: "pauses the job, whose scheduled runs will be skipped until resumed."
+      operationId: "PauseJob"
+      tags:
+        - cron
+      parameters:
+        - name: name
+          in: path
+          required: true
+          type: string
+          description: ""
+      %s
+  /jobs/{name}/resume:
+    post:
+      description: "resumes the job."
+      summary: "resumes the job."
+      operationId: "ResumeJob"
+      tags:
+        - cron
+      parameters:
+        - name: name
+          in: path
+          required: true
+          type: string
+          description: ""
+      %s
+  /jobs/{name}/trigger:
+    post:
+      description: "runs the job now in the background, regardless of its schedule."
+      summary: "runs the job now in the background, regardless of its schedule."
+      operationId: "TriggerJob"
+      tags:
+        - cron
+      parameters:
+        - name: name
+          in: path
+          required: true
+          type: string
+          description: ""
+      %s
+`
+)
+
+func getResponses(schema oas2.Schema) []oas2.OASResponses {
+	return []oas2.OASResponses{
+		oas2.GetOASResponses(schema, "GetJob", 200, &GetJobResponse{}),
+		oas2.GetOASResponses(schema, "ListJobs", 200, &ListJobsResponse{}),
+		oas2.GetOASResponses(schema, "PauseJob", 204, &PauseJobResponse{}),
+		oas2.GetOASResponses(schema, "ResumeJob", 204, &ResumeJobResponse{}),
+		oas2.GetOASResponses(schema, "TriggerJob", 202, &TriggerJobResponse{}),
+	}
+}
+
+func getDefinitions(schema oas2.Schema) map[string]oas2.Definition {
+	defs := make(map[string]oas2.Definition)
+
+	oas2.AddResponseDefinitions(defs, schema, "GetJob", 200, (&GetJobResponse{}).Body())
+
+	oas2.AddResponseDefinitions(defs, schema, "ListJobs", 200, (&ListJobsResponse{}).Body())
+
+	oas2.AddResponseDefinitions(defs, schema, "PauseJob", 204, (&PauseJobResponse{}).Body())
+
+	oas2.AddResponseDefinitions(defs, schema, "ResumeJob", 204, (&ResumeJobResponse{}).Body())
+
+	oas2.AddResponseDefinitions(defs, schema, "TriggerJob", 202, (&TriggerJobResponse{}).Body())
+
+	return defs
+}
+
+func OASv2APIDoc(schema oas2.Schema) string {
+	resps := getResponses(schema)
+	paths := oas2.GenPaths(resps, paths)
+
+	defs := getDefinitions(schema)
+	definitions := oas2.GenDefinitions(defs)
+
+	return base + paths + definitions
+}
@@ -0,0 +1,93 @@
+// Package cronadmin provides an HTTP API for managing the cron jobs, which
+// are scheduled by a manageable scheduler (e.g. cronjob.Scheduler).
+package cronadmin
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/kun/pkg/werror"
+	"github.com/RussellLuo/kun/pkg/werror/gcode"
+)
+
+//go:generate kungen ./service.go Service
+
+// Service is used for managing cron jobs.
+//kun:oas title=Cron-Admin-API
+//kun:oas version=1.0.0
+//kun:oas tags=cron
+type Service interface {
+	// ListJobs lists all the jobs, along with their next runs and last runs.
+	//kun:op GET /jobs
+	ListJobs(ctx context.Context) (jobs []cronjob.JobInfo, err error)
+
+	// GetJob gets the job by name.
+	//kun:op GET /jobs/{name}
+	//kun:success body=job
+	GetJob(ctx context.Context, name string) (job cronjob.JobInfo, err error)
+
+	// TriggerJob runs the job now in the background, regardless of its schedule.
+	//kun:op POST /jobs/{name}/trigger
+	//kun:success statusCode=202
+	TriggerJob(ctx context.Context, name string) (err error)
+
+	// PauseJob pauses the job, whose scheduled runs will be skipped until resumed.
+	//kun:op POST /jobs/{name}/pause
+	//kun:success statusCode=204
+	PauseJob(ctx context.Context, name string) (err error)
+
+	// ResumeJob resumes the job.
+	//kun:op POST /jobs/{name}/resume
+	//kun:success statusCode=204
+	ResumeJob(ctx context.Context, name string) (err error)
+}
+
+// Manager is the interface that a manageable scheduler must implement.
+type Manager interface {
+	Jobs() []cronjob.JobInfo
+	Trigger(name string) error
+	Pause(name string) error
+	Resume(name string) error
+}
+
+// Admin implements Service by a manageable scheduler.
+type Admin struct {
+	m Manager
+}
+
+func NewAdmin(m Manager) *Admin {
+	return &Admin{m: m}
+}
+
+func (a *Admin) ListJobs(ctx context.Context) ([]cronjob.JobInfo, error) {
+	return a.m.Jobs(), nil
+}
+
+func (a *Admin) GetJob(ctx context.Context, name string) (cronjob.JobInfo, error) {
+	for _, job := range a.m.Jobs() {
+		if job.Name == name {
+			return job, nil
+		}
+	}
+	return cronjob.JobInfo{}, werror.Wrapf(gcode.ErrNotFound, "job %s not found", name)
+}
+
+func (a *Admin) TriggerJob(ctx context.Context, name string) error {
+	return toGcodeError(a.m.Trigger(name))
+}
+
+func (a *Admin) PauseJob(ctx context.Context, name string) error {
+	return toGcodeError(a.m.Pause(name))
+}
+
+func (a *Admin) ResumeJob(ctx context.Context, name string) error {
+	return toGcodeError(a.m.Resume(name))
+}
+
+func toGcodeError(err error) error {
+	if errors.Is(err, cronjob.ErrJobNotFound) {
+		return werror.Wrap(gcode.ErrNotFound, err)
+	}
+	return err
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/RussellLuo/appx"
 	"github.com/RussellLuo/kun/pkg/appx/cronapp"
+	"github.com/RussellLuo/kun/pkg/cronjob"
 	"github.com/RussellLuo/micron"
 )
 
@@ -37,6 +38,14 @@ func (m middleware) Init(ctx appx.Context) error {
 		return err
 	}
 
+	if _, ok := scheduler.(*micron.Cron); ok {
+		// Fail with an error, instead of a panic, on the jobs not supported
+		// by micron.Cron (e.g. those with timezones).
+		if err := cronjob.CheckMicron(jobs...); err != nil {
+			return err
+		}
+	}
+
 	return scheduler.AddJob(jobs...)
 }
 
@@ -0,0 +1,29 @@
+package eventapp
+
+import (
+	"github.com/RussellLuo/appx"
+)
+
+type App struct {
+	*appx.App
+}
+
+func New(name string, instance appx.Instance) *App {
+	return &App{App: appx.New(name, instance)}
+}
+
+func (a *App) SubscribedBy(broker string, topics ...string) *App {
+	a.App.Use(SubscribedBy(broker, topics...))
+	a.App.Require(broker)
+	return a
+}
+
+func (a *App) Use(middlewares ...func(appx.Standard) appx.Standard) *App {
+	a.App.Use(middlewares...)
+	return a
+}
+
+func (a *App) Require(names ...string) *App {
+	a.App.Require(names...)
+	return a
+}
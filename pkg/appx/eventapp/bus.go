@@ -0,0 +1,59 @@
+package eventapp
+
+import (
+	"context"
+
+	"github.com/RussellLuo/appx"
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/RussellLuo/kun/pkg/eventpubsub/membus"
+)
+
+// Bus is a broker application backed by membus.Bus. It implements
+// EventBroker.
+//
+// The bus is closed when the application is stopped, which waits until all
+// the enqueued events have been handled or the stopping context is done.
+type Bus struct {
+	opts []membus.Option
+
+	bus *membus.Bus
+}
+
+// NewBus creates a broker application, whose bus is created with opts.
+func NewBus(opts ...membus.Option) *Bus {
+	return &Bus{opts: opts}
+}
+
+// Bus returns the underlying bus, which is typically used for publishing.
+func (b *Bus) Bus() *membus.Bus {
+	return b.bus
+}
+
+func (b *Bus) Broker() Broker {
+	if b.bus == nil {
+		return nil
+	}
+	return b
+}
+
+// Subscribe implements Broker.
+func (b *Bus) Subscribe(topic string, handler eventpubsub.Handler) (Subscription, error) {
+	sub, err := b.bus.SubscribeWithCancel(topic, handler)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (b *Bus) Init(ctx appx.Context) error {
+	b.bus = membus.New(b.opts...)
+	return nil
+}
+
+func (b *Bus) Start(ctx context.Context) error {
+	return nil
+}
+
+func (b *Bus) Stop(ctx context.Context) error {
+	return b.bus.Close(ctx)
+}
@@ -0,0 +1,80 @@
+package eventapp_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/appx"
+	"github.com/RussellLuo/kun/pkg/appx/eventapp"
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+// subscriber records the events it handles, and its stopping.
+type subscriber struct {
+	mu      sync.Mutex
+	logs    []string
+	stopped bool
+}
+
+func (s *subscriber) Handler() eventpubsub.Handler {
+	return eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		time.Sleep(10 * time.Millisecond)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.stopped {
+			s.logs = append(s.logs, "handled after stopped")
+		} else {
+			s.logs = append(s.logs, "handled")
+		}
+		return nil
+	})
+}
+
+func (s *subscriber) Start(ctx context.Context) error {
+	return nil
+}
+
+func (s *subscriber) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	s.logs = append(s.logs, "stopped")
+	return nil
+}
+
+func TestBus_StopOrder(t *testing.T) {
+	sub := new(subscriber)
+	bus := eventapp.NewBus()
+
+	r := appx.NewRegistry()
+	r.MustRegister(eventapp.New("bus", bus).App)
+	r.MustRegister(eventapp.New("sub", sub).SubscribedBy("bus", "user.*").App)
+
+	ctx := context.Background()
+	if err := r.Install(ctx); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer r.Uninstall()
+	if err := r.Start(ctx); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := bus.Bus().Publish(ctx, "user.created", nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// The subscriber is stopped before the bus, after its pending events
+	// have been handled.
+	r.Stop(ctx)
+
+	want := "handled,handled,handled,stopped"
+	if got := strings.Join(sub.logs, ","); got != want {
+		t.Fatalf("Logs: got (%s), want (%s)", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package eventapp_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RussellLuo/appx"
+	"github.com/RussellLuo/kun/pkg/appx/eventapp"
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+type Greeter struct {
+	greeting string
+	words    chan<- string
+	handler  eventpubsub.Handler
+}
+
+func newGreeter(greeting string, words chan<- string) *Greeter {
+	return &Greeter{greeting: greeting, words: words}
+}
+
+func (g *Greeter) Handler() eventpubsub.Handler {
+	return g.handler
+}
+
+func (g *Greeter) Init(ctx appx.Context) error {
+	g.handler = eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		g.words <- fmt.Sprintf("%s, %s", g.greeting, event.Data())
+		return nil
+	})
+	return nil
+}
+
+func Example() {
+	words := make(chan string, 2)
+	printWords := func() {
+		// The events are handled concurrently, so sort the words for a
+		// stable output.
+		var list []string
+		for i := 0; i < cap(words); i++ {
+			list = append(list, <-words)
+		}
+		sort.Strings(list)
+		for _, w := range list {
+			fmt.Println(w)
+		}
+	}
+
+	r := appx.NewRegistry()
+
+	// Typically located in `func init()` of package hi.
+	r.MustRegister(
+		eventapp.New("hi", newGreeter("Hi", words)).
+			SubscribedBy("bus", "user.created").App,
+	)
+
+	// Typically located in `func init()` of package bye.
+	r.MustRegister(
+		eventapp.New("bye", newGreeter("Bye", words)).
+			SubscribedBy("bus", "user.deleted").App,
+	)
+
+	// Typically located in `func init()` of package bus.
+	bus := eventapp.NewBus()
+	r.MustRegister(
+		eventapp.New("bus", bus).App,
+	)
+
+	// Typically located in `func main()` of package main.
+	r.SetOptions(&appx.Options{
+		ErrorHandler: func(err error) {
+			fmt.Printf("err: %v\n", err)
+		},
+	})
+
+	// Installs the applications.
+	if err := r.Install(context.Background()); err != nil {
+		fmt.Printf("err: %v\n", err)
+		return
+	}
+	defer r.Uninstall()
+
+	// Start the bus.
+	startCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.Start(startCtx); err != nil {
+		fmt.Printf("err: %v\n", err)
+		return
+	}
+
+	_ = bus.Bus().Publish(context.Background(), "user.created", "Tracey")
+	_ = bus.Bus().Publish(context.Background(), "user.deleted", "Tracey")
+
+	// Stop the bus, which waits for the published events to be handled.
+	stopCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r.Stop(stopCtx)
+
+	printWords()
+
+	// Output:
+	// Bye, Tracey
+	// Hi, Tracey
+}
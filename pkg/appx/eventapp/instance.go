@@ -0,0 +1,148 @@
+package eventapp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RussellLuo/appx"
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+// SubscribedBy subscribes the handler of the subscriber application to the
+// events whose types match topics, by using the broker application named
+// broker. The syntax of topics is broker-specific, and topics defaults to
+// "*" if not specified (which, for Bus, matches all the events whose types
+// contain no slash).
+//
+// The handler is subscribed when the application is started, and is
+// unsubscribed (with the pending events handled) before the application is
+// stopped, so it never receives events after being stopped.
+func SubscribedBy(broker string, topics ...string) func(appx.Standard) appx.Standard {
+	if len(topics) == 0 {
+		topics = []string{"*"}
+	}
+	return func(next appx.Standard) appx.Standard {
+		return &middleware{
+			Standard: next,
+			broker:   broker,
+			topics:   topics,
+		}
+	}
+}
+
+type middleware struct {
+	appx.Standard
+	broker string
+	topics []string
+
+	b    Broker
+	h    eventpubsub.Handler
+	subs []Subscription
+}
+
+func (m *middleware) Init(ctx appx.Context) error {
+	if err := m.Standard.Init(ctx); err != nil {
+		return err
+	}
+
+	broker, err := getEventBroker(ctx.MustLoad(m.broker))
+	if err != nil {
+		return err
+	}
+
+	handler, err := getEventHandler(m.Standard.Instance())
+	if err != nil {
+		return err
+	}
+
+	m.b, m.h = broker, handler
+	return nil
+}
+
+func (m *middleware) Start(ctx context.Context) error {
+	if err := m.Standard.Start(ctx); err != nil {
+		return err
+	}
+
+	for _, topic := range m.topics {
+		sub, err := m.b.Subscribe(topic, m.h)
+		if err != nil {
+			_ = m.unsubscribe(ctx)
+			return err
+		}
+		m.subs = append(m.subs, sub)
+	}
+	return nil
+}
+
+func (m *middleware) Stop(ctx context.Context) error {
+	// Unsubscribe first, so that the pending events are handled before the
+	// application is stopped.
+	err := m.unsubscribe(ctx)
+	if stopErr := m.Standard.Stop(ctx); stopErr != nil && err == nil {
+		err = stopErr
+	}
+	return err
+}
+
+func (m *middleware) unsubscribe(ctx context.Context) error {
+	var firstErr error
+	for _, sub := range m.subs {
+		if err := sub.Unsubscribe(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	m.subs = nil
+	return firstErr
+}
+
+// Broker represents an event broker.
+type Broker interface {
+	// Subscribe subscribes handler to the events whose types match topic.
+	Subscribe(topic string, handler eventpubsub.Handler) (Subscription, error)
+}
+
+// Subscription represents a subscription of a handler to a broker.
+type Subscription interface {
+	// Unsubscribe cancels the subscription, and then waits until the events
+	// pending for it have been handled or ctx is done.
+	Unsubscribe(ctx context.Context) error
+}
+
+// EventBroker is the interface that a broker application must implement.
+type EventBroker interface {
+	Broker() Broker
+}
+
+// EventHandler is the interface that a subscriber application must implement.
+type EventHandler interface {
+	Handler() eventpubsub.Handler
+}
+
+func getEventBroker(instance interface{}) (Broker, error) {
+	r, ok := instance.(EventBroker)
+	if !ok {
+		return nil, fmt.Errorf("instance %#v does not implement eventapp.EventBroker", instance)
+	}
+
+	result := r.Broker()
+	if result == nil {
+		return nil, fmt.Errorf("method Broker() of instance %#v returns nil", instance)
+	}
+
+	return result, nil
+}
+
+func getEventHandler(instance interface{}) (eventpubsub.Handler, error) {
+	r, ok := instance.(EventHandler)
+	if !ok {
+		return nil, fmt.Errorf("instance %#v does not implement eventapp.EventHandler", instance)
+	}
+
+	result := r.Handler()
+	if result == nil {
+		return nil, fmt.Errorf("method Handler() of instance %#v returns nil", instance)
+	}
+
+	return result, nil
+}
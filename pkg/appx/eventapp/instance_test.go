@@ -0,0 +1,101 @@
+package eventapp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+type broker struct{}
+
+func (b *broker) Subscribe(topic string, handler eventpubsub.Handler) (Subscription, error) {
+	return nil, nil
+}
+
+type eventBroker struct {
+	broker Broker
+}
+
+func (eb *eventBroker) Broker() Broker {
+	return eb.broker
+}
+
+type eventHandler struct {
+	handler eventpubsub.Handler
+}
+
+func (eh *eventHandler) Handler() eventpubsub.Handler {
+	return eh.handler
+}
+
+func TestGetEventBroker(t *testing.T) {
+	nopBroker := &broker{}
+
+	cases := []struct {
+		in         interface{}
+		wantBroker interface{}
+		wantErr    error
+	}{
+		{
+			in:         nil,
+			wantBroker: nil,
+			wantErr:    errors.New("instance <nil> does not implement eventapp.EventBroker"),
+		},
+		{
+			in:         &eventBroker{broker: nil},
+			wantBroker: nil,
+			wantErr:    errors.New("method Broker() of instance &eventapp.eventBroker{broker:eventapp.Broker(nil)} returns nil"),
+		},
+		{
+			in:         &eventBroker{broker: nopBroker},
+			wantBroker: nopBroker,
+			wantErr:    nil,
+		},
+	}
+	for _, c := range cases {
+		broker, err := getEventBroker(c.in)
+		if broker != c.wantBroker {
+			t.Fatalf("Broker: got (%#v), want (%#v)", broker, c.wantBroker)
+		}
+		if !reflect.DeepEqual(err, c.wantErr) {
+			t.Fatalf("Error: got (%#v), want (%#v)", err, c.wantErr)
+		}
+	}
+}
+
+func TestGetEventHandler(t *testing.T) {
+	nopHandler := eventpubsub.NewHandlerSet()
+
+	cases := []struct {
+		in          interface{}
+		wantHandler interface{}
+		wantErr     error
+	}{
+		{
+			in:          nil,
+			wantHandler: nil,
+			wantErr:     errors.New("instance <nil> does not implement eventapp.EventHandler"),
+		},
+		{
+			in:          &eventHandler{handler: nil},
+			wantHandler: nil,
+			wantErr:     errors.New("method Handler() of instance &eventapp.eventHandler{handler:eventpubsub.Handler(nil)} returns nil"),
+		},
+		{
+			in:          &eventHandler{handler: nopHandler},
+			wantHandler: nopHandler,
+			wantErr:     nil,
+		},
+	}
+	for _, c := range cases {
+		handler, err := getEventHandler(c.in)
+		if handler != c.wantHandler {
+			t.Fatalf("Handler: got (%#v), want (%#v)", handler, c.wantHandler)
+		}
+		if !reflect.DeepEqual(err, c.wantErr) {
+			t.Fatalf("Error: got (%#v), want (%#v)", err, c.wantErr)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package grpcapp_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/RussellLuo/appx"
+	"github.com/RussellLuo/kun/pkg/appx/grpcapp"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type Greeter struct{}
+
+func (g *Greeter) Register(r grpc.ServiceRegistrar) {
+	// Typically, call the function generated by protoc instead:
+	//
+	//     pb.RegisterGreeterServer(r, NewGRPCServer(svc, codecs))
+	r.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "greeter.Greeter",
+		HandlerType: (*interface{})(nil),
+	}, g)
+}
+
+func Example() {
+	r := appx.NewRegistry()
+
+	// Typically located in `func init()` of package greeter.
+	r.MustRegister(grpcapp.New("greeter", new(Greeter)).RegisterOn("server").App)
+
+	// Typically located in `func init()` of package server.
+	server := grpcapp.NewServer("127.0.0.1:0")
+	r.MustRegister(grpcapp.New("server", server).App)
+
+	// Typically located in `func main()` of package main.
+	r.SetOptions(&appx.Options{
+		ErrorHandler: func(err error) {
+			fmt.Printf("err: %v\n", err)
+		},
+	})
+
+	// Installs the applications.
+	if err := r.Install(context.Background()); err != nil {
+		fmt.Printf("err: %v\n", err)
+		return
+	}
+	defer r.Uninstall()
+
+	// Start the server.
+	startCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.Start(startCtx); err != nil {
+		fmt.Printf("err: %v\n", err)
+		return
+	}
+
+	// Check the health of the greeter service to demonstrate that our server is running.
+	conn, err := grpc.Dial(server.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		fmt.Printf("err: %v\n", err)
+		return
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{
+		Service: "greeter.Greeter",
+	})
+	if err != nil {
+		fmt.Printf("err: %v\n", err)
+		return
+	}
+	fmt.Println(resp.Status)
+
+	// Stop the server.
+	stopCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r.Stop(stopCtx)
+
+	// Output:
+	// SERVING
+}
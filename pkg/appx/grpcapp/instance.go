@@ -0,0 +1,81 @@
+package grpcapp
+
+import (
+	"fmt"
+
+	"github.com/RussellLuo/appx"
+	"google.golang.org/grpc"
+)
+
+// RegisterOn returns a middleware, which registers the gRPC services of an
+// application on the gRPC server of the application named server.
+func RegisterOn(server string) func(appx.Standard) appx.Standard {
+	return func(next appx.Standard) appx.Standard {
+		return middleware{
+			Standard: next,
+			server:   server,
+		}
+	}
+}
+
+type middleware struct {
+	appx.Standard
+	server string
+}
+
+func (m middleware) Init(ctx appx.Context) error {
+	if err := m.Standard.Init(ctx); err != nil {
+		return err
+	}
+
+	server, err := getGRPCServer(ctx.MustLoad(m.server))
+	if err != nil {
+		return err
+	}
+
+	service, err := getGRPCService(m.Standard.Instance())
+	if err != nil {
+		return err
+	}
+
+	service.Register(server)
+	return nil
+}
+
+// GRPCServer is the interface that a server application must implement.
+type GRPCServer interface {
+	Server() *grpc.Server
+}
+
+// GRPCService is the interface that a service application must implement.
+//
+// Typically, Register calls the registration function generated by protoc:
+//
+//	func (s *Service) Register(r grpc.ServiceRegistrar) {
+//		pb.RegisterServiceServer(r, NewGRPCServer(s.svc, s.codecs))
+//	}
+type GRPCService interface {
+	Register(r grpc.ServiceRegistrar)
+}
+
+func getGRPCServer(instance interface{}) (*grpc.Server, error) {
+	s, ok := instance.(GRPCServer)
+	if !ok {
+		return nil, fmt.Errorf("instance %#v does not implement grpcapp.GRPCServer", instance)
+	}
+
+	result := s.Server()
+	if result == nil {
+		return nil, fmt.Errorf("method Server() of instance %#v returns nil", instance)
+	}
+
+	return result, nil
+}
+
+func getGRPCService(instance interface{}) (GRPCService, error) {
+	s, ok := instance.(GRPCService)
+	if !ok {
+		return nil, fmt.Errorf("instance %#v does not implement grpcapp.GRPCService", instance)
+	}
+	return s, nil
+}
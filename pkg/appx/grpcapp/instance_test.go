@@ -0,0 +1,52 @@
+package grpcapp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+type server struct {
+	server *grpc.Server
+}
+
+func (s *server) Server() *grpc.Server {
+	return s.server
+}
+
+func TestGetGRPCServer(t *testing.T) {
+	grpcServer := grpc.NewServer()
+
+	cases := []struct {
+		in         interface{}
+		wantServer *grpc.Server
+		wantErr    error
+	}{
+		{
+			in:         nil,
+			wantServer: nil,
+			wantErr:    errors.New("instance <nil> does not implement grpcapp.GRPCServer"),
+		},
+		{
+			in:         &server{server: nil},
+			wantServer: nil,
+			wantErr:    errors.New("method Server() of instance &grpcapp.server{server:(*grpc.Server)(nil)} returns nil"),
+		},
+		{
+			in:         &server{server: grpcServer},
+			wantServer: grpcServer,
+			wantErr:    nil,
+		},
+	}
+	for _, c := range cases {
+		s, err := getGRPCServer(c.in)
+		if s != c.wantServer {
+			t.Fatalf("Server: got (%#v), want (%#v)", s, c.wantServer)
+		}
+		if !reflect.DeepEqual(err, c.wantErr) {
+			t.Fatalf("Error: got (%#v), want (%#v)", err, c.wantErr)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package grpcapp
+
+import (
+	"context"
+	"net"
+
+	"github.com/RussellLuo/appx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server is a gRPC server application, on which the gRPC services of other
+// applications can be registered (see RegisterOn).
+//
+// Besides the registered services, Server also serves the standard health
+// checking service and the server reflection service. The health status of
+// the server (and of all the registered services) is SERVING only after the
+// server is started, and becomes NOT_SERVING once the server is stopping.
+type Server struct {
+	addr string
+	opts []grpc.ServerOption
+
+	server *grpc.Server
+	health *health.Server
+	lis    net.Listener
+}
+
+// NewServer creates a gRPC server application, which will listen on the TCP
+// network address addr.
+func NewServer(addr string, opts ...grpc.ServerOption) *Server {
+	return &Server{
+		addr: addr,
+		opts: opts,
+	}
+}
+
+func (s *Server) Init(appx.Context) error {
+	s.server = grpc.NewServer(s.opts...)
+
+	s.health = health.NewServer()
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(s.server, s.health)
+
+	reflection.Register(s.server)
+
+	return nil
+}
+
+func (s *Server) Server() *grpc.Server {
+	return s.server
+}
+
+// Addr returns the network address the server is listening on. It is
+// typically used when the server is listening on a random port (e.g. ":0").
+func (s *Server) Addr() net.Addr {
+	if s.lis == nil {
+		return nil
+	}
+	return s.lis.Addr()
+}
+
+func (s *Server) Start(context.Context) error {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.lis = lis
+
+	go s.server.Serve(lis) // nolint:errcheck
+
+	// All the services are ready, since the server is started after all
+	// the applications depending on it have been initialized.
+	for name := range s.server.GetServiceInfo() {
+		s.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+
+	return nil
+}
+
+// Stop stops the server gracefully. If ctx is done before all the pending
+// RPCs are finished, the server will be stopped immediately.
+func (s *Server) Stop(ctx context.Context) error {
+	// Set all the serving status to NOT_SERVING, to notify the clients
+	// (and the load balancers) before closing the connections.
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
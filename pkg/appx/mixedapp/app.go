@@ -3,6 +3,8 @@ package mixedapp
 import (
 	"github.com/RussellLuo/appx"
 	"github.com/RussellLuo/kun/pkg/appx/cronapp2"
+	"github.com/RussellLuo/kun/pkg/appx/eventapp"
+	"github.com/RussellLuo/kun/pkg/appx/grpcapp"
 	"github.com/RussellLuo/kun/pkg/appx/httpapp"
 )
 
@@ -20,12 +22,24 @@ func (a *App) MountOn(parent, pattern string) *App {
 	return a
 }
 
+func (a *App) RegisterOn(server string) *App {
+	a.App.Use(grpcapp.RegisterOn(server))
+	a.App.Require(server)
+	return a
+}
+
 func (a *App) ScheduledBy(scheduler string) *App {
 	a.App.Use(cronapp2.ScheduledBy(scheduler))
 	a.App.Require(scheduler)
 	return a
 }
 
+func (a *App) SubscribedBy(broker string, topics ...string) *App {
+	a.App.Use(eventapp.SubscribedBy(broker, topics...))
+	a.App.Require(broker)
+	return a
+}
+
 func (a *App) Use(middlewares ...func(appx.Standard) appx.Standard) *App {
 	a.App.Use(middlewares...)
 	return a
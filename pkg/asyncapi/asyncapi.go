@@ -0,0 +1,82 @@
+// Package asyncapi provides helpers for generating and serving the AsyncAPI
+// documents of event-driven services.
+package asyncapi
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/RussellLuo/kun/pkg/oas2"
+	"sigs.k8s.io/yaml"
+)
+
+// Version is the version of the AsyncAPI specification, to which the
+// generated documents conform.
+const Version = "2.6.0"
+
+// DocFunc returns an AsyncAPI document in YAML.
+type DocFunc func() string
+
+// GenSchemas generates the schemas, by reusing the schema parser of OAS2,
+// from the given definitions. The result is intended to be embedded in the
+// components object of an AsyncAPI document.
+func GenSchemas(defs map[string]oas2.Definition) string {
+	if len(defs) == 0 {
+		return ""
+	}
+
+	definitions := oas2.GenDefinitions(defs)
+	definitions = strings.Replace(definitions, "\ndefinitions:", "\nschemas:", 1)
+	definitions = strings.ReplaceAll(definitions, "#/definitions/", "#/components/schemas/")
+
+	// Indent the schemas to be nested within the components object.
+	lines := strings.Split(definitions, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "  " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Handler serves the AsyncAPI document returned by docFn. The document is
+// in YAML by default, and in JSON if requested by either the header
+// `Accept: application/json` or the query `?accept=json`.
+func Handler(docFn DocFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		yamlContent := []byte(docFn())
+
+		if r.Header.Get("Accept") == "application/json" || r.URL.Query().Get("accept") == "json" {
+			jsonContent, err := yaml.YAMLToJSON(yamlContent)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = w.Write(jsonContent)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/yaml; charset=utf-8")
+		_, _ = w.Write(yamlContent)
+	}
+}
+
+// WriteFile writes the AsyncAPI document returned by docFn to the named
+// file. The document is written in JSON if the file has the extension
+// ".json", or in YAML otherwise.
+func WriteFile(filename string, docFn DocFunc) error {
+	content := []byte(docFn())
+
+	if filepath.Ext(filename) == ".json" {
+		var err error
+		if content, err = yaml.YAMLToJSON(content); err != nil {
+			return err
+		}
+	}
+
+	return os.WriteFile(filename, content, 0644)
+}
@@ -0,0 +1,164 @@
+package asyncapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/RussellLuo/kun/pkg/oas2"
+)
+
+func TestGenSchemas(t *testing.T) {
+	type Profile struct {
+		Age int `json:"age"`
+	}
+	type User struct {
+		Name    string   `json:"name"`
+		Profile *Profile `json:"profile"`
+	}
+
+	cases := []struct {
+		name    string
+		defs    map[string]oas2.Definition
+		wantOut string
+	}{
+		{
+			name:    "no definitions",
+			defs:    nil,
+			wantOut: "",
+		},
+		{
+			name: "nested definitions",
+			defs: func() map[string]oas2.Definition {
+				defs := make(map[string]oas2.Definition)
+				oas2.AddDefinition(defs, "UserPayload", reflect.ValueOf(&User{}))
+				return defs
+			}(),
+			wantOut: `
+  schemas:
+    Profile:
+      type: object
+      properties:
+        age:
+          type: integer
+          format: int64
+    UserPayload:
+      type: object
+      properties:
+        name:
+          type: string
+        profile:
+          $ref: "#/components/schemas/Profile"
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out := GenSchemas(c.defs)
+			if out != c.wantOut {
+				t.Fatalf("Out: got (%q), want (%q)", out, c.wantOut)
+			}
+		})
+	}
+}
+
+const testDoc = `asyncapi: "2.6.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+channels:
+  "created":
+    publish:
+      operationId: "Created"
+`
+
+func TestHandler(t *testing.T) {
+	cases := []struct {
+		name            string
+		target          string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "yaml",
+			target:          "/asyncapi",
+			wantContentType: "text/yaml; charset=utf-8",
+			wantBody:        testDoc,
+		},
+		{
+			name:            "json by header",
+			target:          "/asyncapi",
+			accept:          "application/json",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `{"asyncapi":"2.6.0","channels":{"created":{"publish":{"operationId":"Created"}}},"info":{"title":"Test","version":"1.0.0"}}`,
+		},
+		{
+			name:            "json by query",
+			target:          "/asyncapi?accept=json",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `{"asyncapi":"2.6.0","channels":{"created":{"publish":{"operationId":"Created"}}},"info":{"title":"Test","version":"1.0.0"}}`,
+		},
+	}
+
+	handler := Handler(func() string { return testDoc })
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.target, nil)
+			if c.accept != "" {
+				r.Header.Set("Accept", c.accept)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if contentType := w.Header().Get("Content-Type"); contentType != c.wantContentType {
+				t.Fatalf("Content-Type: got (%q), want (%q)", contentType, c.wantContentType)
+			}
+			if body := w.Body.String(); body != c.wantBody {
+				t.Fatalf("Body: got (%q), want (%q)", body, c.wantBody)
+			}
+		})
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+
+	cases := []struct {
+		name        string
+		filename    string
+		wantContent string
+	}{
+		{
+			name:        "yaml",
+			filename:    filepath.Join(dir, "asyncapi.yaml"),
+			wantContent: testDoc,
+		},
+		{
+			name:        "json",
+			filename:    filepath.Join(dir, "asyncapi.json"),
+			wantContent: `{"asyncapi":"2.6.0","channels":{"created":{"publish":{"operationId":"Created"}}},"info":{"title":"Test","version":"1.0.0"}}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := WriteFile(c.filename, func() string { return testDoc }); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			content, err := os.ReadFile(c.filename)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if string(content) != c.wantContent {
+				t.Fatalf("Content: got (%q), want (%q)", content, c.wantContent)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/RussellLuo/micron"
+)
+
+// HistoryStore stores the runs of jobs.
+type HistoryStore interface {
+	// Add adds a run.
+	Add(ctx context.Context, run *Run) error
+	// List returns at most limit runs of the job named job, from the most
+	// recent to the least recent. All runs are returned if limit <= 0.
+	List(ctx context.Context, job string, limit int) ([]*Run, error)
+}
+
+// Observer observes the runs of jobs, typically for metrics.
+type Observer interface {
+	ObserveRun(run *Run)
+}
+
+type RecorderOptions struct {
+	history   HistoryStore
+	observers []Observer
+	clock     Clock
+	errorFunc func(error)
+}
+
+// RecorderOption sets an optional parameter for RecorderOptions.
+type RecorderOption func(*RecorderOptions)
+
+// History sets the store of the runs. Defaults to no store.
+func History(s HistoryStore) RecorderOption {
+	return func(o *RecorderOptions) {
+		o.history = s
+	}
+}
+
+// Observers sets the observers of the runs. Defaults to no observers.
+func Observers(observers ...Observer) RecorderOption {
+	return func(o *RecorderOptions) {
+		o.observers = observers
+	}
+}
+
+// RecorderClock sets the clock used for timing the runs. Defaults to the
+// system clock.
+func RecorderClock(c Clock) RecorderOption {
+	return func(o *RecorderOptions) {
+		o.clock = c
+	}
+}
+
+// HistoryErrorHandler sets the function to handle the errors occurred when
+// adding runs into the history store. Defaults to ignoring the errors.
+func HistoryErrorHandler(f func(error)) RecorderOption {
+	return func(o *RecorderOptions) {
+		o.errorFunc = f
+	}
+}
+
+// Record wraps job to record each of its runs, including the start time,
+// the finish time, the outcome and the error (if any).
+//
+// To record the runs skipped by the overlap policy, Record must wrap the
+// job returned by Wrap (e.g. the generated jobs), instead of the other way
+// round.
+func Record(job micron.Job, opts ...RecorderOption) micron.Job {
+	options := &RecorderOptions{
+		clock:     realClock{},
+		errorFunc: func(error) {},
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	name := job.Name
+	handler := handlerOf(job)
+
+	job.Task = nil
+	job.Handler = func(ctx context.Context) error {
+		run := &Run{
+			Job:       name,
+			Manual:    isManual(ctx),
+			StartedAt: options.clock.Now(),
+		}
+
+		err := handler(ctx)
+
+		run.FinishedAt = options.clock.Now()
+		run.Outcome, run.Error = outcomeOf(err)
+
+		if options.history != nil {
+			if err := options.history.Add(ctx, run); err != nil {
+				options.errorFunc(err)
+			}
+		}
+		for _, o := range options.observers {
+			o.ObserveRun(run)
+		}
+
+		return err
+	}
+
+	return job
+}
+
+// RecordAll is a shortcut for wrapping each of jobs by Record.
+func RecordAll(jobs []micron.Job, opts ...RecorderOption) []micron.Job {
+	result := make([]micron.Job, len(jobs))
+	for i, job := range jobs {
+		result[i] = Record(job, opts...)
+	}
+	return result
+}
+
+// Duration returns the duration of the run.
+func (r *Run) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+func outcomeOf(err error) (outcome, errMsg string) {
+	switch {
+	case err == nil:
+		return OutcomeSuccess, ""
+	case errors.Is(err, ErrSkipped):
+		return OutcomeSkipped, err.Error()
+	default:
+		return OutcomeFailure, err.Error()
+	}
+}
+
+type contextKeyManual struct{}
+
+// withManual returns a copy of ctx, which indicates that the run is
+// triggered manually.
+func withManual(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKeyManual{}, true)
+}
+
+func isManual(ctx context.Context) bool {
+	manual, _ := ctx.Value(contextKeyManual{}).(bool)
+	return manual
+}
+
+// MemoryHistory is an in-memory history store, which keeps at most a fixed
+// number of the most recent runs for each job.
+type MemoryHistory struct {
+	capacity int
+
+	mu   sync.Mutex
+	runs map[string][]*Run // job -> runs, from the least recent to the most recent
+}
+
+// NewMemoryHistory creates an in-memory history store, which keeps at most
+// capacity runs for each job.
+func NewMemoryHistory(capacity int) *MemoryHistory {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &MemoryHistory{
+		capacity: capacity,
+		runs:     make(map[string][]*Run),
+	}
+}
+
+// Add implements HistoryStore.
+func (h *MemoryHistory) Add(ctx context.Context, run *Run) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	runs := h.runs[run.Job]
+	if len(runs) < h.capacity {
+		runs = append(runs, run)
+	} else {
+		// Drop the least recent run.
+		copy(runs, runs[1:])
+		runs[len(runs)-1] = run
+	}
+	h.runs[run.Job] = runs
+
+	return nil
+}
+
+// List implements HistoryStore.
+func (h *MemoryHistory) List(ctx context.Context, job string, limit int) ([]*Run, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	runs := h.runs[job]
+	if limit <= 0 || limit > len(runs) {
+		limit = len(runs)
+	}
+
+	result := make([]*Run, 0, limit)
+	for i := len(runs) - 1; i >= len(runs)-limit; i-- {
+		r := *runs[i]
+		result = append(result, &r)
+	}
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package cronjob_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/micron"
+)
+
+type observer struct {
+	runs []*cronjob.Run
+}
+
+func (o *observer) ObserveRun(run *cronjob.Run) {
+	o.runs = append(o.runs, run)
+}
+
+func TestRecord(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	history := cronjob.NewMemoryHistory(2)
+	obs := new(observer)
+
+	var errs []error
+	job := cronjob.Record(micron.Job{
+		Name: "job",
+		Expr: "@every 1m",
+		Handler: func(ctx context.Context) error {
+			clock.Advance(time.Second)
+			err := errs[0]
+			errs = errs[1:]
+			return err
+		},
+	}, cronjob.History(history), cronjob.Observers(obs), cronjob.RecorderClock(clock))
+
+	errs = []error{nil, errors.New("oops"), cronjob.ErrSkipped}
+	for range errs {
+		_ = job.Handler(context.Background())
+	}
+
+	wantRuns := []*cronjob.Run{
+		{
+			Job:        "job",
+			StartedAt:  start.Add(2 * time.Second),
+			FinishedAt: start.Add(3 * time.Second),
+			Outcome:    cronjob.OutcomeSkipped,
+			Error:      cronjob.ErrSkipped.Error(),
+		},
+		{
+			Job:        "job",
+			StartedAt:  start.Add(time.Second),
+			FinishedAt: start.Add(2 * time.Second),
+			Outcome:    cronjob.OutcomeFailure,
+			Error:      "oops",
+		},
+	}
+
+	// The history keeps the most recent two runs.
+	runs, err := history.List(context.Background(), "job", 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(runs, wantRuns) {
+		t.Fatalf("Runs: got (%+v), want (%+v)", runs, wantRuns)
+	}
+
+	runs, _ = history.List(context.Background(), "job", 1)
+	if !reflect.DeepEqual(runs, wantRuns[:1]) {
+		t.Fatalf("Runs: got (%+v), want (%+v)", runs, wantRuns[:1])
+	}
+
+	// The observers observe all the runs.
+	if len(obs.runs) != 3 || obs.runs[0].Outcome != cronjob.OutcomeSuccess || obs.runs[0].Duration() != time.Second {
+		t.Fatalf("Observed runs: got (%+v)", obs.runs)
+	}
+}
@@ -0,0 +1,164 @@
+// Package cronjob provides a runtime for cron jobs, which enforces per-job
+// policies (timezone, jitter and overlap) on top of micron jobs.
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/werror"
+	"github.com/RussellLuo/micron"
+)
+
+var (
+	ErrSkipped = errors.New("cronjob: skipped since the previous run is still going")
+)
+
+// OverlapPolicy determines what happens when a job is triggered while its
+// previous run is still going.
+type OverlapPolicy string
+
+const (
+	// OverlapAllow runs the job concurrently with the previous run.
+	OverlapAllow OverlapPolicy = "allow"
+	// OverlapSkip skips the run, which fails with an error wrapping ErrSkipped.
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapQueue runs the job after the previous run finishes.
+	OverlapQueue OverlapPolicy = "queue"
+)
+
+// Clock provides the current time and timers. It can be replaced by a fake
+// clock in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+type Options struct {
+	timezone string
+	jitter   time.Duration
+	overlap  OverlapPolicy
+	clock    Clock
+}
+
+// Option sets an optional parameter for Options.
+type Option func(*Options)
+
+// Timezone sets the timezone (e.g. "Asia/Shanghai"), in which the cron
+// expression of the job is interpreted. Defaults to the timezone of the
+// scheduler.
+//
+// Note that the timezone is carried by the cron expression (see TimezonePrefix),
+// which is only supported by Scheduler. Adding such a job into micron.Cron
+// directly will panic, use CheckMicron to detect it beforehand (which is done
+// by cronapp.ScheduledBy and cronapp2.ScheduledBy).
+func Timezone(name string) Option {
+	return func(o *Options) {
+		o.timezone = name
+	}
+}
+
+// Jitter sets the maximum random delay before each run of the job, to spread
+// the load of the jobs scheduled at the same time. Defaults to no jitter.
+//
+// The runs triggered manually (see Scheduler.Trigger) are not delayed.
+func Jitter(d time.Duration) Option {
+	return func(o *Options) {
+		o.jitter = d
+	}
+}
+
+// Overlap sets the policy when the job is triggered while its previous run
+// is still going. Defaults to OverlapAllow.
+func Overlap(p OverlapPolicy) Option {
+	return func(o *Options) {
+		o.overlap = p
+	}
+}
+
+// UseClock sets the clock used for jitter. Defaults to the system clock.
+func UseClock(c Clock) Option {
+	return func(o *Options) {
+		o.clock = c
+	}
+}
+
+// Wrap wraps job to enforce the policies specified by opts.
+func Wrap(job micron.Job, opts ...Option) micron.Job {
+	options := &Options{
+		overlap: OverlapAllow,
+		clock:   realClock{},
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	if options.timezone != "" {
+		job.Expr = TimezonePrefix + options.timezone + " " + job.Expr
+	}
+
+	w := &wrapper{
+		name:    job.Name,
+		handler: handlerOf(job),
+		opts:    options,
+	}
+	job.Task = nil
+	job.Handler = w.Handle
+
+	return job
+}
+
+// handlerOf returns the handler of job, which may be specified by either
+// Handler or Task.
+func handlerOf(job micron.Job) func(context.Context) error {
+	if job.Handler != nil {
+		return job.Handler
+	}
+	task := job.Task
+	return func(context.Context) error {
+		task()
+		return nil
+	}
+}
+
+type wrapper struct {
+	name    string
+	handler func(context.Context) error
+	opts    *Options
+
+	running int32 // for OverlapSkip
+	mu      sync.Mutex
+}
+
+func (w *wrapper) Handle(ctx context.Context) error {
+	if w.opts.jitter > 0 && !isManual(ctx) {
+		d := time.Duration(rand.Int63n(int64(w.opts.jitter)))
+		select {
+		case <-w.opts.clock.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	switch w.opts.overlap {
+	case OverlapSkip:
+		if !atomic.CompareAndSwapInt32(&w.running, 0, 1) {
+			return werror.Wrapf(ErrSkipped, "cronjob: skipped job %s since the previous run is still going", w.name)
+		}
+		defer atomic.StoreInt32(&w.running, 0)
+	case OverlapQueue:
+		w.mu.Lock()
+		defer w.mu.Unlock()
+	}
+
+	return w.handler(ctx)
+}
@@ -0,0 +1,189 @@
+package cronjob_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/micron"
+)
+
+// fakeClock is a clock, whose time only moves forward by Advance.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+type waiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, waiter{at: c.now.Add(d), c: ch})
+	return ch
+}
+
+// Advance moves the time forward by d, and fires the timers due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	var pending []waiter
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.c <- c.now
+	}
+	c.waiters = pending
+}
+
+// BlockUntil blocks until there are n timers waiting.
+func (c *fakeClock) BlockUntil(t *testing.T, n int) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		got := len(c.waiters)
+		c.mu.Unlock()
+		if got >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d timers", n)
+}
+
+func TestWrap_Overlap(t *testing.T) {
+	cases := []struct {
+		name           string
+		inPolicy       cronjob.OverlapPolicy
+		wantConcurrent bool
+		wantErr        error // The error of the second run.
+	}{
+		{
+			name:           "allow",
+			inPolicy:       cronjob.OverlapAllow,
+			wantConcurrent: true,
+		},
+		{
+			name:     "skip",
+			inPolicy: cronjob.OverlapSkip,
+			wantErr:  cronjob.ErrSkipped,
+		},
+		{
+			name:     "queue",
+			inPolicy: cronjob.OverlapQueue,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var running, maxRunning, runs int32
+			release := make(chan struct{})
+			job := cronjob.Wrap(micron.Job{
+				Name: "job",
+				Expr: "@every 1s",
+				Handler: func(ctx context.Context) error {
+					n := atomic.AddInt32(&running, 1)
+					defer atomic.AddInt32(&running, -1)
+					if n > atomic.LoadInt32(&maxRunning) {
+						atomic.StoreInt32(&maxRunning, n)
+					}
+					atomic.AddInt32(&runs, 1)
+					<-release
+					return nil
+				},
+			}, cronjob.Overlap(c.inPolicy))
+
+			// The first run, which will block until released.
+			firstDone := make(chan error, 1)
+			go func() { firstDone <- job.Handler(context.Background()) }()
+			for atomic.LoadInt32(&runs) == 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			// The second run, which is triggered while the first one is still going.
+			secondDone := make(chan error, 1)
+			go func() { secondDone <- job.Handler(context.Background()) }()
+			time.Sleep(20 * time.Millisecond)
+
+			close(release)
+			if err := <-firstDone; err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if err := <-secondDone; !errors.Is(err, c.wantErr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, c.wantErr)
+			}
+
+			wantRuns := int32(2)
+			if c.wantErr != nil {
+				wantRuns = 1
+			}
+			if runs != wantRuns {
+				t.Fatalf("Runs: got (%d), want (%d)", runs, wantRuns)
+			}
+			if concurrent := maxRunning > 1; concurrent != c.wantConcurrent {
+				t.Fatalf("Concurrent: got (%v), want (%v)", concurrent, c.wantConcurrent)
+			}
+		})
+	}
+}
+
+func TestWrap_Jitter(t *testing.T) {
+	clock := newFakeClock(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	var runs int32
+	job := cronjob.Wrap(micron.Job{
+		Name: "job",
+		Expr: "@every 1s",
+		Task: func() { atomic.AddInt32(&runs, 1) },
+	}, cronjob.Jitter(time.Minute), cronjob.UseClock(clock))
+
+	done := make(chan error, 1)
+	go func() { done <- job.Handler(context.Background()) }()
+
+	clock.BlockUntil(t, 1)
+	if n := atomic.LoadInt32(&runs); n != 0 {
+		t.Fatalf("Runs before jitter: got (%d), want (0)", n)
+	}
+
+	clock.Advance(time.Minute)
+	if err := <-done; err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Fatalf("Runs after jitter: got (%d), want (1)", n)
+	}
+}
+
+func TestWrap_Timezone(t *testing.T) {
+	job := cronjob.Wrap(micron.Job{Name: "job", Expr: "0 0 9 * * * *"}, cronjob.Timezone("Asia/Shanghai"))
+	if want := "CRON_TZ=Asia/Shanghai 0 0 9 * * * *"; job.Expr != want {
+		t.Fatalf("Expr: got (%q), want (%q)", job.Expr, want)
+	}
+}
@@ -0,0 +1,81 @@
+package cronjob
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RussellLuo/micron"
+)
+
+// TimezonePrefix is the prefix of a cron expression to specify the timezone,
+// in which the expression is interpreted (e.g. `CRON_TZ=Asia/Shanghai 0 0 9 * * * *`).
+const TimezonePrefix = "CRON_TZ="
+
+// Parse parses the cron expression expr into a schedule.
+//
+// Besides the three formats supported by micron, expr may be prefixed by
+// TimezonePrefix to specify the timezone of the schedule. Otherwise, the
+// schedule follows the timezone of the time passed to Next.
+func Parse(expr string) (micron.Schedule, error) {
+	loc, expr, err := splitTimezone(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := micron.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %v", expr, err)
+	}
+
+	if loc == nil {
+		return s, nil
+	}
+	return &tzSchedule{schedule: s, loc: loc}, nil
+}
+
+// CheckMicron checks whether the cron expressions of jobs are supported by
+// micron.Cron, which panics on unsupported expressions instead of returning
+// errors. In particular, the jobs with timezones (see Timezone) are only
+// supported by Scheduler.
+func CheckMicron(jobs ...micron.Job) error {
+	for _, j := range jobs {
+		if strings.HasPrefix(strings.TrimSpace(j.Expr), TimezonePrefix) {
+			return fmt.Errorf("cronjob: job %s has a timezone, which is not supported by micron.Cron (use cronjob.Scheduler instead)", j.Name)
+		}
+		if _, err := micron.Parse(j.Expr); err != nil {
+			return fmt.Errorf("cronjob: job %s has an invalid cron expression %q: %v", j.Name, j.Expr, err)
+		}
+	}
+	return nil
+}
+
+// splitTimezone splits the timezone (if any) from expr.
+func splitTimezone(expr string) (*time.Location, string, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, TimezonePrefix) {
+		return nil, expr, nil
+	}
+
+	fields := strings.SplitN(strings.TrimPrefix(expr, TimezonePrefix), " ", 2)
+	if len(fields) != 2 {
+		return nil, "", fmt.Errorf("missing cron expression after timezone in %q", expr)
+	}
+
+	loc, err := time.LoadLocation(fields[0])
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid timezone %q: %v", fields[0], err)
+	}
+
+	return loc, strings.TrimSpace(fields[1]), nil
+}
+
+type tzSchedule struct {
+	schedule micron.Schedule
+	loc      *time.Location
+}
+
+// Next implements micron.Schedule.
+func (s *tzSchedule) Next(t time.Time) time.Time {
+	return s.schedule.Next(t.In(s.loc))
+}
@@ -0,0 +1,113 @@
+package cronjob_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/micron"
+)
+
+func TestParse(t *testing.T) {
+	prev := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		inExpr     string
+		wantNext   time.Time
+		wantErrStr string
+	}{
+		{
+			name:     "every",
+			inExpr:   "@every 5s",
+			wantNext: prev.Add(5 * time.Second),
+		},
+		{
+			name:     "cron",
+			inExpr:   "0 0 9 * * * *",
+			wantNext: time.Date(2022, 1, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "cron with timezone",
+			inExpr:   "CRON_TZ=Asia/Shanghai 0 0 9 * * * *",
+			wantNext: time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "predefined with timezone",
+			inExpr:   "CRON_TZ=Asia/Shanghai @daily",
+			wantNext: time.Date(2022, 1, 1, 16, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "invalid timezone",
+			inExpr:     "CRON_TZ=Mars/Olympus 0 0 9 * * * *",
+			wantErrStr: `invalid timezone "Mars/Olympus": unknown time zone Mars/Olympus`,
+		},
+		{
+			name:       "missing expression",
+			inExpr:     "CRON_TZ=Asia/Shanghai",
+			wantErrStr: `missing cron expression after timezone in "CRON_TZ=Asia/Shanghai"`,
+		},
+		{
+			name:       "invalid duration",
+			inExpr:     "@every 5x",
+			wantErrStr: `invalid cron expression "@every 5x": time: unknown unit "x" in duration "5x"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := cronjob.Parse(tt.inExpr)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if err != nil {
+				return
+			}
+			if next := s.Next(prev); !next.Equal(tt.wantNext) {
+				t.Fatalf("Next: got (%v), want (%v)", next, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestCheckMicron(t *testing.T) {
+	tests := []struct {
+		name       string
+		inJob      micron.Job
+		wantErrStr string
+	}{
+		{
+			name:  "ok",
+			inJob: micron.Job{Name: "a", Expr: "0 0 9 * * * *", Task: func() {}},
+		},
+		{
+			name:       "timezone",
+			inJob:      cronjob.Wrap(micron.Job{Name: "b", Expr: "0 0 9 * * * *", Task: func() {}}, cronjob.Timezone("Asia/Shanghai")),
+			wantErrStr: "cronjob: job b has a timezone, which is not supported by micron.Cron (use cronjob.Scheduler instead)",
+		},
+		{
+			name:       "invalid expression",
+			inJob:      micron.Job{Name: "c", Expr: "0 0 25 * * * *", Task: func() {}},
+			wantErrStr: `cronjob: job c has an invalid cron expression "0 0 25 * * * *": `,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cronjob.CheckMicron(tt.inJob)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && !strings.HasPrefix(err.Error(), tt.wantErrStr)) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if err != nil {
+				return
+			}
+
+			// The checked jobs must be accepted by micron.Cron without panics.
+			c := micron.New(micron.NewNilLocker(), nil)
+			if err := c.AddJob(tt.inJob); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+		})
+	}
+}
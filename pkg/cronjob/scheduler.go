@@ -0,0 +1,363 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RussellLuo/micron"
+)
+
+var (
+	ErrJobNotFound = errors.New("cronjob: job not found")
+	ErrStopped     = errors.New("cronjob: scheduler stopped")
+)
+
+// The outcomes of a run.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeSkipped = "skipped" // See ErrSkipped.
+)
+
+// Run is the result of a run of a job.
+type Run struct {
+	Job        string    `json:"job"`
+	Manual     bool      `json:"manual"` // Whether the run is triggered manually.
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// JobInfo is the information of a job.
+type JobInfo struct {
+	Name    string    `json:"name"`
+	Expr    string    `json:"expr"`
+	Paused  bool      `json:"paused"`
+	NextRun time.Time `json:"next_run"`
+	LastRun *Run      `json:"last_run,omitempty"`
+}
+
+type SchedulerOptions struct {
+	timezone   string
+	lockTTL    time.Duration
+	errHandler func(error)
+	clock      Clock
+}
+
+// SchedulerOption sets an optional parameter for SchedulerOptions.
+type SchedulerOption func(*SchedulerOptions)
+
+// DefaultTimezone sets the timezone of the jobs, whose cron expressions have
+// no timezone specified. Defaults to "UTC".
+func DefaultTimezone(name string) SchedulerOption {
+	return func(o *SchedulerOptions) {
+		o.timezone = name
+	}
+}
+
+// LockTTL sets the TTL of the lock obtained for each run of a job, which is
+// typically the maximal clock error among the scheduler instances. Defaults
+// to 1s.
+func LockTTL(d time.Duration) SchedulerOption {
+	return func(o *SchedulerOptions) {
+		o.lockTTL = d
+	}
+}
+
+// ErrorHandler sets the function to handle the errors occurred when running
+// jobs. Defaults to ignoring the errors.
+func ErrorHandler(f func(error)) SchedulerOption {
+	return func(o *SchedulerOptions) {
+		o.errHandler = f
+	}
+}
+
+// SchedulerClock sets the clock used for scheduling. Defaults to the system
+// clock.
+func SchedulerClock(c Clock) SchedulerOption {
+	return func(o *SchedulerOptions) {
+		o.clock = c
+	}
+}
+
+type entry struct {
+	name     string
+	expr     string
+	schedule micron.Schedule
+	handler  func(context.Context) error
+
+	// The states below are protected by the mutex of the scheduler.
+	paused  bool
+	next    time.Time
+	lastRun *Run
+}
+
+// Scheduler is a cron scheduler, which works like micron.Cron, except that
+// each job may have its own timezone (see TimezonePrefix). It implements
+// cronapp.Scheduler.
+type Scheduler struct {
+	locker micron.Locker
+	opts   *SchedulerOptions
+	loc    *time.Location
+
+	mu      sync.Mutex
+	entries []*entry
+	stopped bool
+
+	exitC     chan struct{}
+	stopOnce  sync.Once
+	waitGroup sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler, which uses locker to ensure that each
+// run of a job is executed only once among the scheduler instances.
+func NewScheduler(locker micron.Locker, opts ...SchedulerOption) (*Scheduler, error) {
+	options := &SchedulerOptions{
+		timezone:   "UTC",
+		lockTTL:    time.Second,
+		errHandler: func(error) {},
+		clock:      realClock{},
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	loc, err := time.LoadLocation(options.timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scheduler{
+		locker: locker,
+		opts:   options,
+		loc:    loc,
+		exitC:  make(chan struct{}),
+	}, nil
+}
+
+// Add adds a job, which executes task as scheduled by expr.
+func (s *Scheduler) Add(name, expr string, task func()) error {
+	return s.AddJob(micron.Job{Name: name, Expr: expr, Task: task})
+}
+
+// AddJob adds one or more jobs. Nothing will be added if any of the jobs
+// is invalid or already exists.
+func (s *Scheduler) AddJob(job ...micron.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make(map[string]bool)
+	for _, e := range s.entries {
+		names[e.name] = true
+	}
+
+	var entries []*entry
+	for _, j := range job {
+		if names[j.Name] {
+			return fmt.Errorf("add job %s: %w", j.Name, micron.ErrAlreadyExists)
+		}
+		names[j.Name] = true
+
+		schedule, err := Parse(j.Expr)
+		if err != nil {
+			return fmt.Errorf("add job %s: %v", j.Name, err)
+		}
+
+		entries = append(entries, &entry{
+			name:     j.Name,
+			expr:     j.Expr,
+			schedule: schedule,
+			handler:  handlerOf(j),
+		})
+	}
+
+	s.entries = append(s.entries, entries...)
+	return nil
+}
+
+// Start starts scheduling the jobs added so far.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		s.waitGroup.Add(1)
+		go s.schedule(e)
+	}
+}
+
+// Stop stops scheduling the jobs, and waits for the runs already started to
+// finish (without interrupting them).
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		s.mu.Lock()
+		s.stopped = true
+		s.mu.Unlock()
+		close(s.exitC)
+	})
+	s.waitGroup.Wait()
+}
+
+func (s *Scheduler) schedule(e *entry) {
+	defer s.waitGroup.Done()
+
+	next := e.schedule.Next(s.opts.clock.Now().In(s.loc))
+	for {
+		s.mu.Lock()
+		e.next = next
+		s.mu.Unlock()
+
+		select {
+		case <-s.opts.clock.After(next.Sub(s.opts.clock.Now())):
+		case <-s.exitC:
+			return
+		}
+
+		next = e.schedule.Next(next)
+
+		s.mu.Lock()
+		paused := e.paused
+		s.mu.Unlock()
+		if paused {
+			continue
+		}
+
+		ok, err := s.locker.Lock(e.name, s.opts.lockTTL)
+		if err != nil {
+			s.opts.errHandler(err)
+		}
+		if ok {
+			s.waitGroup.Add(1)
+			go func() {
+				defer s.waitGroup.Done()
+				s.run(e, false)
+			}()
+		}
+	}
+}
+
+func (s *Scheduler) run(e *entry, manual bool) {
+	ctx := context.Background()
+	if manual {
+		ctx = withManual(ctx)
+	}
+
+	run := &Run{
+		Job:       e.name,
+		Manual:    manual,
+		StartedAt: s.opts.clock.Now(),
+	}
+
+	err := e.handler(ctx)
+
+	run.FinishedAt = s.opts.clock.Now()
+	run.Outcome, run.Error = outcomeOf(err)
+
+	s.mu.Lock()
+	e.lastRun = run
+	s.mu.Unlock()
+
+	if err != nil {
+		s.opts.errHandler(err)
+	}
+}
+
+// Jobs returns the information of all the jobs, in the order they are added.
+func (s *Scheduler) Jobs() []JobInfo {
+	now := s.opts.clock.Now().In(s.loc)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]JobInfo, 0, len(s.entries))
+	for _, e := range s.entries {
+		next := e.next
+		if next.IsZero() {
+			// Not started yet.
+			next = e.schedule.Next(now)
+		}
+
+		var lastRun *Run
+		if e.lastRun != nil {
+			r := *e.lastRun
+			lastRun = &r
+		}
+
+		jobs = append(jobs, JobInfo{
+			Name:    e.name,
+			Expr:    e.expr,
+			Paused:  e.paused,
+			NextRun: next,
+			LastRun: lastRun,
+		})
+	}
+	return jobs
+}
+
+// Trigger runs the job named name now, regardless of its schedule and
+// whether it is paused. Unlike the scheduled runs, the manual run does not
+// obtain the lock, and it is executed in the background.
+//
+// ErrStopped is returned if the scheduler has been stopped.
+func (s *Scheduler) Trigger(name string) error {
+	e, err := s.getEntry(name)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return ErrStopped
+	}
+	// Add the run while holding the mutex, so that Stop will wait for it.
+	s.waitGroup.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.waitGroup.Done()
+		s.run(e, true)
+	}()
+	return nil
+}
+
+// Pause pauses the job named name, whose scheduled runs will be skipped
+// until resumed.
+//
+// Note that the paused state is local to this scheduler instance.
+func (s *Scheduler) Pause(name string) error {
+	return s.setPaused(name, true)
+}
+
+// Resume resumes the job named name.
+func (s *Scheduler) Resume(name string) error {
+	return s.setPaused(name, false)
+}
+
+func (s *Scheduler) setPaused(name string, paused bool) error {
+	e, err := s.getEntry(name)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.paused = paused
+	return nil
+}
+
+func (s *Scheduler) getEntry(name string) (*entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		if e.name == name {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrJobNotFound, name)
+}
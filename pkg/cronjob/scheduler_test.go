@@ -0,0 +1,219 @@
+package cronjob_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/micron"
+)
+
+func TestScheduler(t *testing.T) {
+	clock := newFakeClock(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	s, err := cronjob.NewScheduler(micron.NewNilLocker(), cronjob.SchedulerClock(clock))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var mu sync.Mutex
+	var runs []string
+	runC := make(chan struct{}, 10)
+	task := func(name string) func() {
+		return func() {
+			mu.Lock()
+			runs = append(runs, name+"@"+clock.Now().Format("15:04"))
+			mu.Unlock()
+			runC <- struct{}{}
+		}
+	}
+
+	if err := s.AddJob(
+		micron.Job{Name: "utc", Expr: "0 0 9 * * * *", Task: task("utc")},
+		cronjob.Wrap(micron.Job{Name: "shanghai", Expr: "0 0 9 * * * *", Task: task("shanghai")}, cronjob.Timezone("Asia/Shanghai")),
+	); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	s.Start()
+	defer s.Stop()
+
+	// 09:00 in Asia/Shanghai is 01:00 in UTC.
+	clock.BlockUntil(t, 2)
+	clock.Advance(time.Hour)
+	<-runC
+
+	clock.BlockUntil(t, 2)
+	clock.Advance(8 * time.Hour)
+	<-runC
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"shanghai@01:00", "utc@09:00"}
+	if len(runs) != len(want) || runs[0] != want[0] || runs[1] != want[1] {
+		t.Fatalf("Runs: got (%v), want (%v)", runs, want)
+	}
+}
+
+func TestScheduler_TriggerWithoutJitter(t *testing.T) {
+	clock := newFakeClock(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	s, err := cronjob.NewScheduler(micron.NewNilLocker(), cronjob.SchedulerClock(clock))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	runC := make(chan struct{}, 1)
+	if err := s.AddJob(cronjob.Wrap(micron.Job{
+		Name: "job",
+		Expr: "@every 1m",
+		Task: func() { runC <- struct{}{} },
+	}, cronjob.Jitter(time.Hour), cronjob.UseClock(clock))); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer s.Stop()
+
+	// The clock is never advanced, thus the run would block if delayed.
+	if err := s.Trigger("job"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	select {
+	case <-runC:
+	case <-time.After(time.Second):
+		t.Fatal("triggered run has been delayed by jitter")
+	}
+}
+
+func TestScheduler_AddJob(t *testing.T) {
+	s, err := cronjob.NewScheduler(micron.NewNilLocker())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := s.Add("job", "@every 1s", func() {}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	err = s.AddJob(micron.Job{Name: "job", Expr: "@every 1s"})
+	if !errors.Is(err, micron.ErrAlreadyExists) {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, micron.ErrAlreadyExists)
+	}
+
+	err = s.AddJob(micron.Job{Name: "other", Expr: "@evry 1s"})
+	if err == nil || err.Error() != `add job other: invalid cron expression "@evry 1s": missing field(s)` {
+		t.Fatalf("Err: got (%#v)", err)
+	}
+}
+
+func TestScheduler_Stop(t *testing.T) {
+	clock := newFakeClock(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	s, err := cronjob.NewScheduler(micron.NewNilLocker(), cronjob.SchedulerClock(clock))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	startedC := make(chan struct{}, 1)
+	releaseC := make(chan struct{})
+	if err := s.Add("job", "@every 1m", func() {
+		startedC <- struct{}{}
+		<-releaseC
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	s.Start()
+	clock.BlockUntil(t, 1)
+	clock.Advance(time.Minute)
+	<-startedC
+
+	stoppedC := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stoppedC)
+	}()
+
+	select {
+	case <-stoppedC:
+		t.Fatal("Stop returned before the run finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(releaseC)
+	<-stoppedC
+
+	// Stopping again is a no-op.
+	s.Stop()
+}
+
+func TestScheduler_Manage(t *testing.T) {
+	clock := newFakeClock(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	s, err := cronjob.NewScheduler(micron.NewNilLocker(), cronjob.SchedulerClock(clock))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	runC := make(chan struct{}, 10)
+	if err := s.AddJob(micron.Job{
+		Name: "job",
+		Expr: "@every 1m",
+		Handler: func(ctx context.Context) error {
+			defer func() { runC <- struct{}{} }()
+			return errors.New("oops")
+		},
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	s.Start()
+	defer s.Stop()
+
+	// Paused jobs are not run as scheduled.
+	if err := s.Pause("job"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	clock.BlockUntil(t, 1)
+	clock.Advance(time.Minute)
+	clock.BlockUntil(t, 1)
+	select {
+	case <-runC:
+		t.Fatal("paused job has been run")
+	default:
+	}
+
+	jobs := s.Jobs()
+	if len(jobs) != 1 || !jobs[0].Paused || !jobs[0].NextRun.Equal(time.Date(2022, 1, 1, 0, 2, 0, 0, time.UTC)) {
+		t.Fatalf("Jobs: got (%#v)", jobs)
+	}
+
+	// Paused jobs can still be triggered manually.
+	if err := s.Trigger("job"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	<-runC
+	deadline := time.Now().Add(time.Second)
+	for s.Jobs()[0].LastRun == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	run := s.Jobs()[0].LastRun
+	if run == nil || !run.Manual || run.Outcome != cronjob.OutcomeFailure || run.Error != "oops" {
+		t.Fatalf("LastRun: got (%#v)", run)
+	}
+
+	// Resumed jobs are run as scheduled again.
+	if err := s.Resume("job"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	clock.Advance(time.Minute)
+	<-runC
+
+	if err := s.Pause("unknown"); !errors.Is(err, cronjob.ErrJobNotFound) {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, cronjob.ErrJobNotFound)
+	}
+
+	// Stopped schedulers can not be triggered any more.
+	s.Stop()
+	if err := s.Trigger("job"); !errors.Is(err, cronjob.ErrStopped) {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, cronjob.ErrStopped)
+	}
+}
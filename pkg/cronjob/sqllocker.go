@@ -0,0 +1,135 @@
+package cronjob
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/sqlutil"
+)
+
+type SQLLockerOptions struct {
+	table       string
+	placeholder sqlutil.Placeholder
+	owner       string
+	clock       Clock
+}
+
+// SQLLockerOption sets an optional parameter for SQLLockerOptions.
+type SQLLockerOption func(*SQLLockerOptions)
+
+// LockTable sets the name of the table, in which the leases are stored.
+// Defaults to "cron_locks".
+func LockTable(name string) SQLLockerOption {
+	return func(o *SQLLockerOptions) {
+		o.table = name
+	}
+}
+
+// LockPlaceholders sets the placeholder style of the query arguments.
+// Defaults to sqlutil.Question.
+func LockPlaceholders(p sqlutil.Placeholder) SQLLockerOption {
+	return func(o *SQLLockerOptions) {
+		o.placeholder = p
+	}
+}
+
+// LockOwner sets the owner recorded in the leases obtained by the locker,
+// which is informational only. Defaults to "<hostname>-<pid>".
+func LockOwner(name string) SQLLockerOption {
+	return func(o *SQLLockerOptions) {
+		o.owner = name
+	}
+}
+
+// LockerClock sets the clock used for calculating the expiration time of
+// the leases. Defaults to the system clock.
+func LockerClock(c Clock) SQLLockerOption {
+	return func(o *SQLLockerOptions) {
+		o.clock = c
+	}
+}
+
+// SQLLocker is a micron.Locker on top of database/sql, which is shared among
+// the scheduler instances (typically the replicas of a service) using the
+// same database. It supports PostgreSQL, MySQL and SQLite.
+//
+// Each lock is a lease, which is held by its owner until the lease expires.
+// Since the expiration time is calculated by each scheduler instance itself,
+// the TTL of the lock (see LockTTL) must be greater than the maximal clock
+// error among the scheduler instances.
+//
+// The table must be created in advance. For example:
+//
+//	CREATE TABLE cron_locks (
+//	    job        VARCHAR(255) PRIMARY KEY,
+//	    owner      VARCHAR(255) NOT NULL,
+//	    expires_at BIGINT NOT NULL -- Unix time in milliseconds
+//	);
+type SQLLocker struct {
+	db   *sql.DB
+	opts *SQLLockerOptions
+
+	updateQuery string
+	insertQuery string
+	existsQuery string
+}
+
+// NewSQLLocker creates a SQL locker on top of db.
+func NewSQLLocker(db *sql.DB, opts ...SQLLockerOption) *SQLLocker {
+	options := &SQLLockerOptions{
+		table:       "cron_locks",
+		placeholder: sqlutil.Question,
+		owner:       defaultOwner(),
+		clock:       realClock{},
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	p := options.placeholder
+	return &SQLLocker{
+		db:   db,
+		opts: options,
+		updateQuery: fmt.Sprintf("UPDATE %s SET owner = %s, expires_at = %s WHERE job = %s AND expires_at <= %s",
+			options.table, p(1), p(2), p(3), p(4)),
+		insertQuery: fmt.Sprintf("INSERT INTO %s (job, owner, expires_at) VALUES (%s, %s, %s)",
+			options.table, p(1), p(2), p(3)),
+		existsQuery: fmt.Sprintf("SELECT 1 FROM %s WHERE job = %s",
+			options.table, p(1)),
+	}
+}
+
+// Lock implements micron.Locker.
+func (l *SQLLocker) Lock(job string, ttl time.Duration) (bool, error) {
+	ctx := context.Background()
+	now := l.opts.clock.Now()
+	expiresAt := now.Add(ttl).UnixMilli()
+
+	// Take over the lease if it has expired.
+	result, err := l.db.ExecContext(ctx, l.updateQuery, l.opts.owner, expiresAt, job, now.UnixMilli())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		return true, nil
+	}
+
+	// Otherwise, try to create the lease if it does not exist.
+	return sqlutil.InsertIfAbsent(ctx, l.db, l.insertQuery, []interface{}{job, l.opts.owner, expiresAt}, l.existsQuery, job)
+}
+
+func defaultOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return host + "-" + strconv.Itoa(os.Getpid())
+}
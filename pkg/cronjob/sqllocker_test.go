@@ -0,0 +1,78 @@
+package cronjob_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLLocker(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// Use a single connection to share the in-memory database.
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE cron_locks (
+		job        VARCHAR(255) PRIMARY KEY,
+		owner      VARCHAR(255) NOT NULL,
+		expires_at BIGINT NOT NULL
+	)`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	clock := newFakeClock(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	lockers := map[string]*cronjob.SQLLocker{
+		"a": cronjob.NewSQLLocker(db, cronjob.LockOwner("a"), cronjob.LockerClock(clock)),
+		"b": cronjob.NewSQLLocker(db, cronjob.LockOwner("b"), cronjob.LockerClock(clock)),
+	}
+
+	type op struct {
+		advance   time.Duration
+		locker    string
+		job       string
+		wantOK    bool
+		wantOwner string
+	}
+
+	ops := []op{
+		{locker: "a", job: "x", wantOK: true, wantOwner: "a"},
+		{locker: "b", job: "x", wantOK: false, wantOwner: "a"},
+		{locker: "b", job: "y", wantOK: true, wantOwner: "b"},
+		{advance: 500 * time.Millisecond, locker: "a", job: "x", wantOK: false, wantOwner: "a"},
+		{advance: 500 * time.Millisecond, locker: "b", job: "x", wantOK: true, wantOwner: "b"},
+		{locker: "a", job: "x", wantOK: false, wantOwner: "b"},
+		{advance: time.Second, locker: "a", job: "y", wantOK: true, wantOwner: "a"},
+	}
+
+	for i, o := range ops {
+		clock.Advance(o.advance)
+
+		ok, err := lockers[o.locker].Lock(o.job, time.Second)
+		if err != nil {
+			t.Fatalf("#%d: err: %v", i, err)
+		}
+		if ok != o.wantOK {
+			t.Fatalf("#%d: Lock(%q) by %s: got (%v), want (%v)", i, o.job, o.locker, ok, o.wantOK)
+		}
+
+		var owner string
+		if err := db.QueryRow("SELECT owner FROM cron_locks WHERE job = ?", o.job).Scan(&owner); err != nil {
+			t.Fatalf("#%d: err: %v", i, err)
+		}
+		if owner != o.wantOwner {
+			t.Fatalf("#%d: Owner(%q): got (%q), want (%q)", i, o.job, owner, o.wantOwner)
+		}
+	}
+
+	// Real failures are reported as errors.
+	if _, err := cronjob.NewSQLLocker(db, cronjob.LockTable("no_such_table")).Lock("x", time.Second); err == nil {
+		t.Fatal("Err: got nil, want non-nil")
+	}
+}
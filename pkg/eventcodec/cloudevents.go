@@ -0,0 +1,363 @@
+package eventcodec
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/RussellLuo/kun/pkg/werror"
+)
+
+const (
+	// StructuredContentType is the media type of an event in the structured
+	// content mode.
+	StructuredContentType = "application/cloudevents+json"
+
+	// BinaryHeaderPrefix is the prefix of the headers, which carry the context
+	// attributes of an event in the binary content mode.
+	BinaryHeaderPrefix = "ce-"
+)
+
+var (
+	reExtensionName = regexp.MustCompile(`^[a-z0-9]+$`)
+)
+
+// Mode is the content mode of a CloudEvents message.
+// See https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/spec.md#message.
+type Mode int
+
+const (
+	// ModeStructured encodes both the attributes and the data of an event
+	// in the message body, as a JSON object.
+	ModeStructured Mode = iota
+
+	// ModeBinary encodes the attributes of an event in the message headers
+	// (percent-encoded as per the HTTP binding), and the data in the message
+	// body.
+	ModeBinary
+)
+
+// Message is a protocol-independent message, which carries an event.
+type Message struct {
+	// Header holds the message headers, whose keys are in lower case.
+	Header map[string]string
+	Body   []byte
+}
+
+// CloudEvents is a codec for transferring events as CloudEvents messages.
+//
+// Note that CloudEvents only deals with the envelope of an event, the event
+// data is assumed to have been encoded (typically by a Codec) into bytes.
+type CloudEvents struct {
+	// Mode is the content mode used for encoding. Decoding always detects
+	// the mode from the content type of the message.
+	Mode Mode
+}
+
+// Encode encodes event into a message.
+func (c CloudEvents) Encode(event eventpubsub.Event) (*Message, error) {
+	attrs := eventpubsub.AttributesOf(event)
+	if err := validateAttributes(attrs); err != nil {
+		return nil, err
+	}
+
+	data, err := dataBytes(event.Data())
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Mode == ModeBinary {
+		return encodeBinary(attrs, data), nil
+	}
+	return encodeStructured(attrs, data)
+}
+
+// Decode decodes a message into an event.
+func (c CloudEvents) Decode(msg *Message) (*eventpubsub.CloudEvent, error) {
+	ct := header(msg.Header, "content-type")
+	mediaType, _, _ := mime.ParseMediaType(ct)
+	if mediaType == StructuredContentType {
+		return decodeStructured(msg.Body)
+	}
+	return decodeBinary(msg.Header, msg.Body)
+}
+
+func encodeStructured(attrs eventpubsub.Attributes, data []byte) (*Message, error) {
+	m := make(map[string]interface{})
+	for name, value := range attrs.Extensions {
+		m[name] = value
+	}
+	for name, value := range attributeStrings(attrs, false) {
+		m[name] = value
+	}
+
+	if data != nil {
+		if isJSON(attrs.DataContentType) && json.Valid(data) {
+			m["data"] = json.RawMessage(data)
+		} else {
+			m["data_base64"] = base64.StdEncoding.EncodeToString(data)
+		}
+	}
+
+	body, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Header: map[string]string{"content-type": StructuredContentType},
+		Body:   body,
+	}, nil
+}
+
+func decodeStructured(body []byte) (*eventpubsub.CloudEvent, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, werror.Wrap(eventpubsub.ErrInvalidEvent, err)
+	}
+
+	var attrs eventpubsub.Attributes
+	var data []byte
+
+	for name, raw := range m {
+		switch name {
+		case "data":
+			data = raw
+			continue
+		case "data_base64":
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, werror.Wrap(eventpubsub.ErrInvalidEvent, err)
+			}
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, werror.Wrap(eventpubsub.ErrInvalidEvent, err)
+			}
+			data = b
+			continue
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, werror.Wrap(eventpubsub.ErrInvalidEvent, err)
+		}
+		if err := setAttribute(&attrs, name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateAttributes(attrs); err != nil {
+		return nil, err
+	}
+
+	return eventpubsub.NewCloudEvent(attrs, data), nil
+}
+
+func encodeBinary(attrs eventpubsub.Attributes, data []byte) *Message {
+	h := make(map[string]string)
+	for name, value := range attrs.Extensions {
+		h[BinaryHeaderPrefix+name] = percentEncode(fmt.Sprint(value))
+	}
+	for name, value := range attributeStrings(attrs, true) {
+		h[BinaryHeaderPrefix+name] = percentEncode(value)
+	}
+	if attrs.DataContentType != "" {
+		h["content-type"] = attrs.DataContentType
+	}
+
+	return &Message{
+		Header: h,
+		Body:   data,
+	}
+}
+
+func decodeBinary(h map[string]string, body []byte) (*eventpubsub.CloudEvent, error) {
+	var attrs eventpubsub.Attributes
+	for key, value := range h {
+		key = strings.ToLower(key)
+		switch {
+		case key == "content-type":
+			attrs.DataContentType = value
+		case strings.HasPrefix(key, BinaryHeaderPrefix):
+			name := strings.TrimPrefix(key, BinaryHeaderPrefix)
+			if name == "datacontenttype" {
+				// The attribute datacontenttype is carried by the header content-type.
+				continue
+			}
+			value, err := percentDecode(value)
+			if err != nil {
+				return nil, werror.Wrap(eventpubsub.ErrInvalidEvent, err)
+			}
+			if err := setAttribute(&attrs, name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := validateAttributes(attrs); err != nil {
+		return nil, err
+	}
+
+	return eventpubsub.NewCloudEvent(attrs, body), nil
+}
+
+// percentEncode encodes s into a header value. Space, double-quote, percent
+// and any bytes outside the printable ASCII range are percent-encoded.
+// See https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/bindings/http-protocol-binding.md#3132-http-header-values.
+func percentEncode(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' || c == '"' || c == '%' {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xF])
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// percentDecode decodes a header value encoded by percentEncode.
+func percentDecode(s string) (string, error) {
+	if !strings.Contains(s, "%") {
+		return s, nil
+	}
+	v, err := url.PathUnescape(s)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.ValidString(v) {
+		return "", fmt.Errorf("invalid UTF-8 in header value %q", s)
+	}
+	return v, nil
+}
+
+// attributeStrings returns the non-empty attributes (excluding extensions)
+// in their string forms. If binary is true, datacontenttype is excluded
+// since it is carried by the header content-type in the binary mode.
+func attributeStrings(attrs eventpubsub.Attributes, binary bool) map[string]string {
+	m := map[string]string{
+		"id":          attrs.ID,
+		"source":      attrs.Source,
+		"specversion": attrs.SpecVersion,
+		"type":        attrs.Type,
+		"dataschema":  attrs.DataSchema,
+		"subject":     attrs.Subject,
+	}
+	if !binary {
+		m["datacontenttype"] = attrs.DataContentType
+	}
+	if !attrs.Time.IsZero() {
+		m["time"] = attrs.Time.Format(time.RFC3339Nano)
+	}
+	for name, value := range m {
+		if value == "" {
+			delete(m, name)
+		}
+	}
+	return m
+}
+
+func setAttribute(attrs *eventpubsub.Attributes, name string, value interface{}) error {
+	s, isString := value.(string)
+
+	switch name {
+	case "id", "source", "specversion", "type", "datacontenttype", "dataschema", "subject", "time":
+		if !isString {
+			return werror.Wrap(eventpubsub.ErrInvalidEvent, fmt.Errorf("attribute %s must be a string", name))
+		}
+	}
+
+	switch name {
+	case "id":
+		attrs.ID = s
+	case "source":
+		attrs.Source = s
+	case "specversion":
+		attrs.SpecVersion = s
+	case "type":
+		attrs.Type = s
+	case "datacontenttype":
+		attrs.DataContentType = s
+	case "dataschema":
+		attrs.DataSchema = s
+	case "subject":
+		attrs.Subject = s
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return werror.Wrap(eventpubsub.ErrInvalidEvent, err)
+		}
+		attrs.Time = t
+	default:
+		if !reExtensionName.MatchString(name) {
+			return werror.Wrap(eventpubsub.ErrInvalidEvent, fmt.Errorf("invalid attribute name %q", name))
+		}
+		if attrs.Extensions == nil {
+			attrs.Extensions = make(map[string]interface{})
+		}
+		attrs.Extensions[name] = value
+	}
+	return nil
+}
+
+func validateAttributes(attrs eventpubsub.Attributes) error {
+	switch {
+	case attrs.ID == "":
+		return werror.Wrap(eventpubsub.ErrInvalidEvent, fmt.Errorf("missing attribute id"))
+	case attrs.Source == "":
+		return werror.Wrap(eventpubsub.ErrInvalidEvent, fmt.Errorf("missing attribute source"))
+	case attrs.Type == "":
+		return werror.Wrap(eventpubsub.ErrInvalidEvent, fmt.Errorf("missing attribute type"))
+	case attrs.SpecVersion != eventpubsub.SpecVersion:
+		return werror.Wrap(eventpubsub.ErrInvalidEvent, fmt.Errorf("unsupported specversion %q", attrs.SpecVersion))
+	}
+	for name := range attrs.Extensions {
+		if !reExtensionName.MatchString(name) {
+			return werror.Wrap(eventpubsub.ErrInvalidEvent, fmt.Errorf("invalid attribute name %q", name))
+		}
+	}
+	return nil
+}
+
+func dataBytes(data interface{}) ([]byte, error) {
+	switch d := data.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return d, nil
+	case json.RawMessage:
+		return d, nil
+	default:
+		return nil, werror.Wrap(eventpubsub.ErrInvalidData, fmt.Errorf("unsupported data type %T", d))
+	}
+}
+
+// isJSON reports whether the media type ct denotes JSON. An empty media
+// type is considered to be JSON, as per the CloudEvents JSON format.
+func isJSON(ct string) bool {
+	if ct == "" {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(ct)
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func header(h map[string]string, key string) string {
+	for k, v := range h {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,239 @@
+package eventcodec_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/eventcodec"
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+func TestCloudEvents_Encode(t *testing.T) {
+	attrs := eventpubsub.Attributes{
+		ID:              "1",
+		Source:          "/eventsvc/Service",
+		SpecVersion:     "1.0",
+		Type:            "created",
+		DataContentType: "application/json",
+		Subject:         "users",
+		Time:            time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+		Extensions:      map[string]interface{}{"tenant": "acme"},
+	}
+
+	tests := []struct {
+		name       string
+		inMode     eventcodec.Mode
+		inEvent    eventpubsub.Event
+		wantMsg    *eventcodec.Message
+		wantErrStr string
+	}{
+		{
+			name:    "structured",
+			inMode:  eventcodec.ModeStructured,
+			inEvent: eventpubsub.NewCloudEvent(attrs, []byte(`{"id":1}`)),
+			wantMsg: &eventcodec.Message{
+				Header: map[string]string{"content-type": "application/cloudevents+json"},
+				Body:   []byte(`{"data":{"id":1},"datacontenttype":"application/json","id":"1","source":"/eventsvc/Service","specversion":"1.0","subject":"users","tenant":"acme","time":"2021-01-02T03:04:05Z","type":"created"}`),
+			},
+		},
+		{
+			name:   "structured with non-JSON data",
+			inMode: eventcodec.ModeStructured,
+			inEvent: eventpubsub.NewCloudEvent(eventpubsub.Attributes{
+				ID:              "1",
+				Source:          "/eventsvc/Service",
+				SpecVersion:     "1.0",
+				Type:            "created",
+				DataContentType: "text/plain",
+			}, []byte("hello")),
+			wantMsg: &eventcodec.Message{
+				Header: map[string]string{"content-type": "application/cloudevents+json"},
+				Body:   []byte(`{"data_base64":"aGVsbG8=","datacontenttype":"text/plain","id":"1","source":"/eventsvc/Service","specversion":"1.0","type":"created"}`),
+			},
+		},
+		{
+			name:    "binary",
+			inMode:  eventcodec.ModeBinary,
+			inEvent: eventpubsub.NewCloudEvent(attrs, []byte(`{"id":1}`)),
+			wantMsg: &eventcodec.Message{
+				Header: map[string]string{
+					"content-type":   "application/json",
+					"ce-id":          "1",
+					"ce-source":      "/eventsvc/Service",
+					"ce-specversion": "1.0",
+					"ce-type":        "created",
+					"ce-subject":     "users",
+					"ce-time":        "2021-01-02T03:04:05Z",
+					"ce-tenant":      "acme",
+				},
+				Body: []byte(`{"id":1}`),
+			},
+		},
+		{
+			name:   "missing attribute",
+			inMode: eventcodec.ModeBinary,
+			inEvent: eventpubsub.NewCloudEvent(eventpubsub.Attributes{
+				Source:      "/eventsvc/Service",
+				SpecVersion: "1.0",
+				Type:        "created",
+			}, nil),
+			wantErrStr: "missing attribute id",
+		},
+		{
+			name:       "unsupported data",
+			inMode:     eventcodec.ModeBinary,
+			inEvent:    eventpubsub.NewCloudEvent(attrs, 1),
+			wantErrStr: "unsupported data type int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := eventcodec.CloudEvents{Mode: tt.inMode}.Encode(tt.inEvent)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if !reflect.DeepEqual(msg, tt.wantMsg) {
+				t.Fatalf("Message: got (%+v), want (%+v)", msg, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCloudEvents_Decode(t *testing.T) {
+	wantAttrs := eventpubsub.Attributes{
+		ID:              "1",
+		Source:          "/eventsvc/Service",
+		SpecVersion:     "1.0",
+		Type:            "created",
+		DataContentType: "application/json",
+		Time:            time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+		Extensions:      map[string]interface{}{"tenant": "acme"},
+	}
+
+	tests := []struct {
+		name       string
+		inMsg      *eventcodec.Message
+		wantAttrs  eventpubsub.Attributes
+		wantData   []byte
+		wantErrStr string
+	}{
+		{
+			name: "structured",
+			inMsg: &eventcodec.Message{
+				Header: map[string]string{"Content-Type": "application/cloudevents+json; charset=utf-8"},
+				Body:   []byte(`{"specversion":"1.0","id":"1","source":"/eventsvc/Service","type":"created","datacontenttype":"application/json","time":"2021-01-02T03:04:05Z","tenant":"acme","data":{"id":1}}`),
+			},
+			wantAttrs: wantAttrs,
+			wantData:  []byte(`{"id":1}`),
+		},
+		{
+			name: "structured with base64 data",
+			inMsg: &eventcodec.Message{
+				Header: map[string]string{"content-type": "application/cloudevents+json"},
+				Body:   []byte(`{"specversion":"1.0","id":"1","source":"/eventsvc/Service","type":"created","datacontenttype":"application/json","time":"2021-01-02T03:04:05Z","tenant":"acme","data_base64":"eyJpZCI6MX0="}`),
+			},
+			wantAttrs: wantAttrs,
+			wantData:  []byte(`{"id":1}`),
+		},
+		{
+			name: "binary",
+			inMsg: &eventcodec.Message{
+				Header: map[string]string{
+					"Content-Type":   "application/json",
+					"Ce-Id":          "1",
+					"Ce-Source":      "/eventsvc/Service",
+					"Ce-Specversion": "1.0",
+					"Ce-Type":        "created",
+					"Ce-Time":        "2021-01-02T03:04:05Z",
+					"Ce-Tenant":      "acme",
+					"X-Request-Id":   "abc",
+				},
+				Body: []byte(`{"id":1}`),
+			},
+			wantAttrs: wantAttrs,
+			wantData:  []byte(`{"id":1}`),
+		},
+		{
+			name: "unsupported specversion",
+			inMsg: &eventcodec.Message{
+				Header: map[string]string{
+					"ce-id":          "1",
+					"ce-source":      "/eventsvc/Service",
+					"ce-specversion": "0.3",
+					"ce-type":        "created",
+				},
+			},
+			wantErrStr: `unsupported specversion "0.3"`,
+		},
+		{
+			name: "invalid time",
+			inMsg: &eventcodec.Message{
+				Header: map[string]string{"content-type": "application/cloudevents+json"},
+				Body:   []byte(`{"specversion":"1.0","id":"1","source":"/eventsvc/Service","type":"created","time":"yesterday"}`),
+			},
+			wantErrStr: `parsing time "yesterday" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "yesterday" as "2006"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := eventcodec.CloudEvents{}.Decode(tt.inMsg)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if err != nil {
+				return
+			}
+
+			if attrs := event.Attributes(); !reflect.DeepEqual(attrs, tt.wantAttrs) {
+				t.Fatalf("Attributes: got (%+v), want (%+v)", attrs, tt.wantAttrs)
+			}
+			if data := event.Data().([]byte); string(data) != string(tt.wantData) {
+				t.Fatalf("Data: got (%s), want (%s)", data, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestCloudEvents_BinaryRoundTrip(t *testing.T) {
+	attrs := eventpubsub.Attributes{
+		ID:          "1",
+		Source:      `/event svc/"Service"`,
+		SpecVersion: "1.0",
+		Type:        "created",
+		Subject:     "100% café\n",
+		Extensions:  map[string]interface{}{"tenant": "a+b ä"},
+	}
+
+	codec := eventcodec.CloudEvents{Mode: eventcodec.ModeBinary}
+	msg, err := codec.Encode(eventpubsub.NewCloudEvent(attrs, nil))
+	if err != nil {
+		t.Fatalf("Encode: unexpected error: %v", err)
+	}
+
+	wantHeader := map[string]string{
+		"ce-source":  "/event%20svc/%22Service%22",
+		"ce-subject": "100%25%20caf%C3%A9%0A",
+		"ce-tenant":  "a+b%20%C3%A4",
+	}
+	for key, want := range wantHeader {
+		if got := msg.Header[key]; got != want {
+			t.Fatalf("Header %s: got (%s), want (%s)", key, got, want)
+		}
+	}
+
+	event, err := codec.Decode(msg)
+	if err != nil {
+		t.Fatalf("Decode: unexpected error: %v", err)
+	}
+	if got := event.Attributes(); !reflect.DeepEqual(got, attrs) {
+		t.Fatalf("Attributes: got (%+v), want (%+v)", got, attrs)
+	}
+
+	msg.Header["ce-subject"] = "100%"
+	if _, err := codec.Decode(msg); err == nil {
+		t.Fatalf("Decode: want an error for the malformed value")
+	}
+}
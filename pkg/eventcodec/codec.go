@@ -1,5 +1,9 @@
 package eventcodec
 
+import (
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
 // Codec is a codec (encoder and decoder) for an event.
 type Codec interface {
 	// Decode decodes data and stores the result in out.
@@ -12,3 +16,34 @@ type Codec interface {
 type Codecs interface {
 	EncodeDecoder(name string) Codec
 }
+
+// Upcasting is an optional interface for Codecs, which provides the
+// upcasters used by the generated event handlers to move the data of old
+// versions forward before decoding.
+type Upcasting interface {
+	Upcasters() *eventpubsub.Upcasters
+}
+
+// UpcastersOf returns the upcasters provided by codecs, or nil if codecs does
+// not implement Upcasting.
+func UpcastersOf(codecs Codecs) *eventpubsub.Upcasters {
+	if u, ok := codecs.(Upcasting); ok {
+		return u.Upcasters()
+	}
+	return nil
+}
+
+// ContentTyper is an optional interface for a Codec, which reports the media
+// type of the encoded data.
+type ContentTyper interface {
+	ContentType() string
+}
+
+// ContentType returns the media type of the data encoded by c, or an empty
+// string if c does not implement ContentTyper.
+func ContentType(c Codec) string {
+	if ct, ok := c.(ContentTyper); ok {
+		return ct.ContentType()
+	}
+	return ""
+}
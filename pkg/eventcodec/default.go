@@ -1,5 +1,9 @@
 package eventcodec
 
+import (
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
 // NamedCodec holds a codec and its corresponding operation name.
 type NamedCodec struct {
 	Name  string
@@ -15,8 +19,9 @@ func Op(name string, codec Codec) NamedCodec {
 }
 
 type DefaultCodecs struct {
-	d      Codec
-	Codecs map[string]Codec
+	d         Codec
+	Codecs    map[string]Codec
+	upcasters *eventpubsub.Upcasters
 }
 
 func NewDefaultCodecs(d Codec, namedCodecs ...NamedCodec) *DefaultCodecs {
@@ -41,3 +46,15 @@ func (dc *DefaultCodecs) EncodeDecoder(name string) Codec {
 	}
 	return dc.d
 }
+
+// WithUpcasters sets the upcasters, which move the event data of old versions
+// forward before decoding (see Upcasting).
+func (dc *DefaultCodecs) WithUpcasters(u *eventpubsub.Upcasters) *DefaultCodecs {
+	dc.upcasters = u
+	return dc
+}
+
+// Upcasters implements Upcasting.
+func (dc *DefaultCodecs) Upcasters() *eventpubsub.Upcasters {
+	return dc.upcasters
+}
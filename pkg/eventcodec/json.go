@@ -30,3 +30,23 @@ func (j JSON) Decode(data, out interface{}) error {
 func (j JSON) Encode(in interface{}) (interface{}, error) {
 	return json.Marshal(in)
 }
+
+// ContentType implements ContentTyper.
+func (j JSON) ContentType() string {
+	return "application/json"
+}
+
+// JSONUpcaster creates an upcaster for the event data in JSON, which is
+// decoded into a generic object, converted by f, and then encoded again.
+func JSONUpcaster(f func(m map[string]interface{}) error) eventpubsub.Upcaster {
+	return func(data interface{}) (interface{}, error) {
+		m := make(map[string]interface{})
+		if err := (JSON{}).Decode(data, &m); err != nil {
+			return nil, err
+		}
+		if err := f(m); err != nil {
+			return nil, err
+		}
+		return (JSON{}).Encode(m)
+	}
+}
@@ -0,0 +1,199 @@
+package eventpubsub
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/uuidutil"
+)
+
+// SpecVersion is the version of the CloudEvents specification, which the
+// events of CloudEvent comply with.
+const SpecVersion = "1.0"
+
+// Attributes holds the context attributes of an event, as defined in the
+// CloudEvents 1.0 specification.
+// See https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/spec.md#context-attributes.
+type Attributes struct {
+	// Required attributes.
+	ID          string
+	Source      string
+	SpecVersion string
+	Type        string
+
+	// Optional attributes.
+	DataContentType string
+	DataSchema      string
+	Subject         string
+	Time            time.Time
+
+	// Extensions holds the extension attributes, whose names must consist of
+	// lower-case letters or digits.
+	Extensions map[string]interface{}
+}
+
+// Get returns the value of the attribute name, or nil if there is no
+// such attribute.
+func (a Attributes) Get(name string) interface{} {
+	switch name {
+	case "id":
+		return a.ID
+	case "source":
+		return a.Source
+	case "specversion":
+		return a.SpecVersion
+	case "type":
+		return a.Type
+	case "datacontenttype":
+		return a.DataContentType
+	case "dataschema":
+		return a.DataSchema
+	case "subject":
+		return a.Subject
+	case "time":
+		return a.Time
+	default:
+		return a.Extensions[name]
+	}
+}
+
+// Set sets the attribute name to value. Empty values are ignored, so that
+// the defaults (e.g. set by NewAttributes) are kept.
+//
+// The values of the string attributes (e.g. id and source) are converted to
+// strings as StringAttribute does, and the value of time must be a time.Time
+// or an RFC 3339 timestamp, otherwise it is ignored. The values of the
+// extension attributes are kept as is.
+func (a *Attributes) Set(name string, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case string:
+		if v == "" {
+			return
+		}
+	case time.Time:
+		if v.IsZero() {
+			return
+		}
+	}
+
+	var p *string
+	switch name {
+	case "id":
+		p = &a.ID
+	case "source":
+		p = &a.Source
+	case "specversion":
+		p = &a.SpecVersion
+	case "type":
+		p = &a.Type
+	case "datacontenttype":
+		p = &a.DataContentType
+	case "dataschema":
+		p = &a.DataSchema
+	case "subject":
+		p = &a.Subject
+	case "time":
+		if t := toTime(value); !t.IsZero() {
+			a.Time = t
+		}
+		return
+	default:
+		if a.Extensions == nil {
+			a.Extensions = make(map[string]interface{})
+		}
+		a.Extensions[name] = value
+		return
+	}
+
+	if s := toString(value); s != "" {
+		*p = s
+	}
+}
+
+// CloudEvent is an event compatible with the CloudEvents 1.0 specification.
+type CloudEvent struct {
+	attrs Attributes
+	data  interface{}
+}
+
+// NewAttributes creates the attributes for an event of the given type from
+// source, with specversion set to SpecVersion, id set to a random UUID and
+// time set to the current time.
+func NewAttributes(typ, source string) Attributes {
+	return Attributes{
+		ID:          uuidutil.New(),
+		Source:      source,
+		SpecVersion: SpecVersion,
+		Type:        typ,
+		Time:        time.Now(),
+	}
+}
+
+// NewCloudEvent creates a CloudEvent with the given attributes and data.
+func NewCloudEvent(attrs Attributes, data interface{}) *CloudEvent {
+	return &CloudEvent{
+		attrs: attrs,
+		data:  data,
+	}
+}
+
+// Type implements Event.
+func (e *CloudEvent) Type() string { return e.attrs.Type }
+
+// Data implements Event.
+func (e *CloudEvent) Data() interface{} { return e.data }
+
+// Attributes returns the context attributes of e.
+func (e *CloudEvent) Attributes() Attributes { return e.attrs }
+
+// AttributesOf returns the context attributes of event. If event does not
+// carry any attributes, only the attribute type will be returned.
+func AttributesOf(event Event) Attributes {
+	if e, ok := event.(interface{ Attributes() Attributes }); ok {
+		return e.Attributes()
+	}
+	return Attributes{Type: event.Type()}
+}
+
+// StringAttribute returns the value of the attribute name of event as a
+// string. An empty string is returned if there is no such attribute.
+func StringAttribute(event Event, name string) string {
+	return toString(AttributesOf(event).Get(name))
+}
+
+// TimeAttribute returns the value of the attribute name of event as a
+// timestamp. A zero time is returned if there is no such attribute or the
+// value is not a valid RFC 3339 timestamp.
+func TimeAttribute(event Event, name string) time.Time {
+	return toTime(AttributesOf(event).Get(name))
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toTime(value interface{}) time.Time {
+	switch v := value.(type) {
+	case time.Time:
+		return v
+	case string:
+		t, _ := time.Parse(time.RFC3339Nano, v)
+		return t
+	default:
+		return time.Time{}
+	}
+}
@@ -0,0 +1,97 @@
+package eventpubsub_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+func TestAttributes_Set(t *testing.T) {
+	now := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	newAttrs := func() eventpubsub.Attributes {
+		return eventpubsub.Attributes{
+			ID:     "1",
+			Source: "/test",
+			Type:   "created",
+			Time:   now,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		inName    string
+		inValue   interface{}
+		wantAttrs func() eventpubsub.Attributes
+	}{
+		{
+			name:    "string",
+			inName:  "subject",
+			inValue: "users/1",
+			wantAttrs: func() eventpubsub.Attributes {
+				attrs := newAttrs()
+				attrs.Subject = "users/1"
+				return attrs
+			},
+		},
+		{
+			name:    "non-string",
+			inName:  "id",
+			inValue: 42,
+			wantAttrs: func() eventpubsub.Attributes {
+				attrs := newAttrs()
+				attrs.ID = "42"
+				return attrs
+			},
+		},
+		{
+			name:      "empty string",
+			inName:    "source",
+			inValue:   "",
+			wantAttrs: newAttrs,
+		},
+		{
+			name:      "nil",
+			inName:    "type",
+			inValue:   nil,
+			wantAttrs: newAttrs,
+		},
+		{
+			name:    "timestamp",
+			inName:  "time",
+			inValue: "2022-02-03T04:05:06Z",
+			wantAttrs: func() eventpubsub.Attributes {
+				attrs := newAttrs()
+				attrs.Time = time.Date(2022, 2, 3, 4, 5, 6, 0, time.UTC)
+				return attrs
+			},
+		},
+		{
+			name:      "invalid time",
+			inName:    "time",
+			inValue:   42,
+			wantAttrs: newAttrs,
+		},
+		{
+			name:    "extension",
+			inName:  "tenant",
+			inValue: 42,
+			wantAttrs: func() eventpubsub.Attributes {
+				attrs := newAttrs()
+				attrs.Extensions = map[string]interface{}{"tenant": 42}
+				return attrs
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := newAttrs()
+			attrs.Set(tt.inName, tt.inValue)
+			if want := tt.wantAttrs(); !reflect.DeepEqual(attrs, want) {
+				t.Fatalf("Attrs: got (%#v), want (%#v)", attrs, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package dedup provides idempotent event consumption, by skipping the
+// events that have already been processed.
+package dedup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+// The outcomes of handling an event, which are used as the values of the
+// label "outcome" of the counter (see Counter).
+const (
+	OutcomeProcessed = "processed" // The event has been processed successfully.
+	OutcomeDuplicate = "duplicate" // The event is a duplicate and has been skipped.
+	OutcomeFailed    = "failed"    // The event has failed to be processed.
+	OutcomeNoKey     = "nokey"     // The event has no key and has been processed without deduplication.
+)
+
+// Store records the keys of the processed events.
+type Store interface {
+	// Add records key, and reports whether key is newly added. False means
+	// that key has already been recorded, i.e. the event is a duplicate.
+	Add(ctx context.Context, key string) (bool, error)
+
+	// Contains reports whether key has been recorded.
+	Contains(ctx context.Context, key string) (bool, error)
+}
+
+// WrapHandler wraps h to skip the events, whose keys have already been
+// recorded in store.
+//
+// The key of an event is recorded only after the event has been processed
+// by h successfully. Thus an event, which fails to be processed (even if the
+// process crashes halfway), will be processed again on redelivery, i.e. the
+// at-least-once delivery is preserved. As a trade-off, the concurrent
+// duplicates of an event in process are not skipped.
+func WrapHandler(h eventpubsub.Handler, store Store, opts ...Option) eventpubsub.Handler {
+	options := newOptions(opts)
+	return &handler{h: h, store: store, opts: options}
+}
+
+type handler struct {
+	h     eventpubsub.Handler
+	store Store
+	opts  *Options
+}
+
+// Handle implements eventpubsub.Handler.
+func (d *handler) Handle(ctx context.Context, event eventpubsub.Event) error {
+	key := d.opts.keyFunc(event)
+	if key == "" {
+		err := d.h.Handle(ctx, event)
+		d.count(event, OutcomeNoKey)
+		return err
+	}
+
+	processed, err := d.store.Contains(ctx, key)
+	if err != nil {
+		d.count(event, OutcomeFailed)
+		return err
+	}
+	if processed {
+		d.count(event, OutcomeDuplicate)
+		return nil
+	}
+
+	if err := d.h.Handle(ctx, event); err != nil {
+		d.count(event, OutcomeFailed)
+		return err
+	}
+
+	// The key may have been added by a concurrent duplicate, which is fine.
+	if _, err := d.store.Add(ctx, key); err != nil {
+		d.count(event, OutcomeFailed)
+		return fmt.Errorf("failed to record key %q: %w", key, err)
+	}
+
+	d.count(event, OutcomeProcessed)
+	return nil
+}
+
+func (d *handler) count(event eventpubsub.Event, outcome string) {
+	d.opts.counter.With("type", event.Type(), "outcome", outcome).Add(1)
+}
@@ -0,0 +1,211 @@
+package dedup_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/RussellLuo/kun/pkg/eventpubsub/dedup"
+	"github.com/go-kit/kit/metrics"
+)
+
+// counter records the counts by label values.
+type counter struct {
+	counts      map[string]float64
+	labelValues []string
+}
+
+func newCounter() *counter {
+	return &counter{counts: make(map[string]float64)}
+}
+
+func (c *counter) With(labelValues ...string) metrics.Counter {
+	return &counter{counts: c.counts, labelValues: append(c.labelValues, labelValues...)}
+}
+
+func (c *counter) Add(delta float64) {
+	key := ""
+	for i := 1; i < len(c.labelValues); i += 2 {
+		key += c.labelValues[i-1] + "=" + c.labelValues[i] + ","
+	}
+	c.counts[key] += delta
+}
+
+func newEvent(id string) eventpubsub.Event {
+	return newEventFrom("/test", id)
+}
+
+func newEventFrom(source, id string) eventpubsub.Event {
+	attrs := eventpubsub.NewAttributes("created", source)
+	attrs.ID = id
+	return eventpubsub.NewCloudEvent(attrs, nil)
+}
+
+func TestWrapHandler(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	type delivery struct {
+		event eventpubsub.Event
+		err   error // The error returned by the inner handler.
+	}
+
+	cases := []struct {
+		name       string
+		deliveries []delivery
+		wantErrs   []string
+		wantCalls  int
+		wantCounts map[string]float64
+	}{
+		{
+			name: "duplicates",
+			deliveries: []delivery{
+				{event: newEvent("1")},
+				{event: newEvent("1")},
+				{event: newEvent("2")},
+			},
+			wantErrs:  []string{"", "", ""},
+			wantCalls: 2,
+			wantCounts: map[string]float64{
+				"type=created,outcome=processed,": 2,
+				"type=created,outcome=duplicate,": 1,
+			},
+		},
+		{
+			name: "same id from different sources",
+			deliveries: []delivery{
+				{event: newEventFrom("/a", "1")},
+				{event: newEventFrom("/b", "1")},
+				{event: newEventFrom("/a", "1")},
+			},
+			wantErrs:  []string{"", "", ""},
+			wantCalls: 2,
+			wantCounts: map[string]float64{
+				"type=created,outcome=processed,": 2,
+				"type=created,outcome=duplicate,": 1,
+			},
+		},
+		{
+			name: "redelivery after failure",
+			deliveries: []delivery{
+				{event: newEvent("1"), err: errFailed},
+				{event: newEvent("1")},
+				{event: newEvent("1")},
+			},
+			wantErrs:  []string{"failed", "", ""},
+			wantCalls: 2,
+			wantCounts: map[string]float64{
+				"type=created,outcome=failed,":    1,
+				"type=created,outcome=processed,": 1,
+				"type=created,outcome=duplicate,": 1,
+			},
+		},
+		{
+			name: "no key",
+			deliveries: []delivery{
+				{event: newEvent("")},
+				{event: newEvent("")},
+			},
+			wantErrs:  []string{"", ""},
+			wantCalls: 2,
+			wantCounts: map[string]float64{
+				"type=created,outcome=nokey,": 2,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			var err error
+			h := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+				calls++
+				return err
+			})
+
+			c := newCounter()
+			handler := dedup.WrapHandler(h, dedup.NewMemoryStore(10), dedup.Counter(c))
+
+			for i, d := range tt.deliveries {
+				err = d.err
+				gotErr := handler.Handle(context.Background(), d.event)
+				wantErrStr := tt.wantErrs[i]
+				if (gotErr == nil && wantErrStr != "") || (gotErr != nil && gotErr.Error() != wantErrStr) {
+					t.Fatalf("Err #%d: got (%#v), want (%#v)", i, gotErr, wantErrStr)
+				}
+			}
+
+			if calls != tt.wantCalls {
+				t.Fatalf("Calls: got (%d), want (%d)", calls, tt.wantCalls)
+			}
+			if !reflect.DeepEqual(c.counts, tt.wantCounts) {
+				t.Fatalf("Counts: got (%#v), want (%#v)", c.counts, tt.wantCounts)
+			}
+		})
+	}
+}
+
+func TestWrapHandler_KeyFunc(t *testing.T) {
+	var calls int
+	h := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		calls++
+		return nil
+	})
+
+	// Deduplicate events by subject, instead of id.
+	handler := dedup.WrapHandler(h, dedup.NewMemoryStore(10), dedup.KeyFunc(func(event eventpubsub.Event) string {
+		return eventpubsub.StringAttribute(event, "subject")
+	}))
+
+	for _, id := range []string{"1", "2"} {
+		attrs := eventpubsub.NewAttributes("created", "/test")
+		attrs.ID = id
+		attrs.Subject = "user-1"
+		if err := handler.Handle(context.Background(), eventpubsub.NewCloudEvent(attrs, nil)); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("Calls: got (%d), want (%d)", calls, 1)
+	}
+}
+
+func TestWrapHandler_Crash(t *testing.T) {
+	var calls int
+	h := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		calls++
+		if calls == 1 {
+			panic("crashed")
+		}
+		return nil
+	})
+
+	store := dedup.NewMemoryStore(10)
+	handler := dedup.WrapHandler(h, store)
+	event := newEvent("1")
+	key := "/test 1" // The default key is source+id.
+
+	// The first delivery crashes halfway, e.g. the process is killed.
+	func() {
+		defer func() { _ = recover() }()
+		_ = handler.Handle(context.Background(), event)
+	}()
+
+	// The key is not recorded, thus the redelivery is processed.
+	if ok, _ := store.Contains(context.Background(), key); ok {
+		t.Fatal("Contains: got (true), want (false)")
+	}
+	if err := handler.Handle(context.Background(), event); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("Calls: got (%d), want (%d)", calls, 2)
+	}
+
+	// Then the key is recorded.
+	if ok, _ := store.Contains(context.Background(), key); !ok {
+		t.Fatal("Contains: got (false), want (true)")
+	}
+}
@@ -0,0 +1,77 @@
+package dedup
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// MemoryStore is an in-memory Store, which keeps the most recently added
+// keys up to a fixed capacity, and evicts the least recently used ones.
+//
+// MemoryStore is only suitable for a single process, and the keys will be
+// lost after a restart.
+type MemoryStore struct {
+	capacity int
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+// NewMemoryStore creates an in-memory store with the given capacity.
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &MemoryStore{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Add implements Store.
+func (s *MemoryStore) Add(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.items[key]; ok {
+		s.ll.MoveToFront(e)
+		return false, nil
+	}
+
+	s.items[key] = s.ll.PushFront(key)
+	if s.ll.Len() > s.capacity {
+		oldest := s.ll.Back()
+		s.ll.Remove(oldest)
+		delete(s.items, oldest.Value.(string))
+	}
+
+	return true, nil
+}
+
+// Contains implements Store.
+func (s *MemoryStore) Contains(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.items[key]
+	if ok {
+		s.ll.MoveToFront(e)
+	}
+	return ok, nil
+}
+
+// Remove removes key, to allow the event to be processed again.
+func (s *MemoryStore) Remove(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.items[key]; ok {
+		s.ll.Remove(e)
+		delete(s.items, key)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package dedup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub/dedup"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := dedup.NewMemoryStore(2)
+
+	type op struct {
+		remove  bool
+		key     string
+		wantNew bool
+	}
+
+	ops := []op{
+		{key: "a", wantNew: true},
+		{key: "b", wantNew: true},
+		{key: "a", wantNew: false}, // "a" becomes the most recently used
+		{key: "c", wantNew: true},  // evicts "b"
+		{key: "b", wantNew: true},  // evicts "a"
+		{key: "c", wantNew: false},
+		{remove: true, key: "c"},
+		{key: "c", wantNew: true},
+	}
+
+	for i, o := range ops {
+		if o.remove {
+			if err := store.Remove(ctx, o.key); err != nil {
+				t.Fatalf("#%d: err: %v", i, err)
+			}
+			continue
+		}
+
+		added, err := store.Add(ctx, o.key)
+		if err != nil {
+			t.Fatalf("#%d: err: %v", i, err)
+		}
+		if added != o.wantNew {
+			t.Fatalf("#%d: Added(%q): got (%v), want (%v)", i, o.key, added, o.wantNew)
+		}
+
+		contained, err := store.Contains(ctx, o.key)
+		if err != nil {
+			t.Fatalf("#%d: err: %v", i, err)
+		}
+		if !contained {
+			t.Fatalf("#%d: Contains(%q): got (false), want (true)", i, o.key)
+		}
+	}
+}
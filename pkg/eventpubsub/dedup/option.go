@@ -0,0 +1,61 @@
+package dedup
+
+import (
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+)
+
+type Options struct {
+	keyFunc func(event eventpubsub.Event) string
+	counter metrics.Counter
+}
+
+// Option sets an optional parameter for Options.
+type Option func(*Options)
+
+// KeyFunc sets the function to extract the deduplication key from an event.
+// An event with an empty key will always be processed. Defaults to using
+// the attributes source and id of the event (joined by a space, which never
+// occurs in a valid source), since CloudEvents only requires source+id to be
+// unique.
+func KeyFunc(f func(event eventpubsub.Event) string) Option {
+	return func(o *Options) {
+		o.keyFunc = f
+	}
+}
+
+// Counter sets the counter of the handled events, with the labels "type"
+// (the event type) and "outcome" (see OutcomeProcessed etc.). For example:
+//
+//	kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+//		Namespace: "myapp",
+//		Name:      "events_dedup_total",
+//		Help:      "Number of events handled by deduplication.",
+//	}, []string{"type", "outcome"})
+//
+// The events are not counted by default.
+func Counter(c metrics.Counter) Option {
+	return func(o *Options) {
+		o.counter = c
+	}
+}
+
+func newOptions(opts []Option) *Options {
+	options := &Options{
+		keyFunc: defaultKey,
+		counter: discard.NewCounter(),
+	}
+	for _, o := range opts {
+		o(options)
+	}
+	return options
+}
+
+func defaultKey(event eventpubsub.Event) string {
+	id := eventpubsub.StringAttribute(event, "id")
+	if id == "" {
+		return ""
+	}
+	return eventpubsub.StringAttribute(event, "source") + " " + id
+}
@@ -0,0 +1,98 @@
+package dedup
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/RussellLuo/kun/pkg/sqlutil"
+)
+
+type SQLStoreOptions struct {
+	table       string
+	placeholder sqlutil.Placeholder
+}
+
+// SQLStoreOption sets an optional parameter for SQLStoreOptions.
+type SQLStoreOption func(*SQLStoreOptions)
+
+// Table sets the name of the table, in which the keys are recorded.
+// Defaults to "processed_events".
+func Table(name string) SQLStoreOption {
+	return func(o *SQLStoreOptions) {
+		o.table = name
+	}
+}
+
+// Placeholders sets the placeholder style of the query arguments. Defaults
+// to sqlutil.Question.
+func Placeholders(p sqlutil.Placeholder) SQLStoreOption {
+	return func(o *SQLStoreOptions) {
+		o.placeholder = p
+	}
+}
+
+// SQLStore is a Store on top of database/sql, which is shared among
+// processes.
+//
+// The table must be created in advance. For example:
+//
+//	CREATE TABLE processed_events (
+//	    event_key  VARCHAR(255) PRIMARY KEY,
+//	    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+//	);
+//
+// The keys are kept forever, it's up to the user to clean up the old ones
+// (e.g. by created_at) periodically.
+type SQLStore struct {
+	db *sql.DB
+
+	insertQuery string
+	existsQuery string
+	removeQuery string
+}
+
+// NewSQLStore creates a SQL store on top of db.
+func NewSQLStore(db *sql.DB, opts ...SQLStoreOption) *SQLStore {
+	options := &SQLStoreOptions{
+		table:       "processed_events",
+		placeholder: sqlutil.Question,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	return &SQLStore{
+		db: db,
+		insertQuery: fmt.Sprintf("INSERT INTO %s (event_key) VALUES (%s)",
+			options.table, options.placeholder(1)),
+		existsQuery: fmt.Sprintf("SELECT 1 FROM %s WHERE event_key = %s",
+			options.table, options.placeholder(1)),
+		removeQuery: fmt.Sprintf("DELETE FROM %s WHERE event_key = %s",
+			options.table, options.placeholder(1)),
+	}
+}
+
+// Add implements Store.
+func (s *SQLStore) Add(ctx context.Context, key string) (bool, error) {
+	return sqlutil.InsertIfAbsent(ctx, s.db, s.insertQuery, []interface{}{key}, s.existsQuery, key)
+}
+
+// Contains implements Store.
+func (s *SQLStore) Contains(ctx context.Context, key string) (bool, error) {
+	var one int
+	switch err := s.db.QueryRowContext(ctx, s.existsQuery, key).Scan(&one); err {
+	case nil:
+		return true, nil
+	case sql.ErrNoRows:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Remove removes key, to allow the event to be processed again.
+func (s *SQLStore) Remove(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, s.removeQuery, key)
+	return err
+}
@@ -0,0 +1,76 @@
+package dedup_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub/dedup"
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLStore(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// Use a single connection to share the in-memory database.
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE processed_events (
+		event_key  VARCHAR(255) PRIMARY KEY,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx := context.Background()
+	store := dedup.NewSQLStore(db)
+
+	type op struct {
+		remove  bool
+		key     string
+		wantNew bool
+	}
+
+	ops := []op{
+		{key: "a", wantNew: true},
+		{key: "b", wantNew: true},
+		{key: "a", wantNew: false},
+		{remove: true, key: "a"},
+		{key: "a", wantNew: true},
+		{key: "b", wantNew: false},
+	}
+
+	for i, o := range ops {
+		if o.remove {
+			if err := store.Remove(ctx, o.key); err != nil {
+				t.Fatalf("#%d: err: %v", i, err)
+			}
+			continue
+		}
+
+		added, err := store.Add(ctx, o.key)
+		if err != nil {
+			t.Fatalf("#%d: err: %v", i, err)
+		}
+		if added != o.wantNew {
+			t.Fatalf("#%d: Added(%q): got (%v), want (%v)", i, o.key, added, o.wantNew)
+		}
+
+		contained, err := store.Contains(ctx, o.key)
+		if err != nil {
+			t.Fatalf("#%d: err: %v", i, err)
+		}
+		if !contained {
+			t.Fatalf("#%d: Contains(%q): got (false), want (true)", i, o.key)
+		}
+	}
+
+	// Real failures are reported as errors.
+	if _, err := dedup.NewSQLStore(db, dedup.Table("no_such_table")).Add(ctx, "a"); err == nil {
+		t.Fatal("Err: got nil, want non-nil")
+	}
+}
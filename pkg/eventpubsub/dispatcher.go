@@ -0,0 +1,241 @@
+package eventpubsub
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/RussellLuo/kun/pkg/syncutil"
+)
+
+// AttrPartitionKey is the extension attribute, which carries the partition
+// key of an event, as defined in the CloudEvents Partitioning extension.
+// See https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/extensions/partitioning.md.
+const AttrPartitionKey = "partitionkey"
+
+var (
+	ErrDispatcherClosed = errors.New("dispatcher closed")
+)
+
+type DispatcherOptions struct {
+	workers      int
+	queueSize    int
+	keyFunc      func(event Event) string
+	errorHandler func(ctx context.Context, event Event, err error)
+}
+
+// DispatcherOption sets an optional parameter for DispatcherOptions.
+type DispatcherOption func(*DispatcherOptions)
+
+// DispatcherWorkers sets the number of workers, which handle events
+// concurrently. Defaults to the number of CPUs.
+func DispatcherWorkers(n int) DispatcherOption {
+	return func(o *DispatcherOptions) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+// DispatcherQueueSize sets the capacity of the queue of each worker, which
+// buffers events not yet handled. Defaults to 64.
+func DispatcherQueueSize(n int) DispatcherOption {
+	return func(o *DispatcherOptions) {
+		if n >= 0 {
+			o.queueSize = n
+		}
+	}
+}
+
+// PartitionKey sets the function to extract the partition key from an
+// event. Defaults to using the attribute AttrPartitionKey of the event.
+func PartitionKey(f func(event Event) string) DispatcherOption {
+	return func(o *DispatcherOptions) {
+		o.keyFunc = f
+	}
+}
+
+// DispatchErrorHandler sets the handler for errors returned by the event
+// handler. The errors are ignored by default.
+func DispatchErrorHandler(h func(ctx context.Context, event Event, err error)) DispatcherOption {
+	return func(o *DispatcherOptions) {
+		o.errorHandler = h
+	}
+}
+
+// Dispatcher handles events concurrently by a pool of workers, while keeping
+// the order of events with the same partition key.
+//
+// Events with the same partition key are always dispatched to the same
+// worker, and thus are handled one by one in the order they were dispatched.
+// Events without a partition key are dispatched to the workers in turn.
+//
+// Dispatcher implements Handler, whose Handle returns once the event has
+// been enqueued, without waiting for it to be handled. The errors returned
+// by the underlying handler are only reported to DispatchErrorHandler, and
+// never to the caller. Therefore, if the caller is a broker acknowledging
+// events on nil errors, the events failed (or pending when the process
+// crashes) are lost, that is, the delivery becomes at-most-once. To keep
+// the delivery at-least-once, either:
+//
+//   - use HandleAndWait instead, which returns the result of handling; or
+//   - make the underlying handler retry and dead-letter by itself (see
+//     WrapHandler), and accept losing the pending events on crashes.
+type Dispatcher struct {
+	h      Handler
+	opts   *DispatcherOptions
+	queues []chan dispatchItem
+	next   uint32 // The next worker for events without a partition key.
+
+	mu      sync.RWMutex
+	closed  bool
+	closing chan struct{}
+
+	dispatching sync.WaitGroup // in-flight dispatching
+	working     sync.WaitGroup // running workers
+}
+
+// NewDispatcher creates a dispatcher, which dispatches events to h, and
+// starts the workers.
+func NewDispatcher(h Handler, opts ...DispatcherOption) *Dispatcher {
+	options := &DispatcherOptions{
+		workers:   runtime.NumCPU(),
+		queueSize: 64,
+		keyFunc: func(event Event) string {
+			return StringAttribute(event, AttrPartitionKey)
+		},
+		errorHandler: func(ctx context.Context, event Event, err error) {},
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	d := &Dispatcher{
+		h:       h,
+		opts:    options,
+		queues:  make([]chan dispatchItem, options.workers),
+		closing: make(chan struct{}),
+	}
+	for i := range d.queues {
+		queue := make(chan dispatchItem, options.queueSize)
+		d.queues[i] = queue
+
+		d.working.Add(1)
+		go func() {
+			defer d.working.Done()
+			d.work(queue)
+		}()
+	}
+
+	return d
+}
+
+// dispatchItem is an event enqueued, along with the channel to receive the
+// result of handling, which is nil if nobody waits for the result.
+type dispatchItem struct {
+	event Event
+	done  chan error
+}
+
+// Handle implements Handler.
+//
+// Handle blocks if the queue of the target worker is full, until there is
+// room in the queue or ctx is done. It returns nil once the event has been
+// enqueued, and the result of handling is reported to DispatchErrorHandler.
+func (d *Dispatcher) Handle(ctx context.Context, event Event) error {
+	return d.dispatch(ctx, dispatchItem{event: event})
+}
+
+// HandleAndWait is like Handle, but waits until the event has been handled,
+// and returns the result of handling instead of reporting it to
+// DispatchErrorHandler. The ordering of events with the same partition key
+// is still kept.
+//
+// If ctx is done before the event is handled, ctx.Err() is returned, while
+// the event may still be handled later.
+func (d *Dispatcher) HandleAndWait(ctx context.Context, event Event) error {
+	done := make(chan error, 1)
+	if err := d.dispatch(ctx, dispatchItem{event: event, done: done}); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, item dispatchItem) error {
+	d.mu.RLock()
+	if d.closed {
+		d.mu.RUnlock()
+		return ErrDispatcherClosed
+	}
+	d.dispatching.Add(1)
+	defer d.dispatching.Done()
+	d.mu.RUnlock()
+
+	select {
+	case d.queues[d.worker(item.event)] <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-d.closing:
+		return ErrDispatcherClosed
+	}
+}
+
+// Close stops accepting new events, and then waits until all the enqueued
+// events have been handled or ctx is done.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return ErrDispatcherClosed
+	}
+	d.closed = true
+	close(d.closing)
+	d.mu.Unlock()
+
+	// Wait for in-flight dispatching to finish before closing the queues.
+	d.dispatching.Wait()
+	for _, queue := range d.queues {
+		close(queue)
+	}
+
+	return syncutil.Wait(ctx, &d.working)
+}
+
+// worker returns the index of the worker, to which event is dispatched.
+func (d *Dispatcher) worker(event Event) int {
+	n := uint32(len(d.queues))
+
+	key := d.opts.keyFunc(event)
+	if key == "" {
+		return int((atomic.AddUint32(&d.next, 1) - 1) % n)
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % n)
+}
+
+func (d *Dispatcher) work(queue chan dispatchItem) {
+	for item := range queue {
+		// The handling is detached from the dispatching, whose context may
+		// have been canceled at this time.
+		ctx := context.Background()
+		err := d.h.Handle(ctx, item.event)
+		switch {
+		case item.done != nil:
+			item.done <- err
+		case err != nil:
+			d.opts.errorHandler(ctx, item.event, err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package eventpubsub_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+func newKeyedEvent(key string, seq int) eventpubsub.Event {
+	attrs := eventpubsub.NewAttributes("updated", "/test")
+	attrs.Set(eventpubsub.AttrPartitionKey, key)
+	return eventpubsub.NewCloudEvent(attrs, seq)
+}
+
+func TestDispatcher_Ordering(t *testing.T) {
+	var mu sync.Mutex
+	got := make(map[string][]int) // partition keys => sequence numbers
+
+	h := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		// Slow down the handling to interleave the workers.
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		key := eventpubsub.StringAttribute(event, eventpubsub.AttrPartitionKey)[:1]
+		got[key] = append(got[key], event.Data().(int))
+		return nil
+	})
+
+	// Use a custom partition key, which ignores the suffix of the attribute.
+	d := eventpubsub.NewDispatcher(h, eventpubsub.DispatcherWorkers(4), eventpubsub.DispatcherQueueSize(1), eventpubsub.PartitionKey(func(event eventpubsub.Event) string {
+		return eventpubsub.StringAttribute(event, eventpubsub.AttrPartitionKey)[:1]
+	}))
+
+	keys := []string{"a", "a1", "b", "c", "c1", "d", "e"}
+	want := make(map[string][]int)
+	for seq := 0; seq < 20; seq++ {
+		for _, key := range keys {
+			if err := d.Handle(context.Background(), newKeyedEvent(key, seq)); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			want[key[:1]] = append(want[key[:1]], seq)
+		}
+	}
+
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Events: got (%v), want (%v)", got, want)
+	}
+}
+
+func TestDispatcher_Concurrency(t *testing.T) {
+	const workers = 3
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	release := make(chan struct{})
+
+	h := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		wg.Done()
+		<-release
+		return nil
+	})
+
+	// Events without a partition key are dispatched to each worker in turn.
+	d := eventpubsub.NewDispatcher(h, eventpubsub.DispatcherWorkers(workers))
+
+	for i := 0; i < workers; i++ {
+		if err := d.Handle(context.Background(), newKeyedEvent("", i)); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// All the events must be in process at the same time.
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("events are not handled concurrently")
+	}
+
+	close(release)
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestDispatcher_Close(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	var mu sync.Mutex
+	var handled int
+	var errs []error
+
+	h := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled++
+		return errFailed
+	})
+
+	d := eventpubsub.NewDispatcher(h, eventpubsub.DispatcherWorkers(2), eventpubsub.DispatchErrorHandler(func(ctx context.Context, event eventpubsub.Event, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}))
+
+	for i := 0; i < 10; i++ {
+		if err := d.Handle(context.Background(), newKeyedEvent("", i)); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// All the enqueued events are handled before Close returns.
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if handled != 10 {
+		t.Fatalf("Handled: got (%d), want (%d)", handled, 10)
+	}
+	if len(errs) != 10 {
+		t.Fatalf("Errors: got (%d), want (%d)", len(errs), 10)
+	}
+
+	wantErrStr := eventpubsub.ErrDispatcherClosed.Error()
+	if err := d.Handle(context.Background(), newKeyedEvent("a", 0)); err == nil || err.Error() != wantErrStr {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, wantErrStr)
+	}
+}
+
+func TestDispatcher_HandleAndWait(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	h := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		if event.Data().(int)%2 == 1 {
+			return errFailed
+		}
+		return nil
+	})
+
+	var reported int
+	d := eventpubsub.NewDispatcher(h, eventpubsub.DispatcherWorkers(2), eventpubsub.DispatchErrorHandler(func(ctx context.Context, event eventpubsub.Event, err error) {
+		reported++
+	}))
+	defer d.Close(context.Background()) // nolint:errcheck
+
+	for i := 0; i < 4; i++ {
+		var wantErr error
+		if i%2 == 1 {
+			wantErr = errFailed
+		}
+		if err := d.HandleAndWait(context.Background(), newKeyedEvent("a", i)); err != wantErr {
+			t.Fatalf("Err: got (%#v), want (%#v)", err, wantErr)
+		}
+	}
+
+	// The errors are returned to the caller instead of being reported.
+	if reported != 0 {
+		t.Fatalf("Reported: got (%d), want (0)", reported)
+	}
+}
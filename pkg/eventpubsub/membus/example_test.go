@@ -0,0 +1,37 @@
+package membus_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/RussellLuo/kun/pkg/eventpubsub/membus"
+)
+
+func Example() {
+	bus := membus.New()
+
+	// Typically, subscribe the handler generated by kun (i.e. NewEventHandler).
+	handler := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		fmt.Printf("Received event %s: %s\n", event.Type(), event.Data())
+		return nil
+	})
+	if err := bus.Subscribe("user.*", handler); err != nil {
+		fmt.Printf("err: %v\n", err)
+		return
+	}
+
+	// Typically, pass the bus to NewEventPublisher generated by kun.
+	if err := bus.Publish(context.Background(), "user.created", []byte(`{"id":1}`)); err != nil {
+		fmt.Printf("err: %v\n", err)
+		return
+	}
+
+	// Wait for all the events to be handled.
+	if err := bus.Close(context.Background()); err != nil {
+		fmt.Printf("err: %v\n", err)
+	}
+
+	// Output:
+	// Received event user.created: {"id":1}
+}
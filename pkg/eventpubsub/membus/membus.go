@@ -0,0 +1,249 @@
+// Package membus provides an in-memory event bus, which is useful for
+// in-process event dispatching and as a test double for event-driven services.
+package membus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/RussellLuo/kun/pkg/syncutil"
+)
+
+var (
+	ErrClosed = errors.New("membus: bus closed")
+)
+
+// Bus is an in-memory event bus, which implements eventpubsub.Publisher
+// (and eventpubsub.EventPublisher).
+//
+// Each subscription has its own bounded queue and worker pool. Publishing
+// to a subscription whose queue is full blocks, until there is room in the
+// queue or the publishing context is done.
+type Bus struct {
+	opts *Options
+
+	mu      sync.RWMutex
+	subs    []*Subscription
+	closed  bool
+	closing chan struct{}
+
+	publishing sync.WaitGroup // in-flight publishing
+	working    sync.WaitGroup // running workers
+}
+
+// New creates an in-memory event bus.
+func New(opts ...Option) *Bus {
+	options := &Options{
+		errorHandler: func(ctx context.Context, event eventpubsub.Event, err error) {},
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	return &Bus{
+		opts:    options,
+		closing: make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes handler to the events, whose types match topic.
+//
+// The syntax of topic is the same as the pattern of path.Match. For example,
+// "user.*" matches both "user.created" and "user.deleted", and "*" matches
+// all events whose types contain no slash.
+func (b *Bus) Subscribe(topic string, handler eventpubsub.Handler, opts ...SubscribeOption) error {
+	_, err := b.SubscribeWithCancel(topic, handler, opts...)
+	return err
+}
+
+// SubscribeWithCancel is like Subscribe, but returns the subscription, which
+// can be canceled by Subscription.Unsubscribe.
+func (b *Bus) SubscribeWithCancel(topic string, handler eventpubsub.Handler, opts ...SubscribeOption) (*Subscription, error) {
+	if _, err := path.Match(topic, ""); err != nil {
+		return nil, fmt.Errorf("membus: invalid topic %q: %w", topic, err)
+	}
+
+	options := &SubscribeOptions{
+		workers:   1,
+		queueSize: 64,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	s := &Subscription{
+		bus:           b,
+		topic:         topic,
+		handler:       handler,
+		queue:         make(chan eventpubsub.Event, options.queueSize),
+		unsubscribing: make(chan struct{}),
+	}
+	b.subs = append(b.subs, s)
+
+	for i := 0; i < options.workers; i++ {
+		b.working.Add(1)
+		s.working.Add(1)
+		go func() {
+			defer func() {
+				s.working.Done()
+				b.working.Done()
+			}()
+			b.work(s)
+		}()
+	}
+
+	return s, nil
+}
+
+// Publish implements eventpubsub.Publisher.
+func (b *Bus) Publish(ctx context.Context, typ string, data interface{}) error {
+	return b.PublishEvent(ctx, &event{typ: typ, data: data})
+}
+
+// PublishEvent implements eventpubsub.EventPublisher.
+//
+// PublishEvent returns once event has been enqueued for all the matching
+// subscriptions, without waiting for it to be handled.
+func (b *Bus) PublishEvent(ctx context.Context, event eventpubsub.Event) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrClosed
+	}
+	b.publishing.Add(1)
+	defer b.publishing.Done()
+
+	var subs []*Subscription
+	for _, s := range b.subs {
+		if s.matches(event.Type()) {
+			s.publishing.Add(1)
+			subs = append(subs, s)
+		}
+	}
+	b.mu.RUnlock()
+
+	for i, s := range subs {
+		if err := s.send(ctx, event); err != nil {
+			for _, rest := range subs[i+1:] {
+				rest.publishing.Done()
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close stops accepting new events, and then waits until all the enqueued
+// events have been handled or ctx is done.
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	b.closed = true
+	close(b.closing)
+	b.mu.Unlock()
+
+	// Wait for in-flight publishing to finish before closing the queues.
+	b.publishing.Wait()
+	for _, s := range b.subs {
+		close(s.queue)
+	}
+
+	return syncutil.Wait(ctx, &b.working)
+}
+
+func (b *Bus) work(s *Subscription) {
+	for e := range s.queue {
+		// The handling is detached from the publishing, whose context may
+		// have been canceled at this time.
+		ctx := context.Background()
+		if err := s.handler.Handle(ctx, e); err != nil {
+			b.opts.errorHandler(ctx, e, err)
+		}
+	}
+}
+
+// Subscription is a subscription of a handler to the events of a Bus.
+type Subscription struct {
+	bus     *Bus
+	topic   string
+	handler eventpubsub.Handler
+	queue   chan eventpubsub.Event
+
+	unsubscribed  bool // guarded by bus.mu
+	unsubscribing chan struct{}
+
+	publishing sync.WaitGroup // in-flight publishing to the subscription
+	working    sync.WaitGroup // running workers of the subscription
+}
+
+// Unsubscribe cancels the subscription, and then waits until the events
+// enqueued for it have been handled or ctx is done. The events published
+// afterwards will not be delivered to the subscription.
+func (s *Subscription) Unsubscribe(ctx context.Context) error {
+	b := s.bus
+
+	b.mu.Lock()
+	if s.unsubscribed || b.closed {
+		// The queue has been (or is being) closed, just wait.
+		b.mu.Unlock()
+		return syncutil.Wait(ctx, &s.working)
+	}
+	s.unsubscribed = true
+	for i, sub := range b.subs {
+		if sub == s {
+			b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+			break
+		}
+	}
+	b.mu.Unlock()
+
+	// Wait for in-flight publishing to finish before closing the queue.
+	close(s.unsubscribing)
+	s.publishing.Wait()
+	close(s.queue)
+
+	return syncutil.Wait(ctx, &s.working)
+}
+
+func (s *Subscription) matches(typ string) bool {
+	ok, _ := path.Match(s.topic, typ) // the topic has been validated
+	return ok
+}
+
+// send enqueues event, unless the subscription has been canceled.
+func (s *Subscription) send(ctx context.Context, event eventpubsub.Event) error {
+	defer s.publishing.Done()
+
+	select {
+	case s.queue <- event:
+	case <-s.unsubscribing:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.bus.closing:
+		return ErrClosed
+	}
+	return nil
+}
+
+type event struct {
+	typ  string
+	data interface{}
+}
+
+func (e *event) Type() string      { return e.typ }
+func (e *event) Data() interface{} { return e.data }
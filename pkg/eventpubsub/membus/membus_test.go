@@ -0,0 +1,236 @@
+package membus_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/RussellLuo/kun/pkg/eventpubsub/membus"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	types []string
+}
+
+func (r *recorder) Handle(ctx context.Context, event eventpubsub.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types = append(r.types, event.Type())
+	return nil
+}
+
+func (r *recorder) Types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	types := append([]string(nil), r.types...)
+	sort.Strings(types)
+	return types
+}
+
+func TestBus_Routing(t *testing.T) {
+	bus := membus.New()
+
+	all, users := new(recorder), new(recorder)
+	if err := bus.Subscribe("*", all, membus.Workers(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Subscribe("user.*", users); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, typ := range []string{"user.created", "user.deleted", "order.created"} {
+		if err := bus.Publish(ctx, typ, nil); err != nil {
+			t.Fatalf("Err: got (%#v), want (nil)", err)
+		}
+	}
+
+	if err := bus.Close(ctx); err != nil {
+		t.Fatalf("Err: got (%#v), want (nil)", err)
+	}
+
+	if got, want := all.Types(), []string{"order.created", "user.created", "user.deleted"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Types: got (%v), want (%v)", got, want)
+	}
+	if got, want := users.Types(), []string{"user.created", "user.deleted"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Types: got (%v), want (%v)", got, want)
+	}
+}
+
+func TestBus_Subscribe(t *testing.T) {
+	bus := membus.New()
+
+	err := bus.Subscribe("[", new(recorder))
+	if want := `membus: invalid topic "[": syntax error in pattern`; err == nil || err.Error() != want {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, want)
+	}
+
+	_ = bus.Close(context.Background())
+	if err := bus.Subscribe("*", new(recorder)); err != membus.ErrClosed {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, membus.ErrClosed)
+	}
+}
+
+func TestBus_Backpressure(t *testing.T) {
+	bus := membus.New()
+
+	release := make(chan struct{})
+	handler := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		<-release
+		return nil
+	})
+	if err := bus.Subscribe("*", handler, membus.QueueSize(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	// The first event is being handled, and the second one is queued.
+	for i := 0; i < 2; i++ {
+		if err := bus.Publish(ctx, "created", nil); err != nil {
+			t.Fatalf("Err: got (%#v), want (nil)", err)
+		}
+	}
+
+	// The third one blocks until the context is done, since the queue is full.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	// Give the worker a chance to dequeue the first event.
+	time.Sleep(10 * time.Millisecond)
+	if err := bus.Publish(timeoutCtx, "created", nil); err != context.DeadlineExceeded {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	if err := bus.Close(ctx); err != nil {
+		t.Fatalf("Err: got (%#v), want (nil)", err)
+	}
+}
+
+func TestBus_Close(t *testing.T) {
+	var mu sync.Mutex
+	var errs []error
+	bus := membus.New(membus.ErrorHandler(func(ctx context.Context, event eventpubsub.Event, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}))
+
+	var handled int
+	handler := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		handled++
+		return errors.New("oops")
+	})
+	if err := bus.Subscribe("*", handler); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := bus.Publish(ctx, "created", nil); err != nil {
+			t.Fatalf("Err: got (%#v), want (nil)", err)
+		}
+	}
+
+	// Close drains all the queued events.
+	if err := bus.Close(ctx); err != nil {
+		t.Fatalf("Err: got (%#v), want (nil)", err)
+	}
+	if handled != 3 || len(errs) != 3 {
+		t.Fatalf("Handled: got (%d, %d errors), want (3, 3 errors)", handled, len(errs))
+	}
+
+	if err := bus.Publish(ctx, "created", nil); err != membus.ErrClosed {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, membus.ErrClosed)
+	}
+	if err := bus.Close(ctx); err != membus.ErrClosed {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, membus.ErrClosed)
+	}
+}
+
+func TestBus_CloseTimeout(t *testing.T) {
+	bus := membus.New()
+
+	release := make(chan struct{})
+	defer close(release)
+	handler := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		<-release
+		return nil
+	})
+	if err := bus.Subscribe("*", handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(context.Background(), "created", nil); err != nil {
+		t.Fatalf("Err: got (%#v), want (nil)", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bus.Close(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, context.DeadlineExceeded)
+	}
+}
+
+func TestSubscription_Unsubscribe(t *testing.T) {
+	bus := membus.New()
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var handled int
+	handler := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		handled++
+		return nil
+	})
+	sub, err := bus.SubscribeWithCancel("*", handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	others := new(recorder)
+	if err := bus.Subscribe("*", others); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := bus.Publish(ctx, "created", nil); err != nil {
+			t.Fatalf("Err: got (%#v), want (nil)", err)
+		}
+	}
+
+	// Unsubscribe waits for the enqueued events.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := sub.Unsubscribe(timeoutCtx); err != context.DeadlineExceeded {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, context.DeadlineExceeded)
+	}
+	close(release)
+	if err := sub.Unsubscribe(ctx); err != nil {
+		t.Fatalf("Err: got (%#v), want (nil)", err)
+	}
+
+	// The events published afterwards are only delivered to the others.
+	if err := bus.Publish(ctx, "deleted", nil); err != nil {
+		t.Fatalf("Err: got (%#v), want (nil)", err)
+	}
+	if err := bus.Close(ctx); err != nil {
+		t.Fatalf("Err: got (%#v), want (nil)", err)
+	}
+
+	if handled != 2 {
+		t.Fatalf("Handled: got (%d), want (2)", handled)
+	}
+	if got, want := others.Types(), []string{"created", "created", "deleted"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Types: got (%v), want (%v)", got, want)
+	}
+}
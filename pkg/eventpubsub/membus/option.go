@@ -0,0 +1,50 @@
+package membus
+
+import (
+	"context"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+type Options struct {
+	errorHandler func(ctx context.Context, event eventpubsub.Event, err error)
+}
+
+// Option sets an optional parameter for Options.
+type Option func(*Options)
+
+// ErrorHandler sets the handler for errors returned by the event handlers.
+// The errors are ignored by default.
+func ErrorHandler(h func(ctx context.Context, event eventpubsub.Event, err error)) Option {
+	return func(o *Options) {
+		o.errorHandler = h
+	}
+}
+
+type SubscribeOptions struct {
+	workers   int
+	queueSize int
+}
+
+// SubscribeOption sets an optional parameter for SubscribeOptions.
+type SubscribeOption func(*SubscribeOptions)
+
+// Workers sets the number of workers, which handle events concurrently for
+// a subscription. Defaults to 1.
+func Workers(n int) SubscribeOption {
+	return func(o *SubscribeOptions) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+// QueueSize sets the capacity of the queue, which buffers events not yet
+// handled for a subscription. Defaults to 64.
+func QueueSize(n int) SubscribeOption {
+	return func(o *SubscribeOptions) {
+		if n >= 0 {
+			o.queueSize = n
+		}
+	}
+}
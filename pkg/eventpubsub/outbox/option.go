@@ -0,0 +1,87 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/sqlutil"
+)
+
+type Options struct {
+	table        string
+	placeholder  sqlutil.Placeholder
+	source       string
+	batchSize    int
+	pollInterval time.Duration
+	errorHandler func(ctx context.Context, err error)
+}
+
+// Option sets an optional parameter for Options.
+type Option func(*Options)
+
+// Table sets the name of the outbox table. Defaults to "outbox".
+func Table(name string) Option {
+	return func(o *Options) {
+		o.table = name
+	}
+}
+
+// Placeholders sets the placeholder style of the query arguments. Defaults
+// to sqlutil.Question.
+func Placeholders(p sqlutil.Placeholder) Option {
+	return func(o *Options) {
+		o.placeholder = p
+	}
+}
+
+// Source sets the CloudEvents attribute source of the events published by
+// Publisher.Publish. Defaults to "/outbox".
+func Source(source string) Option {
+	return func(o *Options) {
+		o.source = source
+	}
+}
+
+// BatchSize sets the maximum number of events the relay fetches from the
+// outbox table at a time. Defaults to 100.
+func BatchSize(n int) Option {
+	return func(o *Options) {
+		if n > 0 {
+			o.batchSize = n
+		}
+	}
+}
+
+// PollInterval sets the interval, at which the relay polls the outbox table
+// for new events. Defaults to 1s.
+func PollInterval(d time.Duration) Option {
+	return func(o *Options) {
+		if d > 0 {
+			o.pollInterval = d
+		}
+	}
+}
+
+// ErrorHandler sets the handler for errors occurred while the relay is
+// running. The errors specific to an event carry the id of its record in
+// the outbox table. The errors are ignored by default.
+func ErrorHandler(h func(ctx context.Context, err error)) Option {
+	return func(o *Options) {
+		o.errorHandler = h
+	}
+}
+
+func newOptions(opts []Option) *Options {
+	options := &Options{
+		table:        "outbox",
+		placeholder:  sqlutil.Question,
+		source:       "/outbox",
+		batchSize:    100,
+		pollInterval: time.Second,
+		errorHandler: func(ctx context.Context, err error) {},
+	}
+	for _, o := range opts {
+		o(options)
+	}
+	return options
+}
@@ -0,0 +1,118 @@
+// Package outbox implements the transactional outbox pattern on top of
+// database/sql.
+//
+// Instead of being sent to the message broker directly, events are written
+// by Publisher into an outbox table, within the same transaction as the
+// business data. A Relay then polls the outbox table and forwards the events
+// to the real publisher. As a result, an event is published if and only if
+// the transaction is committed, at least once.
+//
+// The outbox table must be created in advance. For example, in SQLite:
+//
+//	CREATE TABLE outbox (
+//	    id      INTEGER PRIMARY KEY AUTOINCREMENT,
+//	    type    TEXT NOT NULL,
+//	    payload BLOB NOT NULL
+//	);
+//
+// Or in PostgreSQL:
+//
+//	CREATE TABLE outbox (
+//	    id      BIGSERIAL PRIMARY KEY,
+//	    type    TEXT NOT NULL,
+//	    payload BYTEA NOT NULL
+//	);
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/RussellLuo/kun/pkg/eventcodec"
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+var (
+	ErrNoTx = errors.New("outbox: no transaction in context")
+)
+
+type contextKeyTx struct{}
+
+// NewContext returns a copy of ctx carrying tx, within which the events
+// will be written by Publisher.
+func NewContext(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, contextKeyTx{}, tx)
+}
+
+// FromContext returns the transaction carried by ctx, if any.
+func FromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(contextKeyTx{}).(*sql.Tx)
+	return tx, ok
+}
+
+// WithTx runs f within a transaction, which is carried by the context
+// passed to f. The transaction will be committed if f succeeds, or rolled
+// back otherwise.
+func WithTx(ctx context.Context, db *sql.DB, f func(ctx context.Context) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := f(NewContext(ctx, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// codec encodes (or decodes) events into (or from) the payloads stored in
+// the outbox table.
+var codec = eventcodec.CloudEvents{Mode: eventcodec.ModeStructured}
+
+// Publisher writes events into the outbox table, within the transaction
+// carried by the publishing context (see NewContext). It implements
+// eventpubsub.Publisher (and eventpubsub.EventPublisher).
+//
+// The events are stored as CloudEvents in the structured content mode, thus
+// the event data must have been encoded into bytes (as done by the generated
+// EventPublisher).
+type Publisher struct {
+	opts  *Options
+	query string
+}
+
+// NewPublisher creates an outbox publisher.
+func NewPublisher(opts ...Option) *Publisher {
+	options := newOptions(opts)
+	return &Publisher{
+		opts: options,
+		query: fmt.Sprintf("INSERT INTO %s (type, payload) VALUES (%s, %s)",
+			options.table, options.placeholder(1), options.placeholder(2)),
+	}
+}
+
+// Publish implements eventpubsub.Publisher.
+func (p *Publisher) Publish(ctx context.Context, typ string, data interface{}) error {
+	attrs := eventpubsub.NewAttributes(typ, p.opts.source)
+	return p.PublishEvent(ctx, eventpubsub.NewCloudEvent(attrs, data))
+}
+
+// PublishEvent implements eventpubsub.EventPublisher.
+func (p *Publisher) PublishEvent(ctx context.Context, event eventpubsub.Event) error {
+	tx, ok := FromContext(ctx)
+	if !ok {
+		return ErrNoTx
+	}
+
+	msg, err := codec.Encode(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, p.query, event.Type(), msg.Body)
+	return err
+}
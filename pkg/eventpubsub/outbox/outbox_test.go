@@ -0,0 +1,337 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/RussellLuo/kun/pkg/eventpubsub/outbox"
+	_ "modernc.org/sqlite"
+)
+
+func newDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// Use a single connection to share the in-memory database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE outbox (
+		id      INTEGER PRIMARY KEY AUTOINCREMENT,
+		type    TEXT NOT NULL,
+		payload BLOB NOT NULL
+	)`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	return db
+}
+
+func countEvents(t *testing.T, db *sql.DB) int {
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM outbox").Scan(&n); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return n
+}
+
+type publishedEvent struct {
+	Type   string
+	Source string
+	Data   string
+}
+
+type recorder struct {
+	events []publishedEvent
+	err    error
+}
+
+func (r *recorder) Publish(ctx context.Context, typ string, data interface{}) error {
+	return errors.New("unexpected call to Publish")
+}
+
+func (r *recorder) PublishEvent(ctx context.Context, event eventpubsub.Event) error {
+	if r.err != nil {
+		return r.err
+	}
+	data, _ := event.Data().([]byte)
+	r.events = append(r.events, publishedEvent{
+		Type:   event.Type(),
+		Source: eventpubsub.StringAttribute(event, "source"),
+		Data:   string(data),
+	})
+	return nil
+}
+
+func TestPublisher_PublishEvent(t *testing.T) {
+	db := newDB(t)
+	pub := outbox.NewPublisher()
+
+	cases := []struct {
+		name       string
+		inCtx      func(tx *sql.Tx) context.Context
+		commit     bool
+		wantErrStr string
+		wantCount  int
+	}{
+		{
+			name: "no transaction",
+			inCtx: func(tx *sql.Tx) context.Context {
+				return context.Background()
+			},
+			commit:     true,
+			wantErrStr: "outbox: no transaction in context",
+			wantCount:  0,
+		},
+		{
+			name: "rolled back",
+			inCtx: func(tx *sql.Tx) context.Context {
+				return outbox.NewContext(context.Background(), tx)
+			},
+			commit:    false,
+			wantCount: 0,
+		},
+		{
+			name: "committed",
+			inCtx: func(tx *sql.Tx) context.Context {
+				return outbox.NewContext(context.Background(), tx)
+			},
+			commit:    true,
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			attrs := eventpubsub.NewAttributes("created", "/test")
+			err = pub.PublishEvent(tt.inCtx(tx), eventpubsub.NewCloudEvent(attrs, []byte(`{"id":1}`)))
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+
+			if tt.commit {
+				err = tx.Commit()
+			} else {
+				err = tx.Rollback()
+			}
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if n := countEvents(t, db); n != tt.wantCount {
+				t.Fatalf("Count: got (%d), want (%d)", n, tt.wantCount)
+			}
+
+			// Clean up for the next case.
+			if _, err := db.Exec("DELETE FROM outbox"); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+		})
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	db := newDB(t)
+	pub := outbox.NewPublisher(outbox.Source("/users"))
+
+	errFailed := errors.New("failed")
+	err := outbox.WithTx(context.Background(), db, func(ctx context.Context) error {
+		if err := pub.Publish(ctx, "created", []byte(`{"id":1}`)); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if err != errFailed {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, errFailed)
+	}
+	if n := countEvents(t, db); n != 0 {
+		t.Fatalf("Count: got (%d), want (%d)", n, 0)
+	}
+
+	err = outbox.WithTx(context.Background(), db, func(ctx context.Context) error {
+		return pub.Publish(ctx, "created", []byte(`{"id":1}`))
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n := countEvents(t, db); n != 1 {
+		t.Fatalf("Count: got (%d), want (%d)", n, 1)
+	}
+}
+
+func TestRelay_Relay(t *testing.T) {
+	db := newDB(t)
+	pub := outbox.NewPublisher(outbox.Source("/users"))
+
+	err := outbox.WithTx(context.Background(), db, func(ctx context.Context) error {
+		for _, data := range []string{`{"id":1}`, `{"id":2}`, `{"id":3}`} {
+			if err := pub.Publish(ctx, "created", []byte(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	rec := &recorder{err: errors.New("unavailable")}
+	relay := outbox.NewRelay(db, rec, outbox.BatchSize(2))
+
+	// Events are kept if they fail to be published.
+	n, err := relay.Relay(context.Background())
+	wantErrStr := "outbox: publish event #1: unavailable"
+	if err == nil || err.Error() != wantErrStr {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, wantErrStr)
+	}
+	if n != 0 {
+		t.Fatalf("N: got (%d), want (%d)", n, 0)
+	}
+	if n := countEvents(t, db); n != 3 {
+		t.Fatalf("Count: got (%d), want (%d)", n, 3)
+	}
+
+	rec.err = nil
+
+	n, err = relay.Relay(context.Background())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("N: got (%d), want (%d)", n, 2)
+	}
+
+	n, err = relay.Relay(context.Background())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("N: got (%d), want (%d)", n, 1)
+	}
+
+	wantEvents := []publishedEvent{
+		{Type: "created", Source: "/users", Data: `{"id":1}`},
+		{Type: "created", Source: "/users", Data: `{"id":2}`},
+		{Type: "created", Source: "/users", Data: `{"id":3}`},
+	}
+	if !reflect.DeepEqual(rec.events, wantEvents) {
+		t.Fatalf("Events: got (%#v), want (%#v)", rec.events, wantEvents)
+	}
+	if n := countEvents(t, db); n != 0 {
+		t.Fatalf("Count: got (%d), want (%d)", n, 0)
+	}
+}
+
+func TestRelay_Run(t *testing.T) {
+	db := newDB(t)
+	pub := outbox.NewPublisher()
+
+	rec := &recorder{}
+	relay := outbox.NewRelay(db, rec, outbox.PollInterval(10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- relay.Run(ctx)
+	}()
+
+	err := outbox.WithTx(context.Background(), db, func(ctx context.Context) error {
+		return pub.Publish(ctx, "created", []byte(`{"id":1}`))
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for countEvents(t, db) != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, context.Canceled)
+	}
+
+	if len(rec.events) != 1 {
+		t.Fatalf("Events: got (%d), want (%d)", len(rec.events), 1)
+	}
+}
+
+// plainEvent is an event without any context attributes.
+type plainEvent struct {
+	typ  string
+	data interface{}
+}
+
+func (e plainEvent) Type() string      { return e.typ }
+func (e plainEvent) Data() interface{} { return e.data }
+
+func TestPublisher_DeadLetter(t *testing.T) {
+	db := newDB(t)
+	pub := outbox.NewPublisher()
+
+	h := eventpubsub.WrapHandler(
+		eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+			return eventpubsub.ErrInvalidData
+		}),
+		eventpubsub.DeadLetter(pub),
+	)
+
+	err := outbox.WithTx(context.Background(), db, func(ctx context.Context) error {
+		return h.Handle(ctx, plainEvent{typ: "created", data: []byte(`{"id":1}`)})
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	rec := &recorder{}
+	if _, err := outbox.NewRelay(db, rec).Relay(context.Background()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	wantEvents := []publishedEvent{
+		{Type: "created", Source: eventpubsub.DeadLetterSource, Data: `{"id":1}`},
+	}
+	if !reflect.DeepEqual(rec.events, wantEvents) {
+		t.Fatalf("Events: got (%#v), want (%#v)", rec.events, wantEvents)
+	}
+}
+
+func TestRelay_Discard(t *testing.T) {
+	db := newDB(t)
+	if _, err := db.Exec("INSERT INTO outbox (type, payload) VALUES ('created', 'not json')"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var errs []string
+	relay := outbox.NewRelay(db, &recorder{}, outbox.ErrorHandler(func(ctx context.Context, err error) {
+		errs = append(errs, err.Error())
+	}))
+
+	n, err := relay.Relay(context.Background())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("N: got (%d), want (%d)", n, 1)
+	}
+	if len(errs) != 1 || !strings.HasPrefix(errs[0], "outbox: discard event #1: ") {
+		t.Fatalf("Errors: got (%v), want an error for discarding event #1", errs)
+	}
+	if n := countEvents(t, db); n != 0 {
+		t.Fatalf("Count: got (%d), want (%d)", n, 0)
+	}
+}
@@ -0,0 +1,142 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/eventcodec"
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+// Relay forwards the events in the outbox table to the real publisher, in
+// the order of their ids.
+//
+// The events written by the same transaction are forwarded in the order
+// they were written. However, there is no such guarantee across concurrent
+// transactions: ids are assigned at insert time rather than commit time,
+// so an event with a lower id may be committed (and thus forwarded) after
+// the events with higher ids. Serialize the writing transactions if the
+// order across them matters.
+//
+// An event is deleted from the outbox table only after it has been
+// published successfully, so it may be published more than once (e.g. if
+// the relay crashes right after publishing), that is, the delivery is
+// at-least-once. Subscribers should therefore be idempotent.
+//
+// Only one relay should be running for the same outbox table at a time,
+// otherwise the events may be published in duplicate and out of order.
+type Relay struct {
+	db        *sql.DB
+	publisher eventpubsub.Publisher
+	opts      *Options
+
+	selectQuery string
+	deleteQuery string
+}
+
+// NewRelay creates a relay, which forwards the events in db to publisher.
+func NewRelay(db *sql.DB, publisher eventpubsub.Publisher, opts ...Option) *Relay {
+	options := newOptions(opts)
+	return &Relay{
+		db:        db,
+		publisher: publisher,
+		opts:      options,
+		selectQuery: fmt.Sprintf("SELECT id, payload FROM %s ORDER BY id LIMIT %d",
+			options.table, options.batchSize),
+		deleteQuery: fmt.Sprintf("DELETE FROM %s WHERE id = %s",
+			options.table, options.placeholder(1)),
+	}
+}
+
+// Run polls the outbox table and forwards the events periodically, until
+// ctx is done.
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.opts.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.relayAll(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// relayAll forwards the events batch by batch, until there are no more
+// events or an error occurs.
+func (r *Relay) relayAll(ctx context.Context) {
+	for {
+		n, err := r.Relay(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.opts.errorHandler(ctx, err)
+			}
+			return
+		}
+		if n < r.opts.batchSize {
+			return
+		}
+	}
+}
+
+// Relay forwards a batch of events, and returns the number of events that
+// have been removed from the outbox table (including the malformed ones,
+// which are discarded).
+//
+// Relay stops at the first event failed to be published, which will be
+// retried next time, so that the events are always published in order.
+func (r *Relay) Relay(ctx context.Context) (n int, err error) {
+	records, err := r.fetch(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, rec := range records {
+		event, err := codec.Decode(&eventcodec.Message{
+			Header: map[string]string{"content-type": eventcodec.StructuredContentType},
+			Body:   rec.payload,
+		})
+		if err != nil {
+			// The event is malformed and will never be published, just
+			// report and discard it to unblock the events after it.
+			r.opts.errorHandler(ctx, fmt.Errorf("outbox: discard event #%d: %w", rec.id, err))
+		} else if err := eventpubsub.Publish(ctx, r.publisher, event); err != nil {
+			return n, fmt.Errorf("outbox: publish event #%d: %w", rec.id, err)
+		}
+
+		if _, err := r.db.ExecContext(ctx, r.deleteQuery, rec.id); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+type record struct {
+	id      int64
+	payload []byte
+}
+
+func (r *Relay) fetch(ctx context.Context) (records []record, err error) {
+	rows, err := r.db.QueryContext(ctx, r.selectQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rec record
+		if err := rows.Scan(&rec.id, &rec.payload); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+
+	return records, rows.Err()
+}
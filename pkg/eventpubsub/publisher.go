@@ -7,3 +7,18 @@ import (
 type Publisher interface {
 	Publish(ctx context.Context, typ string, data interface{}) error
 }
+
+// EventPublisher is an optional interface for a Publisher, which is able to
+// publish the whole event, including all of its context attributes.
+type EventPublisher interface {
+	PublishEvent(ctx context.Context, event Event) error
+}
+
+// Publish publishes event by p. If p implements EventPublisher, the whole
+// event will be published, otherwise only the type and data will be.
+func Publish(ctx context.Context, p Publisher, event Event) error {
+	if ep, ok := p.(EventPublisher); ok {
+		return ep.PublishEvent(ctx, event)
+	}
+	return p.Publish(ctx, event.Type(), event.Data())
+}
@@ -0,0 +1,184 @@
+package eventpubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/werror/gcode"
+)
+
+// The extension attributes, which carry the failure metadata of an event
+// forwarded to the dead-letter publisher.
+const (
+	AttrDeadLetterReason   = "deadletterreason"   // The error message.
+	AttrDeadLetterCode     = "deadlettercode"     // The error code (see gcode.ToCodeMessage).
+	AttrDeadLetterAttempts = "deadletterattempts" // The number of attempts made.
+)
+
+// DeadLetterSource is the default attribute source of the events forwarded
+// to the dead-letter publisher.
+const DeadLetterSource = "/deadletter"
+
+// RetryOptions holds the options for retrying (and dead-lettering) the
+// handling of events.
+type RetryOptions struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	retryable      func(err error) bool
+	deadLetter     Publisher
+}
+
+// newRetryOptions creates the options with the defaults overridden by opts.
+func newRetryOptions(opts ...RetryOption) *RetryOptions {
+	options := &RetryOptions{
+		maxAttempts:    1,
+		initialBackoff: 100 * time.Millisecond,
+		maxBackoff:     10 * time.Second,
+		retryable:      IsRetryable,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+	return options
+}
+
+// RetryOption sets an optional parameter for RetryOptions.
+type RetryOption func(*RetryOptions)
+
+// MaxAttempts sets the maximum number of attempts (including the first one)
+// to handle an event. Defaults to 1, which means no retry.
+func MaxAttempts(n int) RetryOption {
+	return func(o *RetryOptions) {
+		if n > 0 {
+			o.maxAttempts = n
+		}
+	}
+}
+
+// Backoff sets the exponential backoff between attempts, which starts from
+// initial and doubles after each attempt, up to max. Defaults to starting
+// from 100ms, up to 10s.
+func Backoff(initial, max time.Duration) RetryOption {
+	return func(o *RetryOptions) {
+		o.initialBackoff = initial
+		o.maxBackoff = max
+	}
+}
+
+// Retryable sets the function to determine whether to retry on an error.
+// Defaults to IsRetryable.
+func Retryable(f func(err error) bool) RetryOption {
+	return func(o *RetryOptions) {
+		o.retryable = f
+	}
+}
+
+// DeadLetter sets the publisher, to which an event will be forwarded if it
+// can not be handled after all attempts (or on a non-retryable error).
+//
+// The forwarded event carries the failure metadata as extension attributes
+// (see AttrDeadLetterReason etc.), if the publisher implements EventPublisher.
+// The required attributes missing from the original event (e.g. a plain one
+// without any attributes) are filled in, with the source defaulting to
+// DeadLetterSource.
+func DeadLetter(p Publisher) RetryOption {
+	return func(o *RetryOptions) {
+		o.deadLetter = p
+	}
+}
+
+// IsRetryable reports whether err is retryable. Errors caused by invalid
+// events are not retryable, since retrying would fail again anyway. Other
+// errors are classified by gcode.IsRetryable.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrInvalidType) || errors.Is(err, ErrInvalidData) || errors.Is(err, ErrInvalidEvent) {
+		return false
+	}
+	return gcode.IsRetryable(err)
+}
+
+// WrapHandler wraps h with the given options, which adds retrying (and
+// dead-lettering) to h.
+func WrapHandler(h Handler, opts ...RetryOption) Handler {
+	options := newRetryOptions(opts...)
+	if options.maxAttempts == 1 && options.deadLetter == nil {
+		return h
+	}
+	return &retryHandler{h: h, opts: options}
+}
+
+type retryHandler struct {
+	h    Handler
+	opts *RetryOptions
+}
+
+// Handle implements Handler.
+func (r *retryHandler) Handle(ctx context.Context, event Event) error {
+	var err error
+	attempts := 0
+	backoff := r.opts.initialBackoff
+
+	for {
+		attempts++
+		if err = r.h.Handle(ctx, event); err == nil {
+			return nil
+		}
+		if attempts >= r.opts.maxAttempts || !r.opts.retryable(err) {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+
+		backoff *= 2
+		if backoff > r.opts.maxBackoff {
+			backoff = r.opts.maxBackoff
+		}
+	}
+
+	if r.opts.deadLetter == nil {
+		return err
+	}
+	if dlErr := r.sendToDeadLetter(ctx, event, err, attempts); dlErr != nil {
+		return fmt.Errorf("%w (failed to send to dead letter: %v)", err, dlErr)
+	}
+	return nil
+}
+
+func (r *retryHandler) sendToDeadLetter(ctx context.Context, event Event, err error, attempts int) error {
+	attrs := AttributesOf(event)
+
+	// Fill in the required attributes, if missing, to make a valid CloudEvent.
+	defaults := NewAttributes(attrs.Type, DeadLetterSource)
+	if attrs.ID == "" {
+		attrs.ID = defaults.ID
+	}
+	if attrs.Source == "" {
+		attrs.Source = defaults.Source
+	}
+	if attrs.SpecVersion == "" {
+		attrs.SpecVersion = defaults.SpecVersion
+	}
+	if attrs.Time.IsZero() {
+		attrs.Time = defaults.Time
+	}
+
+	// Copy the extensions to avoid modifying the original event.
+	extensions := make(map[string]interface{}, len(attrs.Extensions)+3)
+	for k, v := range attrs.Extensions {
+		extensions[k] = v
+	}
+	code, _ := gcode.ToCodeMessage(err)
+	extensions[AttrDeadLetterReason] = err.Error()
+	extensions[AttrDeadLetterCode] = code
+	extensions[AttrDeadLetterAttempts] = attempts
+	attrs.Extensions = extensions
+
+	return Publish(ctx, r.opts.deadLetter, NewCloudEvent(attrs, event.Data()))
+}
@@ -0,0 +1,139 @@
+package eventpubsub_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/RussellLuo/kun/pkg/werror"
+	"github.com/RussellLuo/kun/pkg/werror/gcode"
+)
+
+type deadLetter struct {
+	events []eventpubsub.Event
+}
+
+func (d *deadLetter) Publish(ctx context.Context, typ string, data interface{}) error {
+	panic("unreachable")
+}
+
+func (d *deadLetter) PublishEvent(ctx context.Context, event eventpubsub.Event) error {
+	d.events = append(d.events, event)
+	return nil
+}
+
+func TestWrapHandler(t *testing.T) {
+	errTemporary := werror.Wrap(gcode.ErrUnavailable, errors.New("temporary"))
+	errInvalid := werror.Wrap(gcode.ErrInvalidArgument, errors.New("invalid"))
+
+	tests := []struct {
+		name           string
+		inErrs         []error // errors returned by successive attempts
+		inOpts         []eventpubsub.RetryOption
+		wantAttempts   int
+		wantErrStr     string
+		wantDeadLetter map[string]interface{}
+	}{
+		{
+			name:         "no retry by default",
+			inErrs:       []error{errTemporary, nil},
+			wantAttempts: 1,
+			wantErrStr:   "temporary",
+		},
+		{
+			name:         "succeed after retries",
+			inErrs:       []error{errTemporary, errTemporary, nil},
+			inOpts:       []eventpubsub.RetryOption{eventpubsub.MaxAttempts(3)},
+			wantAttempts: 3,
+		},
+		{
+			name:         "attempts exhausted",
+			inErrs:       []error{errTemporary, errTemporary, errTemporary},
+			inOpts:       []eventpubsub.RetryOption{eventpubsub.MaxAttempts(2)},
+			wantAttempts: 2,
+			wantErrStr:   "temporary",
+		},
+		{
+			name:         "non-retryable",
+			inErrs:       []error{errInvalid, nil},
+			inOpts:       []eventpubsub.RetryOption{eventpubsub.MaxAttempts(3)},
+			wantAttempts: 1,
+			wantErrStr:   "invalid",
+		},
+		{
+			name:   "custom retryable",
+			inErrs: []error{errInvalid, nil},
+			inOpts: []eventpubsub.RetryOption{
+				eventpubsub.MaxAttempts(3),
+				eventpubsub.Retryable(func(err error) bool { return true }),
+			},
+			wantAttempts: 2,
+		},
+		{
+			name:   "dead letter",
+			inErrs: []error{errTemporary, errTemporary},
+			inOpts: []eventpubsub.RetryOption{
+				eventpubsub.MaxAttempts(2),
+			},
+			wantAttempts: 2,
+			wantDeadLetter: map[string]interface{}{
+				"tenant":                           "acme",
+				eventpubsub.AttrDeadLetterReason:   "temporary",
+				eventpubsub.AttrDeadLetterCode:     "Unavailable",
+				eventpubsub.AttrDeadLetterAttempts: 2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			h := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+				err := tt.inErrs[attempts]
+				attempts++
+				return err
+			})
+
+			opts := append(tt.inOpts, eventpubsub.Backoff(time.Millisecond, 2*time.Millisecond))
+			dl := new(deadLetter)
+			if tt.wantDeadLetter != nil {
+				opts = append(opts, eventpubsub.DeadLetter(dl))
+			}
+
+			attrs := eventpubsub.NewAttributes("created", "/test")
+			attrs.Set("tenant", "acme")
+			event := eventpubsub.NewCloudEvent(attrs, []byte(`{"id":1}`))
+
+			err := eventpubsub.WrapHandler(h, opts...).Handle(context.Background(), event)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Fatalf("Attempts: got (%d), want (%d)", attempts, tt.wantAttempts)
+			}
+
+			if tt.wantDeadLetter == nil {
+				if len(dl.events) != 0 {
+					t.Fatalf("DeadLetter: got (%d events), want (0 events)", len(dl.events))
+				}
+				return
+			}
+			if len(dl.events) != 1 {
+				t.Fatalf("DeadLetter: got (%d events), want (1 event)", len(dl.events))
+			}
+			gotAttrs := eventpubsub.AttributesOf(dl.events[0])
+			if !reflect.DeepEqual(gotAttrs.Extensions, tt.wantDeadLetter) {
+				t.Fatalf("Extensions: got (%v), want (%v)", gotAttrs.Extensions, tt.wantDeadLetter)
+			}
+			if gotAttrs.ID != attrs.ID || string(dl.events[0].Data().([]byte)) != `{"id":1}` {
+				t.Fatalf("Event: got (%+v), want the original event", gotAttrs)
+			}
+			if len(attrs.Extensions) != 1 {
+				t.Fatalf("Original extensions modified: %v", attrs.Extensions)
+			}
+		})
+	}
+}
@@ -10,6 +10,9 @@ import (
 var (
 	ErrInvalidType = errors.New("invalid event type")
 	ErrInvalidData = errors.New("invalid event data")
+	// ErrInvalidEvent indicates that the event is malformed, e.g. a required
+	// context attribute is missing.
+	ErrInvalidEvent = errors.New("invalid event")
 )
 
 type Event interface {
@@ -21,6 +24,14 @@ type Handler interface {
 	Handle(ctx context.Context, event Event) error
 }
 
+// HandlerFunc is an adapter to allow the use of ordinary functions as Handlers.
+type HandlerFunc func(ctx context.Context, event Event) error
+
+// Handle implements Handler.
+func (f HandlerFunc) Handle(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
 type HandlerSet struct {
 	set map[string]Handler
 }
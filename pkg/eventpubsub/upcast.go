@@ -0,0 +1,94 @@
+package eventpubsub
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/RussellLuo/kun/pkg/werror"
+)
+
+// AttrDataVersion is the extension attribute, which carries the version of
+// the event data. An event without this attribute is considered to be of
+// version 1.
+const AttrDataVersion = "dataversion"
+
+// DataVersion returns the version of the data of event.
+func DataVersion(event Event) (int, error) {
+	s := StringAttribute(event, AttrDataVersion)
+	if s == "" {
+		return 1, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 1 {
+		return 0, werror.Wrapf(ErrInvalidData, "invalid %s %q of event %q", AttrDataVersion, s, event.Type())
+	}
+	return v, nil
+}
+
+// Upcaster converts the event data of a version into the one of the next
+// version. The data is typically in its encoded form (e.g. JSON bytes).
+type Upcaster func(data interface{}) (interface{}, error)
+
+// Upcasters is a registry of upcasters, which move the event data of old
+// versions forward.
+type Upcasters struct {
+	mu sync.RWMutex
+	m  map[string]map[int]Upcaster // event types => from versions => upcasters
+}
+
+func NewUpcasters() *Upcasters {
+	return &Upcasters{m: make(map[string]map[int]Upcaster)}
+}
+
+// Register registers the upcaster f, which converts the data of the events
+// of type typ from version from to version from+1.
+func (u *Upcasters) Register(typ string, from int, f Upcaster) *Upcasters {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.m[typ] == nil {
+		u.m[typ] = make(map[int]Upcaster)
+	}
+	u.m[typ][from] = f
+	return u
+}
+
+// Upcast returns the data of event, which has been moved forward to the
+// given version step by step, by the registered upcasters.
+//
+// Upcast fails with an error wrapping ErrInvalidData if the event is of an
+// unknown future version, or if any upcaster is missing. A nil Upcasters
+// has no upcasters registered.
+func (u *Upcasters) Upcast(event Event, version int) (interface{}, error) {
+	v, err := DataVersion(event)
+	if err != nil {
+		return nil, err
+	}
+	if v > version {
+		return nil, werror.Wrapf(ErrInvalidData, "unsupported version %d of event %q (the latest known version is %d)", v, event.Type(), version)
+	}
+
+	data := event.Data()
+	for ; v < version; v++ {
+		f := u.get(event.Type(), v)
+		if f == nil {
+			return nil, werror.Wrapf(ErrInvalidData, "no upcaster registered for event %q of version %d", event.Type(), v)
+		}
+		if data, err = f(data); err != nil {
+			return nil, werror.Wrapf(ErrInvalidData, "failed to upcast event %q from version %d: %v", event.Type(), v, err)
+		}
+	}
+
+	return data, nil
+}
+
+func (u *Upcasters) get(typ string, from int) Upcaster {
+	if u == nil {
+		return nil
+	}
+
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.m[typ][from]
+}
@@ -0,0 +1,115 @@
+package eventpubsub_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+func TestUpcasters_Upcast(t *testing.T) {
+	appendStep := func(step string) eventpubsub.Upcaster {
+		return func(data interface{}) (interface{}, error) {
+			return data.(string) + "," + step, nil
+		}
+	}
+	upcasters := eventpubsub.NewUpcasters().
+		Register("created", 1, appendStep("v2")).
+		Register("created", 2, appendStep("v3")).
+		Register("updated", 1, func(data interface{}) (interface{}, error) {
+			return nil, errors.New("bad data")
+		})
+
+	newEvent := func(typ string, version interface{}) eventpubsub.Event {
+		attrs := eventpubsub.NewAttributes(typ, "/test")
+		attrs.Set(eventpubsub.AttrDataVersion, version)
+		return eventpubsub.NewCloudEvent(attrs, "v?")
+	}
+
+	tests := []struct {
+		name       string
+		inEvent    eventpubsub.Event
+		inVersion  int
+		wantData   interface{}
+		wantErrStr string
+	}{
+		{
+			name:      "no version",
+			inEvent:   newEvent("created", nil),
+			inVersion: 3,
+			wantData:  "v?,v2,v3",
+		},
+		{
+			name:      "old version",
+			inEvent:   newEvent("created", 2),
+			inVersion: 3,
+			wantData:  "v?,v3",
+		},
+		{
+			name:      "string version",
+			inEvent:   newEvent("created", "3"),
+			inVersion: 3,
+			wantData:  "v?",
+		},
+		{
+			name:       "future version",
+			inEvent:    newEvent("created", 4),
+			inVersion:  3,
+			wantErrStr: `unsupported version 4 of event "created" (the latest known version is 3)`,
+		},
+		{
+			name:       "invalid version",
+			inEvent:    newEvent("created", "v1"),
+			inVersion:  3,
+			wantErrStr: `invalid dataversion "v1" of event "created"`,
+		},
+		{
+			name:       "missing upcaster",
+			inEvent:    newEvent("deleted", 1),
+			inVersion:  2,
+			wantErrStr: `no upcaster registered for event "deleted" of version 1`,
+		},
+		{
+			name:       "upcaster failure",
+			inEvent:    newEvent("updated", 1),
+			inVersion:  2,
+			wantErrStr: `failed to upcast event "updated" from version 1: bad data`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := upcasters.Upcast(tt.inEvent, tt.inVersion)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if err != nil && !errors.Is(err, eventpubsub.ErrInvalidData) {
+				t.Fatalf("Err: got (%#v), want wrapping ErrInvalidData", err)
+			}
+			if !reflect.DeepEqual(data, tt.wantData) {
+				t.Fatalf("Data: got (%#v), want (%#v)", data, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestUpcasters_Nil(t *testing.T) {
+	var upcasters *eventpubsub.Upcasters
+
+	attrs := eventpubsub.NewAttributes("created", "/test")
+	data, err := upcasters.Upcast(eventpubsub.NewCloudEvent(attrs, "v1"), 1)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if data != "v1" {
+		t.Fatalf("Data: got (%#v), want (%#v)", data, "v1")
+	}
+
+	attrs.Set(eventpubsub.AttrDataVersion, 1)
+	_, err = upcasters.Upcast(eventpubsub.NewCloudEvent(attrs, "v1"), 2)
+	if err == nil || !strings.HasPrefix(err.Error(), "no upcaster registered") {
+		t.Fatalf("Err: got (%#v), want no upcaster registered", err)
+	}
+}
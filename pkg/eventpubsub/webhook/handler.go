@@ -0,0 +1,91 @@
+// Package webhook provides a transport, which delivers and receives events
+// over HTTP as signed webhook requests.
+//
+// Events are transferred as CloudEvents messages (see eventcodec.CloudEvents),
+// and signed by HMAC-SHA256 (see SignatureHeader).
+package webhook
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/RussellLuo/kun/pkg/eventcodec"
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+)
+
+// MaxBodySize is the maximum size of the body of a webhook request.
+const MaxBodySize = 1 << 20
+
+var codec = eventcodec.CloudEvents{}
+
+// NewHandler creates an HTTP handler, which receives the events pushed as
+// webhook requests, verifies their signatures by v, and then handles them
+// by h.
+//
+// The status code of the response is:
+//
+//   - 204 if the event has been handled successfully.
+//   - 401 if the signature is invalid, expired or replayed.
+//   - 400 if the event is malformed, or fails with a non-retryable error
+//     (see eventpubsub.IsRetryable).
+//   - 500 if the event fails with a retryable error, in which case the event
+//     is expected to be redelivered.
+func NewHandler(h eventpubsub.Handler, v *Verifier) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		header := messageHeader(r.Header)
+		if err := v.Verify(r.Context(), r.Header.Get(SignatureHeader), header, body); err != nil {
+			status := http.StatusUnauthorized
+			if !errors.Is(err, ErrInvalidSignature) && !errors.Is(err, ErrExpired) && !errors.Is(err, ErrReplayed) {
+				status = http.StatusInternalServerError
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		event, err := codec.Decode(&eventcodec.Message{
+			Header: header,
+			Body:   body,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := h.Handle(r.Context(), event); err != nil {
+			status := http.StatusInternalServerError
+			if !eventpubsub.IsRetryable(err) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// messageHeader converts the HTTP header h into the header of a message.
+func messageHeader(h http.Header) map[string]string {
+	m := make(map[string]string)
+	for key, values := range h {
+		key = strings.ToLower(key)
+		if SignedHeaders(key) {
+			m[key] = values[0]
+		}
+	}
+	return m
+}
@@ -0,0 +1,350 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/eventcodec"
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/RussellLuo/kun/pkg/syncutil"
+)
+
+// ErrClosed is returned when publishing events to a closed publisher.
+var ErrClosed = errors.New("webhook: publisher closed")
+
+type Options struct {
+	client       *http.Client
+	mode         eventcodec.Mode
+	source       string
+	errorHandler func(ctx context.Context, event eventpubsub.Event, err error)
+}
+
+// Option sets an optional parameter for Options.
+type Option func(*Options)
+
+// Client sets the HTTP client used to send webhook requests, whose timeout
+// is the deadline of each attempt. Defaults to a client with a timeout of 10s.
+func Client(c *http.Client) Option {
+	return func(o *Options) {
+		o.client = c
+	}
+}
+
+// Mode sets the content mode of the webhook requests. Defaults to
+// eventcodec.ModeStructured.
+func Mode(m eventcodec.Mode) Option {
+	return func(o *Options) {
+		o.mode = m
+	}
+}
+
+// Source sets the CloudEvents attribute source of the events published by
+// Publisher.Publish. Defaults to "/webhook".
+func Source(source string) Option {
+	return func(o *Options) {
+		o.source = source
+	}
+}
+
+// ErrorHandler sets the handler for the events that failed to be delivered
+// to an endpoint (i.e. after all attempts, or on a non-retryable failure).
+// The errors are ignored by default.
+func ErrorHandler(h func(ctx context.Context, event eventpubsub.Event, err error)) Option {
+	return func(o *Options) {
+		o.errorHandler = h
+	}
+}
+
+type EndpointOptions struct {
+	secrets        [][]byte
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	queueSize      int
+}
+
+// EndpointOption sets an optional parameter for EndpointOptions.
+type EndpointOption func(*EndpointOptions)
+
+// AdditionalSecrets sets the secrets, by which the webhook requests sent to
+// the endpoint are also signed, besides the one given to Register.
+//
+// It's typically used during secret rotation: register the endpoint with
+// the new secret and the old one as an additional secret, until the
+// receiver has switched to the new secret.
+func AdditionalSecrets(secrets ...[]byte) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.secrets = append(o.secrets, secrets...)
+	}
+}
+
+// MaxAttempts sets the maximum number of attempts (including the first one)
+// to deliver an event to the endpoint. Defaults to 3.
+func MaxAttempts(n int) EndpointOption {
+	return func(o *EndpointOptions) {
+		if n > 0 {
+			o.maxAttempts = n
+		}
+	}
+}
+
+// Backoff sets the exponential backoff between attempts to deliver an event
+// to the endpoint, which starts from initial and doubles after each attempt,
+// up to max. Defaults to starting from 1s, up to 1m.
+func Backoff(initial, max time.Duration) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.initialBackoff = initial
+		o.maxBackoff = max
+	}
+}
+
+// QueueSize sets the capacity of the queue, which buffers events not yet
+// delivered to the endpoint. Defaults to 64.
+func QueueSize(n int) EndpointOption {
+	return func(o *EndpointOptions) {
+		if n >= 0 {
+			o.queueSize = n
+		}
+	}
+}
+
+type endpoint struct {
+	url     string
+	secrets [][]byte
+	opts    *EndpointOptions
+	queue   chan *delivery
+}
+
+type delivery struct {
+	event eventpubsub.Event
+	msg   *eventcodec.Message
+}
+
+// Publisher delivers events to the registered endpoints as signed webhook
+// requests. It implements eventpubsub.Publisher (and eventpubsub.EventPublisher).
+//
+// The data of the events must have been encoded into bytes (as done by the
+// generated EventPublisher).
+//
+// The events are delivered in the background, by a worker for each endpoint,
+// and the publisher must be closed (see Close) to wait for the pending
+// deliveries.
+type Publisher struct {
+	opts *Options
+
+	// ctx is the context of the deliveries, which is canceled if Close
+	// gives up waiting for them.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	mu        sync.RWMutex
+	endpoints []*endpoint
+	closed    bool
+}
+
+// NewPublisher creates a webhook publisher.
+func NewPublisher(opts ...Option) *Publisher {
+	options := &Options{
+		client:       &http.Client{Timeout: 10 * time.Second},
+		mode:         eventcodec.ModeStructured,
+		source:       "/webhook",
+		errorHandler: func(ctx context.Context, event eventpubsub.Event, err error) {},
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Publisher{
+		opts:    options,
+		ctx:     ctx,
+		cancel:  cancel,
+		closing: make(chan struct{}),
+	}
+}
+
+// Register registers an endpoint, to which the events will be delivered.
+// The webhook requests sent to the endpoint are signed by secret (and the
+// additional secrets, if any, see AdditionalSecrets).
+//
+// Registering an endpoint to a closed publisher has no effect.
+func (p *Publisher) Register(url string, secret []byte, opts ...EndpointOption) {
+	options := &EndpointOptions{
+		maxAttempts:    3,
+		initialBackoff: time.Second,
+		maxBackoff:     time.Minute,
+		queueSize:      64,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	e := &endpoint{
+		url:     url,
+		secrets: append([][]byte{secret}, options.secrets...),
+		opts:    options,
+		queue:   make(chan *delivery, options.queueSize),
+	}
+	p.endpoints = append(p.endpoints, e)
+
+	p.wg.Add(1)
+	go p.work(e)
+}
+
+// Publish implements eventpubsub.Publisher.
+func (p *Publisher) Publish(ctx context.Context, typ string, data interface{}) error {
+	attrs := eventpubsub.NewAttributes(typ, p.opts.source)
+	return p.PublishEvent(ctx, eventpubsub.NewCloudEvent(attrs, data))
+}
+
+// PublishEvent implements eventpubsub.EventPublisher.
+//
+// PublishEvent returns once event has been enqueued for delivery to all
+// the endpoints, without waiting for the webhook requests. The events are
+// delivered to each endpoint in order, by following its own retries and
+// backoff, and the failures are reported to the error handler (see
+// ErrorHandler).
+//
+// If the queue of an endpoint is full (see QueueSize), PublishEvent blocks
+// until the queue has room for event, ctx is done (in which case ctx.Err()
+// is returned), or the publisher is closed. Note that event may have been
+// enqueued for some of the endpoints when an error is returned.
+func (p *Publisher) PublishEvent(ctx context.Context, event eventpubsub.Event) error {
+	msg, err := eventcodec.CloudEvents{Mode: p.opts.mode}.Encode(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	d := &delivery{event: event, msg: msg}
+	for _, e := range p.endpoints {
+		select {
+		case e.queue <- d:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.closing:
+			return ErrClosed
+		}
+	}
+	return nil
+}
+
+// Close closes the publisher, and waits until all the pending deliveries
+// have finished. If ctx is done before that, the pending deliveries will be
+// canceled, and ctx.Err() will be returned.
+func (p *Publisher) Close(ctx context.Context) error {
+	// Unblock the publishing waiting for the queues, before acquiring the
+	// lock held by them.
+	p.closeOnce.Do(func() {
+		close(p.closing)
+	})
+
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, e := range p.endpoints {
+			close(e.queue)
+		}
+	}
+	p.mu.Unlock()
+
+	err := syncutil.Wait(ctx, &p.wg)
+	p.cancel()
+	if err != nil {
+		// Wait for the canceled deliveries to return.
+		p.wg.Wait()
+	}
+	return err
+}
+
+// work delivers the events in the queue of endpoint e, until the queue is
+// closed.
+func (p *Publisher) work(e *endpoint) {
+	defer p.wg.Done()
+
+	for d := range e.queue {
+		if err := p.deliver(p.ctx, e, d.msg); err != nil {
+			p.opts.errorHandler(p.ctx, d.event, fmt.Errorf("webhook: failed to deliver event %q to %s: %w", d.event.Type(), e.url, err))
+		}
+	}
+}
+
+// deliver delivers msg to endpoint e, with retries.
+func (p *Publisher) deliver(ctx context.Context, e *endpoint, msg *eventcodec.Message) error {
+	backoff := e.opts.initialBackoff
+
+	for attempt := 1; ; attempt++ {
+		retryable, err := p.send(ctx, e, msg)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= e.opts.maxAttempts {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+
+		backoff *= 2
+		if backoff > e.opts.maxBackoff {
+			backoff = e.opts.maxBackoff
+		}
+	}
+}
+
+// send sends msg to endpoint e, and reports whether the failure (if any)
+// is retryable.
+func (p *Publisher) send(ctx context.Context, e *endpoint, msg *eventcodec.Message) (retryable bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(msg.Body))
+	if err != nil {
+		return false, err
+	}
+	for key, value := range msg.Header {
+		req.Header.Set(key, value)
+	}
+	// Sign the request right before sending it, to get a fresh timestamp
+	// for each attempt.
+	req.Header.Set(SignatureHeader, SignMulti(e.secrets, time.Now(), msg.Header, msg.Body))
+
+	resp, err := p.opts.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+}
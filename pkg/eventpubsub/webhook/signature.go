@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/eventcodec"
+	"github.com/RussellLuo/kun/pkg/eventpubsub/dedup"
+)
+
+// SignatureHeader is the HTTP header, which carries the signature of a
+// webhook request in the form of `t=<timestamp>,v1=<signature>`, where:
+//
+//   - <timestamp> is the Unix time (in seconds) at which the request is signed.
+//   - <signature> is the hex-encoded HMAC-SHA256 of
+//     `<timestamp>.<headers><body>`, where <headers> are the signed headers
+//     (see SignedHeaders) in the form of `<key>:<value>\n`, sorted by key.
+//
+// There may be multiple v1 signatures (e.g. signed by both the old and the
+// new secrets during secret rotation, see AdditionalSecrets), and the
+// request is considered valid if any of them matches.
+const SignatureHeader = "Webhook-Signature"
+
+var (
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	ErrExpired          = errors.New("webhook: timestamp outside the tolerance window")
+	ErrReplayed         = errors.New("webhook: replayed request")
+)
+
+// SignedHeaders reports whether the header key (in lower case) is signed,
+// which is true for Content-Type and the CloudEvents headers in binary mode
+// (i.e. ce-*), since they carry the identity of the event.
+func SignedHeaders(key string) bool {
+	return key == "content-type" || strings.HasPrefix(key, eventcodec.BinaryHeaderPrefix)
+}
+
+// Sign returns the value of SignatureHeader for the request consisting of
+// header and body, which is signed by secret at time t.
+func Sign(secret []byte, t time.Time, header map[string]string, body []byte) string {
+	return SignMulti([][]byte{secret}, t, header, body)
+}
+
+// SignMulti is like Sign, but the value carries a v1 signature for each of
+// secrets, in the same order.
+func SignMulti(secrets [][]byte, t time.Time, header map[string]string, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	var b strings.Builder
+	b.WriteString("t=" + timestamp)
+	for _, secret := range secrets {
+		b.WriteString(",v1=" + computeSignature(secret, timestamp, header, body))
+	}
+	return b.String()
+}
+
+func computeSignature(secret []byte, timestamp string, header map[string]string, body []byte) string {
+	signed := make(map[string]string)
+	var keys []string
+	for key, value := range header {
+		key = strings.ToLower(key)
+		if SignedHeaders(key) {
+			signed[key] = value
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	for _, key := range keys {
+		mac.Write([]byte(key + ":" + signed[key] + "\n"))
+	}
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type VerifierOptions struct {
+	tolerance   time.Duration
+	replayStore dedup.Store
+	now         func() time.Time
+}
+
+// VerifierOption sets an optional parameter for VerifierOptions.
+type VerifierOption func(*VerifierOptions)
+
+// Tolerance sets the maximum difference allowed between the signing time of
+// a request and the current time. Defaults to 5 minutes.
+func Tolerance(d time.Duration) VerifierOption {
+	return func(o *VerifierOptions) {
+		if d > 0 {
+			o.tolerance = d
+		}
+	}
+}
+
+// ReplayStore sets the store, which records the signatures verified within
+// the tolerance window, to reject the replayed requests. The replay check
+// is disabled by default, in which case a request may be replayed within
+// the tolerance window.
+//
+// Since a signature is accepted as long as its timestamp is within Tolerance
+// of the current time, in either direction, the store must keep each entry
+// for at least twice the tolerance, otherwise a request replayed after its
+// entry has gone will be accepted again. Note that dedup.MemoryStore evicts
+// entries by capacity rather than by time, so its capacity must exceed the
+// number of requests that can arrive within that period.
+func ReplayStore(s dedup.Store) VerifierOption {
+	return func(o *VerifierOptions) {
+		o.replayStore = s
+	}
+}
+
+// Now sets the function to get the current time. Defaults to time.Now.
+func Now(f func() time.Time) VerifierOption {
+	return func(o *VerifierOptions) {
+		o.now = f
+	}
+}
+
+// Verifier verifies the signatures of webhook requests.
+type Verifier struct {
+	secret []byte
+	opts   *VerifierOptions
+}
+
+// NewVerifier creates a verifier, which verifies signatures by secret.
+func NewVerifier(secret []byte, opts ...VerifierOption) *Verifier {
+	options := &VerifierOptions{
+		tolerance: 5 * time.Minute,
+		now:       time.Now,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	return &Verifier{
+		secret: secret,
+		opts:   options,
+	}
+}
+
+// Verify verifies signature, the value of SignatureHeader, for the request
+// consisting of header and body.
+func (v *Verifier) Verify(ctx context.Context, signature string, header map[string]string, body []byte) error {
+	var timestamp string
+	var signatures []string
+	for _, pair := range strings.Split(signature, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if diff := v.opts.now().Sub(time.Unix(sec, 0)); diff > v.opts.tolerance || diff < -v.opts.tolerance {
+		return ErrExpired
+	}
+
+	expected := computeSignature(v.secret, timestamp, header, body)
+	var matched string
+	for _, s := range signatures {
+		if hmac.Equal([]byte(s), []byte(expected)) {
+			matched = s
+			break
+		}
+	}
+	if matched == "" {
+		return ErrInvalidSignature
+	}
+
+	if v.opts.replayStore != nil {
+		added, err := v.opts.replayStore.Add(ctx, timestamp+"."+matched)
+		if err != nil {
+			return err
+		}
+		if !added {
+			return ErrReplayed
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package webhook_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/eventpubsub/dedup"
+	"github.com/RussellLuo/kun/pkg/eventpubsub/webhook"
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	secret := []byte("secret")
+	header := map[string]string{"ce-id": "1", "ce-type": "created", "ce-source": "/test"}
+	body := []byte(`{"id":"1"}`)
+	now := time.Unix(1600000000, 0)
+
+	tests := []struct {
+		name        string
+		inSignature string
+		inHeader    map[string]string
+		inBody      []byte
+		wantErrStr  string
+	}{
+		{
+			name:        "valid",
+			inSignature: webhook.Sign(secret, now, header, body),
+			inBody:      body,
+		},
+		{
+			name:        "secret rotation, signed by the old secret",
+			inSignature: webhook.SignMulti([][]byte{[]byte("new"), secret}, now, header, body),
+			inBody:      body,
+		},
+		{
+			name:        "secret rotation, signed by the new secret",
+			inSignature: webhook.SignMulti([][]byte{secret, []byte("old")}, now, header, body),
+			inBody:      body,
+		},
+		{
+			name:        "within tolerance",
+			inSignature: webhook.Sign(secret, now.Add(-4*time.Minute), header, body),
+			inBody:      body,
+		},
+		{
+			name:        "expired",
+			inSignature: webhook.Sign(secret, now.Add(-6*time.Minute), header, body),
+			inBody:      body,
+			wantErrStr:  "webhook: timestamp outside the tolerance window",
+		},
+		{
+			name:        "from the future",
+			inSignature: webhook.Sign(secret, now.Add(6*time.Minute), header, body),
+			inBody:      body,
+			wantErrStr:  "webhook: timestamp outside the tolerance window",
+		},
+		{
+			name:        "tampered body",
+			inSignature: webhook.Sign(secret, now, header, body),
+			inBody:      []byte(`{"id":"2"}`),
+			wantErrStr:  "webhook: invalid signature",
+		},
+		{
+			name:        "wrong secret",
+			inSignature: webhook.Sign([]byte("other"), now, header, body),
+			inBody:      body,
+			wantErrStr:  "webhook: invalid signature",
+		},
+		{
+			name:        "missing signature",
+			inSignature: fmt.Sprintf("t=%d", now.Unix()),
+			inBody:      body,
+			wantErrStr:  "webhook: invalid signature",
+		},
+		{
+			name:        "malformed timestamp",
+			inSignature: "t=abc,v1=deadbeef",
+			inBody:      body,
+			wantErrStr:  "webhook: invalid signature",
+		},
+		{
+			name:        "empty header",
+			inSignature: "",
+			inBody:      body,
+			wantErrStr:  "webhook: invalid signature",
+		},
+	}
+
+	v := webhook.NewVerifier(secret, webhook.Now(func() time.Time { return now }))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inHeader := tt.inHeader
+			if inHeader == nil {
+				inHeader = header
+			}
+			err := v.Verify(context.Background(), tt.inSignature, inHeader, tt.inBody)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+		})
+	}
+}
+
+func TestVerifier_Replay(t *testing.T) {
+	secret := []byte("secret")
+	body := []byte(`{"id":"1"}`)
+	now := time.Unix(1600000000, 0)
+
+	v := webhook.NewVerifier(secret,
+		webhook.Now(func() time.Time { return now }),
+		webhook.ReplayStore(dedup.NewMemoryStore(10)),
+	)
+
+	signature := webhook.Sign(secret, now, nil, body)
+	if err := v.Verify(context.Background(), signature, nil, body); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := v.Verify(context.Background(), signature, nil, body); err != webhook.ErrReplayed {
+		t.Fatalf("Err: got (%#v), want (%#v)", err, webhook.ErrReplayed)
+	}
+
+	// A request signed at a different time is not a replay.
+	signature = webhook.Sign(secret, now.Add(time.Second), nil, body)
+	if err := v.Verify(context.Background(), signature, nil, body); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
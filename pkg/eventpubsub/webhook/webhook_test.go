@@ -0,0 +1,381 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/eventcodec"
+	"github.com/RussellLuo/kun/pkg/eventpubsub"
+	"github.com/RussellLuo/kun/pkg/eventpubsub/webhook"
+	"github.com/RussellLuo/kun/pkg/werror"
+)
+
+func TestPublisher_PublishEvent(t *testing.T) {
+	secret := []byte("secret")
+
+	for _, mode := range []eventcodec.Mode{eventcodec.ModeStructured, eventcodec.ModeBinary} {
+		var mu sync.Mutex
+		var got []eventpubsub.Event
+		h := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, event)
+			return nil
+		})
+		srv := httptest.NewServer(webhook.NewHandler(h, webhook.NewVerifier(secret)))
+
+		p := webhook.NewPublisher(webhook.Mode(mode))
+		p.Register(srv.URL, secret)
+
+		attrs := eventpubsub.NewAttributes("created", "/test")
+		attrs.DataContentType = "application/json"
+		if err := p.PublishEvent(context.Background(), eventpubsub.NewCloudEvent(attrs, []byte(`{"id":1}`))); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := p.Close(context.Background()); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		srv.Close()
+
+		if len(got) != 1 {
+			t.Fatalf("Events: got (%d), want (1)", len(got))
+		}
+		gotAttrs := got[0].(*eventpubsub.CloudEvent).Attributes()
+		if gotAttrs.Type != "created" || gotAttrs.ID != attrs.ID {
+			t.Fatalf("Event: got (%s, %s), want (created, %s)", gotAttrs.Type, gotAttrs.ID, attrs.ID)
+		}
+		if data := string(got[0].Data().([]byte)); data != `{"id":1}` {
+			t.Fatalf("Data: got (%s), want (%s)", data, `{"id":1}`)
+		}
+	}
+}
+
+func TestPublisher_AdditionalSecrets(t *testing.T) {
+	oldSecret, newSecret := []byte("old"), []byte("new")
+
+	for _, secret := range [][]byte{oldSecret, newSecret} {
+		var got int
+		h := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+			got++
+			return nil
+		})
+		srv := httptest.NewServer(webhook.NewHandler(h, webhook.NewVerifier(secret)))
+
+		var err error
+		p := webhook.NewPublisher(webhook.ErrorHandler(func(ctx context.Context, event eventpubsub.Event, e error) {
+			err = e
+		}))
+		p.Register(srv.URL, newSecret, webhook.AdditionalSecrets(oldSecret), webhook.MaxAttempts(1))
+
+		if err := p.Publish(context.Background(), "created", []byte(`{}`)); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := p.Close(context.Background()); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		srv.Close()
+
+		if err != nil {
+			t.Fatalf("Err: got (%#v), want (<nil>)", err)
+		}
+		if got != 1 {
+			t.Fatalf("Events: got (%d), want (1)", got)
+		}
+	}
+}
+
+func TestPublisher_Retry(t *testing.T) {
+	secret := []byte("secret")
+
+	cases := []struct {
+		name       string
+		inStatuses []int // The status codes returned by the endpoint in order.
+		wantCalls  int
+		wantErrStr string
+	}{
+		{
+			name:       "success after retries",
+			inStatuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusNoContent},
+			wantCalls:  3,
+		},
+		{
+			name:       "retries exhausted",
+			inStatuses: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+			wantCalls:  3,
+			wantErrStr: `webhook: failed to deliver event "created" to <url>: unexpected status 500`,
+		},
+		{
+			name:       "non-retryable",
+			inStatuses: []int{http.StatusBadRequest},
+			wantCalls:  1,
+			wantErrStr: `webhook: failed to deliver event "created" to <url>: unexpected status 400`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(c.inStatuses[calls-1])
+			}))
+			defer srv.Close()
+
+			var err error
+			p := webhook.NewPublisher(webhook.ErrorHandler(func(ctx context.Context, event eventpubsub.Event, e error) {
+				err = e
+			}))
+			p.Register(srv.URL, secret, webhook.Backoff(time.Millisecond, time.Millisecond))
+
+			if err := p.Publish(context.Background(), "created", []byte(`{}`)); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if err := p.Close(context.Background()); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			wantErrStr := strings.ReplaceAll(c.wantErrStr, "<url>", srv.URL)
+			if (err == nil && wantErrStr != "") || (err != nil && err.Error() != wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, wantErrStr)
+			}
+			if calls != c.wantCalls {
+				t.Fatalf("Calls: got (%d), want (%d)", calls, c.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPublisher_Close(t *testing.T) {
+	secret := []byte("secret")
+
+	calls := make(chan struct{}, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls <- struct{}{}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	var err error
+	p := webhook.NewPublisher(webhook.ErrorHandler(func(ctx context.Context, event eventpubsub.Event, e error) {
+		err = e
+	}))
+	p.Register(srv.URL, secret, webhook.MaxAttempts(10), webhook.Backoff(time.Hour, time.Hour))
+
+	// Publish returns without waiting for the retries.
+	start := time.Now()
+	if err := p.Publish(context.Background(), "created", []byte(`{}`)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	<-calls
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Publish: took %v", d)
+	}
+
+	// Close gives up waiting for the retries once ctx is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Close(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Err: got (%v), want (%v)", err, context.DeadlineExceeded)
+	}
+	if err == nil || !strings.HasSuffix(err.Error(), "unexpected status 500") {
+		t.Fatalf("Err: got (%v)", err)
+	}
+
+	if err := p.Publish(context.Background(), "created", []byte(`{}`)); err != webhook.ErrClosed {
+		t.Fatalf("Err: got (%v), want (%v)", err, webhook.ErrClosed)
+	}
+}
+
+func TestPublisher_QueueFull(t *testing.T) {
+	secret := []byte("secret")
+
+	calls := make(chan struct{}, 10)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	p := webhook.NewPublisher()
+	p.Register(srv.URL, secret, webhook.QueueSize(1))
+
+	// The first event is being delivered, and the second one is queued.
+	if err := p.Publish(context.Background(), "created", []byte(`{}`)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	<-calls
+	if err := p.Publish(context.Background(), "created", []byte(`{}`)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The third one blocks since the queue is full.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Publish(ctx, "created", []byte(`{}`)); err != context.DeadlineExceeded {
+		t.Fatalf("Err: got (%v), want (%v)", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// Only the first two events have been delivered.
+	if n := 1 + len(calls); n != 2 {
+		t.Fatalf("Calls: got (%d), want (2)", n)
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	secret := []byte("secret")
+	body := `{"specversion":"1.0","id":"1","source":"/test","type":"created"}`
+
+	cases := []struct {
+		name       string
+		inMethod   string
+		inSecret   []byte
+		inBody     string
+		inErr      error // The error returned by the event handler.
+		wantStatus int
+	}{
+		{
+			name:       "ok",
+			inMethod:   http.MethodPost,
+			inSecret:   secret,
+			inBody:     body,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "method not allowed",
+			inMethod:   http.MethodGet,
+			inSecret:   secret,
+			inBody:     body,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "invalid signature",
+			inMethod:   http.MethodPost,
+			inSecret:   []byte("other"),
+			inBody:     body,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "malformed event",
+			inMethod:   http.MethodPost,
+			inSecret:   secret,
+			inBody:     `{"id":"1"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "non-retryable error",
+			inMethod:   http.MethodPost,
+			inSecret:   secret,
+			inBody:     body,
+			inErr:      werror.Wrap(eventpubsub.ErrInvalidData, errors.New("bad data")),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "retryable error",
+			inMethod:   http.MethodPost,
+			inSecret:   secret,
+			inBody:     body,
+			inErr:      errors.New("unavailable"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+				return c.inErr
+			})
+			handler := webhook.NewHandler(h, webhook.NewVerifier(secret))
+
+			r := httptest.NewRequest(c.inMethod, "/", strings.NewReader(c.inBody))
+			r.Header.Set("Content-Type", eventcodec.StructuredContentType)
+			r.Header.Set(webhook.SignatureHeader, webhook.Sign(c.inSecret, time.Now(),
+				map[string]string{"content-type": eventcodec.StructuredContentType}, []byte(c.inBody)))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != c.wantStatus {
+				t.Fatalf("Status: got (%d), want (%d)", w.Code, c.wantStatus)
+			}
+		})
+	}
+}
+
+func TestNewHandler_BinaryMode(t *testing.T) {
+	secret := []byte("secret")
+	header := map[string]string{
+		"content-type":   "application/json",
+		"ce-specversion": "1.0",
+		"ce-id":          "1",
+		"ce-source":      "/test",
+		"ce-type":        "created",
+	}
+	signature := webhook.Sign(secret, time.Now(), header, []byte(`{}`))
+
+	cases := []struct {
+		name       string
+		inTamper   func(h http.Header)
+		wantStatus int
+		wantType   string
+	}{
+		{
+			name:       "ok",
+			inTamper:   func(h http.Header) {},
+			wantStatus: http.StatusNoContent,
+			wantType:   "created",
+		},
+		{
+			name:       "tampered type",
+			inTamper:   func(h http.Header) { h.Set("Ce-Type", "deleted") },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "tampered id",
+			inTamper:   func(h http.Header) { h.Set("Ce-Id", "2") },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "added attribute",
+			inTamper:   func(h http.Header) { h.Set("Ce-Subject", "x") },
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var gotType string
+			h := eventpubsub.HandlerFunc(func(ctx context.Context, event eventpubsub.Event) error {
+				gotType = event.Type()
+				return nil
+			})
+			handler := webhook.NewHandler(h, webhook.NewVerifier(secret))
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+			for key, value := range header {
+				r.Header.Set(key, value)
+			}
+			r.Header.Set(webhook.SignatureHeader, signature)
+			c.inTamper(r.Header)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != c.wantStatus {
+				t.Fatalf("Status: got (%d), want (%d)", w.Code, c.wantStatus)
+			}
+			if gotType != c.wantType {
+				t.Fatalf("Type: got (%q), want (%q)", gotType, c.wantType)
+			}
+		})
+	}
+}
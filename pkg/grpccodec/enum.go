@@ -0,0 +1,102 @@
+package grpccodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var enums sync.Map // map[protoreflect.FullName]*enumValues
+
+type enumValues struct {
+	byNumber map[protoreflect.EnumNumber]json.RawMessage
+}
+
+// RegisterEnum registers the mapping from the numbers of the proto enum
+// (which e belongs to) to the values of the corresponding Go type.
+//
+// It is typically called by the generated code, to make ProtoJSON able to
+// convert between proto enums and Go constants. For example:
+//
+//	grpccodec.RegisterEnum(pb.Status(0), map[int32]interface{}{
+//		0: "",
+//		1: "active",
+//		2: "inactive",
+//	})
+func RegisterEnum(e protoreflect.Enum, values map[int32]interface{}) {
+	byNumber := make(map[protoreflect.EnumNumber]json.RawMessage)
+	for n, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			panic(fmt.Errorf("invalid value %v of enum %s: %w", v, e.Descriptor().FullName(), err))
+		}
+		byNumber[protoreflect.EnumNumber(n)] = data
+	}
+	enums.Store(e.Descriptor().FullName(), &enumValues{byNumber: byNumber})
+}
+
+func lookupEnum(ed protoreflect.EnumDescriptor) (*enumValues, bool) {
+	v, ok := enums.Load(ed.FullName())
+	if !ok {
+		return nil, false
+	}
+	return v.(*enumValues), true
+}
+
+// enumToGo converts the protojson value v (i.e. the name or the number) of
+// an enum to the value of the corresponding Go type. If the enum has not
+// been registered, v will be returned as is.
+func enumToGo(ed protoreflect.EnumDescriptor, v interface{}) interface{} {
+	values, ok := lookupEnum(ed)
+	if !ok {
+		return v
+	}
+
+	var n protoreflect.EnumNumber
+	switch x := v.(type) {
+	case string:
+		value := ed.Values().ByName(protoreflect.Name(x))
+		if value == nil {
+			return v
+		}
+		n = value.Number()
+	case json.Number:
+		i, err := strconv.ParseInt(string(x), 10, 32)
+		if err != nil {
+			return v
+		}
+		n = protoreflect.EnumNumber(i)
+	default:
+		return v
+	}
+
+	if value, ok := values.byNumber[n]; ok {
+		return value
+	}
+	return v
+}
+
+// enumFromGo converts the value v of the Go type corresponding to an enum
+// to the enum number. If the enum has not been registered, v will be
+// returned as is.
+func enumFromGo(ed protoreflect.EnumDescriptor, v interface{}) (interface{}, error) {
+	values, ok := lookupEnum(ed)
+	if !ok {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	for n, value := range values.byNumber {
+		if bytes.Equal(value, data) {
+			return json.Number(strconv.Itoa(int(n))), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid value %s of enum %s", data, ed.FullName())
+}
@@ -0,0 +1,37 @@
+package grpccodec
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataValue returns the first value of key in the incoming gRPC metadata
+// of ctx. An empty string is returned if there is no such key.
+func MetadataValue(ctx context.Context, key string) string {
+	if values := MetadataValues(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// MetadataValues returns all the values of key in the incoming gRPC metadata
+// of ctx.
+func MetadataValues(ctx context.Context, key string) []string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	return md.Get(key)
+}
+
+// AppendMetadata returns a copy of ctx with values appended to key in the
+// outgoing gRPC metadata, which is the client-side counterpart of
+// MetadataValue and MetadataValues.
+func AppendMetadata(ctx context.Context, key string, values ...string) context.Context {
+	kv := make([]string, 0, 2*len(values))
+	for _, v := range values {
+		kv = append(kv, key, v)
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
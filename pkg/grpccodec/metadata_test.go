@@ -0,0 +1,29 @@
+package grpccodec
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAppendMetadata(t *testing.T) {
+	ctx := AppendMetadata(context.Background(), "x-tenant-id", "acme")
+	ctx = AppendMetadata(ctx, "x-role", "admin", "owner")
+	ctx = AppendMetadata(ctx, "x-empty")
+
+	// Pass the outgoing metadata to the server side.
+	md, _ := metadata.FromOutgoingContext(ctx)
+	ctx = metadata.NewIncomingContext(context.Background(), md)
+
+	if got := MetadataValue(ctx, "x-tenant-id"); got != "acme" {
+		t.Fatalf("MetadataValue: got (%q), want (%q)", got, "acme")
+	}
+	if got, want := MetadataValues(ctx, "x-role"), []string{"admin", "owner"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("MetadataValues: got (%#v), want (%#v)", got, want)
+	}
+	if got := MetadataValues(ctx, "x-empty"); got != nil {
+		t.Fatalf("MetadataValues: got (%#v), want nil", got)
+	}
+}
@@ -1,12 +1,30 @@
 package grpccodec
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
+// ProtoJSON converts between proto messages and Go values by using JSON as
+// the intermediate representation.
+//
+// The conversion is done by protojson, except that some JSON values are
+// adjusted to be compatible with encoding/json:
+//
+//   - 64-bit integers are encoded as JSON numbers instead of strings.
+//   - google.protobuf.Duration is encoded as nanoseconds (time.Duration).
+//   - google.protobuf.Timestamp and google.protobuf.Duration are left unset
+//     for the zero time.Time and time.Duration respectively.
+//   - Enums are encoded as the values of their Go types (see RegisterEnum).
 type ProtoJSON struct{}
 
 func (pj ProtoJSON) DecodeRequest(pb proto.Message, out interface{}) error {
@@ -14,6 +32,20 @@ func (pj ProtoJSON) DecodeRequest(pb proto.Message, out interface{}) error {
 	if err != nil {
 		return err
 	}
+
+	v, err := decodeJSON(data)
+	if err != nil {
+		return err
+	}
+	v, err = messageToGo(pb.ProtoReflect().Descriptor(), v)
+	if err != nil {
+		return err
+	}
+
+	data, err = json.Marshal(v)
+	if err != nil {
+		return err
+	}
 	return json.Unmarshal(data, out)
 }
 
@@ -22,5 +54,277 @@ func (pj ProtoJSON) EncodeResponse(in interface{}, pb proto.Message) error {
 	if err != nil {
 		return err
 	}
+
+	v, err := decodeJSON(data)
+	if err != nil {
+		return err
+	}
+	v, err = messageFromGo(pb.ProtoReflect().Descriptor(), v)
+	if err != nil {
+		return err
+	}
+
+	data, err = json.Marshal(v)
+	if err != nil {
+		return err
+	}
 	return protojson.Unmarshal(data, pb)
 }
+
+const (
+	timestampName protoreflect.FullName = "google.protobuf.Timestamp"
+	durationName  protoreflect.FullName = "google.protobuf.Duration"
+)
+
+// zeroTime is the JSON value of the zero time.Time.
+var zeroTime = time.Time{}.Format(time.RFC3339Nano)
+
+func decodeJSON(data []byte) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// converter converts a singular JSON value of field fd.
+type converter func(fd protoreflect.FieldDescriptor, v interface{}) (interface{}, error)
+
+// convertMessage converts the fields of the JSON object v, which holds a
+// message of type md, by using convert. The singular fields whose converted
+// values are nil will be removed.
+func convertMessage(md protoreflect.MessageDescriptor, v interface{}, convert converter) (interface{}, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		// Leave the invalid value to protojson or encoding/json.
+		return v, nil
+	}
+
+	fields := md.Fields()
+	for name, value := range obj {
+		fd := fields.ByJSONName(name)
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(name))
+		}
+		if fd == nil || value == nil {
+			// Leave the unknown fields to protojson or encoding/json.
+			continue
+		}
+
+		var err error
+		switch {
+		case fd.IsList():
+			values, ok := value.([]interface{})
+			if !ok {
+				continue
+			}
+			for i, item := range values {
+				if values[i], err = convertElem(fd, item, convert); err != nil {
+					return nil, err
+				}
+			}
+		case fd.IsMap():
+			values, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for k, item := range values {
+				if values[k], err = convertElem(fd.MapValue(), item, convert); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			if value, err = convert(fd, value); err != nil {
+				return nil, err
+			}
+			if value == nil {
+				delete(obj, name)
+			} else {
+				obj[name] = value
+			}
+		}
+	}
+
+	return obj, nil
+}
+
+// convertElem converts an element of a list or a map by using convert. Since
+// elements can not be unset, the original value is kept if the converted
+// value is nil.
+func convertElem(fd protoreflect.FieldDescriptor, v interface{}, convert converter) (interface{}, error) {
+	value, err := convert(fd, v)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return v, nil
+	}
+	return value, nil
+}
+
+// messageToGo converts the JSON value v, which is produced by protojson for
+// a message of type md, to be compatible with encoding/json.
+func messageToGo(md protoreflect.MessageDescriptor, v interface{}) (interface{}, error) {
+	switch md.FullName() {
+	case timestampName:
+		// RFC 3339 strings are compatible with time.Time.
+		return v, nil
+	case durationName:
+		s, ok := v.(string)
+		if !ok {
+			return v, nil
+		}
+		d, err := parseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(strconv.FormatInt(int64(d), 10)), nil
+	}
+
+	if isWrapper(md) {
+		return singularToGo(md.Fields().ByName("value"), v)
+	}
+
+	return convertMessage(md, v, singularToGo)
+}
+
+func singularToGo(fd protoreflect.FieldDescriptor, v interface{}) (interface{}, error) {
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson encodes 64-bit integers as strings.
+		if s, ok := v.(string); ok {
+			return json.Number(s), nil
+		}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		// protojson encodes NaN and ±Infinity as strings, which are not
+		// supported by encoding/json.
+		if s, ok := v.(string); ok {
+			return nil, fmt.Errorf("unsupported value %s for field %q", s, fd.Name())
+		}
+	case protoreflect.EnumKind:
+		return enumToGo(fd.Enum(), v), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageToGo(fd.Message(), v)
+	}
+	return v, nil
+}
+
+// messageFromGo converts the JSON value v, which is produced by encoding/json
+// for a message of type md, to be compatible with protojson. A nil result
+// indicates that the message should be left unset.
+func messageFromGo(md protoreflect.MessageDescriptor, v interface{}) (interface{}, error) {
+	switch md.FullName() {
+	case timestampName:
+		if v == zeroTime {
+			return nil, nil
+		}
+		return v, nil
+	case durationName:
+		n, ok := v.(json.Number)
+		if !ok {
+			return v, nil
+		}
+		d, err := strconv.ParseInt(string(n), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s for %s: %w", n, md.FullName(), err)
+		}
+		if d == 0 {
+			return nil, nil
+		}
+		return formatDuration(time.Duration(d)), nil
+	}
+
+	if isWrapper(md) {
+		return singularFromGo(md.Fields().ByName("value"), v)
+	}
+
+	return convertMessage(md, v, singularFromGo)
+}
+
+func singularFromGo(fd protoreflect.FieldDescriptor, v interface{}) (interface{}, error) {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		return enumFromGo(fd.Enum(), v)
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageFromGo(fd.Message(), v)
+	}
+	return v, nil
+}
+
+// isWrapper reports whether md is one of the wrapper types defined in
+// google/protobuf/wrappers.proto.
+func isWrapper(md protoreflect.MessageDescriptor) bool {
+	return md.ParentFile().Path() == "google/protobuf/wrappers.proto"
+}
+
+// parseDuration parses the protojson representation of a duration (e.g.
+// "1.5s").
+func parseDuration(s string) (time.Duration, error) {
+	orig := s
+	invalid := fmt.Errorf("invalid value %q for %s", orig, durationName)
+
+	if !strings.HasSuffix(s, "s") {
+		return 0, invalid
+	}
+	s = strings.TrimSuffix(s, "s")
+
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	secStr, nanoStr := s, ""
+	if i := strings.Index(s, "."); i >= 0 {
+		secStr, nanoStr = s[:i], s[i+1:]
+	}
+	if len(nanoStr) > 9 {
+		return 0, invalid
+	}
+
+	sec, err := strconv.ParseInt(secStr, 10, 64)
+	if err != nil {
+		return 0, invalid
+	}
+	var nano int64
+	if nanoStr != "" {
+		if nano, err = strconv.ParseInt(nanoStr+strings.Repeat("0", 9-len(nanoStr)), 10, 64); err != nil {
+			return 0, invalid
+		}
+	}
+
+	// The absolute value of the minimum time.Duration is one nanosecond
+	// greater than that of the maximum one.
+	const maxSec, maxNano = int64(math.MaxInt64 / time.Second), int64(math.MaxInt64 % time.Second)
+	limit := maxNano
+	if neg {
+		limit++
+	}
+	if sec > maxSec || (sec == maxSec && nano > limit) {
+		return 0, fmt.Errorf("value %q for %s is out of the range of time.Duration", orig, durationName)
+	}
+
+	if neg {
+		return -time.Duration(sec)*time.Second - time.Duration(nano), nil
+	}
+	return time.Duration(sec)*time.Second + time.Duration(nano), nil
+}
+
+// formatDuration formats d in the protojson representation of a duration.
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+	}
+	sec, nano := int64(d/time.Second), int64(d%time.Second)
+	if sec < 0 {
+		sec = -sec
+	}
+	if nano < 0 {
+		nano = -nano
+	}
+	if nano == 0 {
+		return fmt.Sprintf("%s%ds", sign, sec)
+	}
+	return fmt.Sprintf("%s%d.%09ds", sign, sec, nano)
+}
@@ -0,0 +1,335 @@
+package grpccodec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// testFile is the descriptor of the following file:
+//
+//	syntax = "proto3";
+//	package test;
+//
+//	message Value {
+//	  int32 i32 = 1;
+//	  int64 i64 = 2;
+//	  uint32 u32 = 3;
+//	  uint64 u64 = 4;
+//	  double f64 = 5;
+//	  Kind kind = 6;
+//	  google.protobuf.Timestamp time = 7;
+//	  google.protobuf.Duration dur = 8;
+//	  google.protobuf.Int64Value wrapped = 9;
+//	  optional int64 opt = 10;
+//	  repeated Kind kinds = 11;
+//	  map<string, int64> counts = 12;
+//	  repeated google.protobuf.Timestamp times = 13;
+//	}
+//
+//	enum Kind {
+//	  KIND_UNSPECIFIED = 0;
+//	  KIND_GAUGE = 1;
+//	  KIND_COUNTER = 2;
+//	}
+const testFile = `
+name: "test.proto"
+package: "test"
+syntax: "proto3"
+dependency: ["google/protobuf/timestamp.proto", "google/protobuf/duration.proto", "google/protobuf/wrappers.proto"]
+message_type: {
+  name: "Value"
+  field: {name: "i32" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: "i32"}
+  field: {name: "i64" number: 2 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "i64"}
+  field: {name: "u32" number: 3 label: LABEL_OPTIONAL type: TYPE_UINT32 json_name: "u32"}
+  field: {name: "u64" number: 4 label: LABEL_OPTIONAL type: TYPE_UINT64 json_name: "u64"}
+  field: {name: "f64" number: 5 label: LABEL_OPTIONAL type: TYPE_DOUBLE json_name: "f64"}
+  field: {name: "kind" number: 6 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.Kind" json_name: "kind"}
+  field: {name: "time" number: 7 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Timestamp" json_name: "time"}
+  field: {name: "dur" number: 8 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Duration" json_name: "dur"}
+  field: {name: "wrapped" number: 9 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Int64Value" json_name: "wrapped"}
+  field: {name: "opt" number: 10 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "opt" oneof_index: 0 proto3_optional: true}
+  field: {name: "kinds" number: 11 label: LABEL_REPEATED type: TYPE_ENUM type_name: ".test.Kind" json_name: "kinds"}
+  field: {name: "counts" number: 12 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Value.CountsEntry" json_name: "counts"}
+  field: {name: "times" number: 13 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".google.protobuf.Timestamp" json_name: "times"}
+  nested_type: {
+    name: "CountsEntry"
+    field: {name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "key"}
+    field: {name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "value"}
+    options: {map_entry: true}
+  }
+  oneof_decl: {name: "_opt"}
+}
+enum_type: {
+  name: "Kind"
+  value: {name: "KIND_UNSPECIFIED" number: 0}
+  value: {name: "KIND_GAUGE" number: 1}
+  value: {name: "KIND_COUNTER" number: 2}
+}
+`
+
+// value is the Go type corresponding to the message test.Value.
+type value struct {
+	I32     int32            `json:"i32"`
+	I64     int64            `json:"i64"`
+	U32     uint32           `json:"u32"`
+	U64     uint64           `json:"u64"`
+	F64     float64          `json:"f64"`
+	Kind    string           `json:"kind"`
+	Time    time.Time        `json:"time"`
+	Dur     time.Duration    `json:"dur"`
+	Wrapped *int64           `json:"wrapped"`
+	Opt     *int64           `json:"opt"`
+	Kinds   []string         `json:"kinds"`
+	Counts  map[string]int64 `json:"counts"`
+	Times   []time.Time      `json:"times"`
+}
+
+func newTestMessage(t *testing.T) func() *dynamicpb.Message {
+	fdp := new(descriptorpb.FileDescriptorProto)
+	if err := prototext.Unmarshal([]byte(testFile), fdp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	kind := fd.Enums().ByName("Kind")
+	RegisterEnum(dynamicpb.NewEnumType(kind).New(0), map[int32]interface{}{
+		0: "",
+		1: "gauge",
+		2: "counter",
+	})
+
+	md := fd.Messages().ByName("Value")
+	return func() *dynamicpb.Message {
+		return dynamicpb.NewMessage(md)
+	}
+}
+
+func int64Ptr(n int64) *int64 { return &n }
+
+func TestProtoJSON_DecodeRequest(t *testing.T) {
+	newMessage := newTestMessage(t)
+	tm := time.Date(2021, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	tests := []struct {
+		name       string
+		in         string // The protojson representation of the message.
+		wantOut    value
+		wantErrStr string
+	}{
+		{
+			name:    "empty",
+			in:      `{}`,
+			wantOut: value{},
+		},
+		{
+			name: "scalars",
+			in:   `{"i32": -1, "i64": "-9223372036854775808", "u32": 4294967295, "u64": "18446744073709551615", "f64": 1.5}`,
+			wantOut: value{
+				I32: -1,
+				I64: -9223372036854775808,
+				U32: 4294967295,
+				U64: 18446744073709551615,
+				F64: 1.5,
+			},
+		},
+		{
+			name:       "NaN",
+			in:         `{"f64": "NaN"}`,
+			wantErrStr: `unsupported value NaN for field "f64"`,
+		},
+		{
+			name:       "infinity",
+			in:         `{"f64": "-Infinity"}`,
+			wantErrStr: `unsupported value -Infinity for field "f64"`,
+		},
+		{
+			name:    "enum",
+			in:      `{"kind": "KIND_GAUGE", "kinds": ["KIND_COUNTER", "KIND_UNSPECIFIED"]}`,
+			wantOut: value{Kind: "gauge", Kinds: []string{"counter", ""}},
+		},
+		{
+			name:    "timestamp",
+			in:      `{"time": "2021-01-02T03:04:05.000000006Z", "times": ["2021-01-02T03:04:05.000000006Z"]}`,
+			wantOut: value{Time: tm, Times: []time.Time{tm}},
+		},
+		{
+			name:    "duration",
+			in:      `{"dur": "-1.5s"}`,
+			wantOut: value{Dur: -1500 * time.Millisecond},
+		},
+		{
+			name:    "max duration",
+			in:      `{"dur": "9223372036.854775807s"}`,
+			wantOut: value{Dur: 1<<63 - 1},
+		},
+		{
+			name:    "min duration",
+			in:      `{"dur": "-9223372036.854775808s"}`,
+			wantOut: value{Dur: -1 << 63},
+		},
+		{
+			name:       "duration out of range",
+			in:         `{"dur": "9223372037s"}`,
+			wantErrStr: `value "9223372037s" for google.protobuf.Duration is out of the range of time.Duration`,
+		},
+		{
+			name:    "wrapper and optional",
+			in:      `{"wrapped": "0", "opt": "0"}`,
+			wantOut: value{Wrapped: int64Ptr(0), Opt: int64Ptr(0)},
+		},
+		{
+			name:    "map",
+			in:      `{"counts": {"a": "1"}}`,
+			wantOut: value{Counts: map[string]int64{"a": 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMessage()
+			if err := protojson.Unmarshal([]byte(tt.in), m); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			var out value
+			err := ProtoJSON{}.DecodeRequest(m, &out)
+			if (err == nil && tt.wantErrStr != "") || (err != nil && err.Error() != tt.wantErrStr) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if err == nil && !reflect.DeepEqual(out, tt.wantOut) {
+				t.Fatalf("Out: got (%#v), want (%#v)", out, tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestProtoJSON_EncodeResponse(t *testing.T) {
+	newMessage := newTestMessage(t)
+	tm := time.Date(2021, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	tests := []struct {
+		name       string
+		in         interface{}
+		wantOut    string // The protojson representation of the message.
+		wantErrStr string
+	}{
+		{
+			name:    "zero",
+			in:      value{},
+			wantOut: `{}`,
+		},
+		{
+			name: "scalars",
+			in: value{
+				I32: -1,
+				I64: -9223372036854775808,
+				U32: 4294967295,
+				U64: 18446744073709551615,
+				F64: 1.5,
+			},
+			wantOut: `{"i32":-1,"i64":"-9223372036854775808","u32":4294967295,"u64":"18446744073709551615","f64":1.5}`,
+		},
+		{
+			name:       "int32 out of range",
+			in:         map[string]interface{}{"i32": 1 << 31},
+			wantErrStr: `(line 1:8): invalid value for int32 type: 2147483648`,
+		},
+		{
+			name:       "uint32 out of range",
+			in:         map[string]interface{}{"u32": -1},
+			wantErrStr: `(line 1:8): invalid value for uint32 type: -1`,
+		},
+		{
+			name:    "enum",
+			in:      value{Kind: "gauge", Kinds: []string{"counter", ""}},
+			wantOut: `{"kind":"KIND_GAUGE","kinds":["KIND_COUNTER","KIND_UNSPECIFIED"]}`,
+		},
+		{
+			name:       "invalid enum",
+			in:         value{Kind: "unknown"},
+			wantErrStr: `invalid value "unknown" of enum test.Kind`,
+		},
+		{
+			name:    "timestamp",
+			in:      value{Time: tm, Times: []time.Time{tm}},
+			wantOut: `{"time":"2021-01-02T03:04:05.000000006Z","times":["2021-01-02T03:04:05.000000006Z"]}`,
+		},
+		{
+			name:    "zero timestamp in list",
+			in:      value{Times: []time.Time{{}}},
+			wantOut: `{"times":["0001-01-01T00:00:00Z"]}`,
+		},
+		{
+			name:    "duration",
+			in:      value{Dur: -1500 * time.Millisecond},
+			wantOut: `{"dur":"-1.500s"}`,
+		},
+		{
+			name:    "wrapper and optional",
+			in:      value{Wrapped: int64Ptr(0), Opt: int64Ptr(0)},
+			wantOut: `{"wrapped":"0","opt":"0"}`,
+		},
+		{
+			name:    "map",
+			in:      value{Counts: map[string]int64{"a": 1}},
+			wantOut: `{"counts":{"a":"1"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMessage()
+			err := ProtoJSON{}.EncodeResponse(tt.in, m)
+			// The prefix of the errors returned by protojson is unstable.
+			if (err == nil && tt.wantErrStr != "") || (err != nil && !strings.HasSuffix(err.Error(), tt.wantErrStr)) {
+				t.Fatalf("Err: got (%#v), want (%#v)", err, tt.wantErrStr)
+			}
+			if err != nil {
+				return
+			}
+
+			// Compare the canonical forms, since the output of protojson
+			// is unstable.
+			want := newMessage()
+			if err := protojson.Unmarshal([]byte(tt.wantOut), want); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			got, _ := protojson.Marshal(m)
+			wantOut, _ := protojson.Marshal(want)
+			if string(got) != string(wantOut) {
+				t.Fatalf("Out: got (%s), want (%s)", got, wantOut)
+			}
+		})
+	}
+}
+
+func TestProtoJSON_Unset(t *testing.T) {
+	newMessage := newTestMessage(t)
+	m := newMessage()
+
+	if err := (ProtoJSON{}).EncodeResponse(value{}, m); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, name := range []protoreflect.Name{"time", "dur", "wrapped", "opt"} {
+		fd := m.Descriptor().Fields().ByName(name)
+		if m.Has(fd) {
+			t.Fatalf("%s: got (%v), want unset", name, m.Get(fd))
+		}
+	}
+}
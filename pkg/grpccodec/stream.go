@@ -0,0 +1,137 @@
+package grpccodec
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// StreamReceiver manages the goroutine, which receives the messages of a
+// client stream on behalf of the service.
+//
+// It is used by the generated code as follows:
+//
+//	recv := grpccodec.NewStreamReceiver(cancel)
+//	go func() {
+//		defer recv.Done()
+//		defer close(recvc)
+//		for msg := first; msg != nil; {
+//			if msg != first || grpccodec.HasElem(msg, "field") {
+//				// Decode msg and send it to recvc, or call recv.Fail on errors...
+//			}
+//			var err error
+//			if !recv.Receive(func() { msg, err = stream.Recv() }) {
+//				return
+//			}
+//			if err != nil && err != io.EOF {
+//				recv.Fail(err)
+//				return
+//			}
+//		}
+//	}()
+//
+//	// Call the service with recvc...
+//	if err := recv.Stop(); err != nil {
+//		return err
+//	}
+type StreamReceiver struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu        sync.Mutex
+	receiving bool
+	stopped   bool
+	err       error
+}
+
+// NewStreamReceiver creates a stream receiver, where cancel cancels the
+// context passed to the service.
+func NewStreamReceiver(cancel context.CancelFunc) *StreamReceiver {
+	return &StreamReceiver{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+}
+
+// Receive calls recv (i.e. stream.Recv) to receive a message, unless the
+// receiver has been stopped, in which case false is returned.
+func (r *StreamReceiver) Receive(recv func()) bool {
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		return false
+	}
+	r.receiving = true
+	r.mu.Unlock()
+
+	recv()
+
+	r.mu.Lock()
+	r.receiving = false
+	r.mu.Unlock()
+	return true
+}
+
+// Fail records err, which occurred in receiving (or decoding) a message, and
+// cancels the context of the service.
+//
+// Fail must be called before closing the channel of the stream, so that
+// the service can tell a broken stream (by checking ctx.Err()) from a
+// finished one.
+func (r *StreamReceiver) Fail(err error) {
+	r.mu.Lock()
+	if r.err == nil {
+		r.err = err
+	}
+	r.mu.Unlock()
+	r.cancel()
+}
+
+// Done marks the receiving goroutine as exited.
+func (r *StreamReceiver) Done() {
+	close(r.done)
+}
+
+// Stop cancels the context of the service, waits for the receiving goroutine
+// to exit, and then returns the error occurred in receiving (if any).
+//
+// If the goroutine is blocked in receiving a message (i.e. the service
+// returns without consuming the whole stream), which can only be unblocked
+// by the end of the RPC, Stop will not wait for it.
+func (r *StreamReceiver) Stop() error {
+	r.cancel()
+
+	r.mu.Lock()
+	r.stopped = true
+	receiving := r.receiving
+	r.mu.Unlock()
+
+	if !receiving {
+		<-r.done
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// HasElem reports whether msg carries a stream element in the field named
+// field. It is used to tell whether the first message of a client stream,
+// which also carries the non-stream parameters, carries an element too.
+//
+// Fields without presence (e.g. non-optional scalars and repeated fields)
+// are always deemed to carry an element, since their zero values can not
+// be told from unset ones.
+func HasElem(msg proto.Message, field string) bool {
+	m := msg.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(field))
+	if fd == nil {
+		return false
+	}
+	if !fd.HasPresence() {
+		return true
+	}
+	return m.Has(fd)
+}
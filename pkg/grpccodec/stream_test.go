@@ -0,0 +1,116 @@
+package grpccodec
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestStreamReceiver_Stop(t *testing.T) {
+	t.Run("wait for the goroutine", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := NewStreamReceiver(cancel)
+
+		exited := false
+		go func() {
+			defer r.Done()
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			exited = true
+		}()
+
+		if err := r.Stop(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !exited {
+			t.Fatal("Stop returned before the goroutine exited")
+		}
+	})
+
+	t.Run("not wait for the blocked receiving", func(t *testing.T) {
+		_, cancel := context.WithCancel(context.Background())
+		r := NewStreamReceiver(cancel)
+
+		entered, unblock := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer r.Done()
+			r.Receive(func() {
+				close(entered)
+				<-unblock
+			})
+		}()
+		<-entered
+
+		if err := r.Stop(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		close(unblock)
+
+		// No more receiving after stopped.
+		if r.Receive(func() {}) {
+			t.Fatal("Receive: got (true), want (false)")
+		}
+	})
+
+	t.Run("report the error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := NewStreamReceiver(cancel)
+
+		go func() {
+			defer r.Done()
+			r.Fail(errors.New("oops"))
+		}()
+
+		<-ctx.Done()
+		if err := r.Stop(); err == nil || err.Error() != "oops" {
+			t.Fatalf("Err: got (%v), want (oops)", err)
+		}
+	})
+}
+
+func TestHasElem(t *testing.T) {
+	tests := []struct {
+		name    string
+		inMsg   proto.Message
+		inField string
+		want    bool
+	}{
+		{
+			name:    "unset message field",
+			inMsg:   &descriptorpb.DescriptorProto{},
+			inField: "options",
+			want:    false,
+		},
+		{
+			name:    "set message field",
+			inMsg:   &descriptorpb.DescriptorProto{Options: &descriptorpb.MessageOptions{}},
+			inField: "options",
+			want:    true,
+		},
+		{
+			name:    "scalar field without presence",
+			inMsg:   &durationpb.Duration{},
+			inField: "seconds",
+			want:    true,
+		},
+		{
+			name:    "unknown field",
+			inMsg:   &durationpb.Duration{},
+			inField: "minutes",
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasElem(tt.inMsg, tt.inField); got != tt.want {
+				t.Fatalf("HasElem: got (%v), want (%v)", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package ifacetool
 
 import (
 	"fmt"
+	"go/token"
 	"go/types"
 	"strings"
 )
@@ -46,7 +47,9 @@ func (p *Param) CallName() string {
 }
 
 type Method struct {
-	Doc     []string
+	Doc []string
+	// DocPos holds the source positions of the comments in Doc, if known.
+	DocPos  []token.Position `json:"-"`
 	Name    string
 	Params  []*Param
 	Returns []*Param
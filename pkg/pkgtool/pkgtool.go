@@ -35,18 +35,21 @@ func ParseInterface(pkgName, srcFilename, interfaceName string) (*ifacetool.Data
 	data.InterfaceDoc = doc.Doc
 	for _, m := range data.Methods {
 		m.Doc = doc.MethodDocs[m.Name]
+		m.DocPos = doc.MethodDocPos[m.Name]
 	}
 
 	return data, nil
 }
 
 type interfaceDoc struct {
-	Doc        []string
-	MethodDocs map[string][]string
+	Doc          []string
+	MethodDocs   map[string][]string
+	MethodDocPos map[string][]token.Position
 }
 
 func newInterfaceDoc(filename, name string) (*interfaceDoc, error) {
-	ifType, ifDoc, err := getAstInterfaceInfo(filename, name)
+	fset := token.NewFileSet()
+	ifType, ifDoc, err := getAstInterfaceInfo(fset, filename, name)
 	if err != nil {
 		return nil, err
 	}
@@ -59,6 +62,7 @@ func newInterfaceDoc(filename, name string) (*interfaceDoc, error) {
 	}
 
 	methodDocs := make(map[string][]string)
+	methodDocPos := make(map[string][]token.Position)
 
 	for _, method := range ifType.Methods.List {
 		methodName := method.Names[0].Name
@@ -68,19 +72,22 @@ func newInterfaceDoc(filename, name string) (*interfaceDoc, error) {
 		}
 
 		var comments []string
+		var positions []token.Position
 		for _, c := range method.Doc.List {
 			comments = append(comments, c.Text)
+			positions = append(positions, fset.Position(c.Pos()))
 		}
 		methodDocs[methodName] = comments
+		methodDocPos[methodName] = positions
 	}
 
-	return &interfaceDoc{Doc: doc, MethodDocs: methodDocs}, nil
+	return &interfaceDoc{Doc: doc, MethodDocs: methodDocs, MethodDocPos: methodDocPos}, nil
 }
 
-func getAstInterfaceInfo(filename, name string) (*ast.InterfaceType, *ast.CommentGroup, error) {
+func getAstInterfaceInfo(fset *token.FileSet, filename, name string) (*ast.InterfaceType, *ast.CommentGroup, error) {
 	filename, _ = filepath.Abs(filename)
 
-	f, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.ParseComments|parser.DeclarationErrors)
+	f, err := parser.ParseFile(fset, filename, nil, parser.ParseComments|parser.DeclarationErrors)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,84 @@
+package cronstats
+
+import (
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/kun/pkg/prometheus/metric"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Opts struct {
+	Namespace string
+	Subsystem string
+
+	Buckets []float64 // Buckets of the job duration. Defaults to prometheus.DefBuckets.
+
+	Registerer prometheus.Registerer // The registry of the metrics. Defaults to prometheus.DefaultRegisterer.
+}
+
+// Metrics for the runs of cron jobs.
+type Metrics struct {
+	RunsTotal            *prometheus.CounterVec   // The total number of runs, by job and result.
+	DurationSeconds      *prometheus.HistogramVec // The duration of runs in seconds, by job.
+	LastSuccessTimestamp *prometheus.GaugeVec     // The Unix timestamp of the last successful run, by job.
+}
+
+// Exporter exports the runs of cron jobs as Prometheus metrics. It implements
+// cronjob.Observer.
+type Exporter struct {
+	metrics *Metrics
+}
+
+// NewExporter creates an exporter, whose metrics are registered into
+// opts.Registerer.
+func NewExporter(opts *Opts) *Exporter {
+	buckets := opts.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	registerer := opts.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	m := &Metrics{
+		RunsTotal: metric.NewCounterVecIn(registerer, prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Subsystem: opts.Subsystem,
+			Name:      "job_runs_total",
+			Help:      "The total number of runs of cron jobs.",
+		}, []string{"job", "result"}),
+		DurationSeconds: metric.NewHistogramVecIn(registerer, prometheus.HistogramOpts{
+			Namespace: opts.Namespace,
+			Subsystem: opts.Subsystem,
+			Name:      "job_duration_seconds",
+			Help:      "The duration of runs of cron jobs in seconds.",
+			Buckets:   buckets,
+		}, []string{"job"}),
+		LastSuccessTimestamp: metric.NewGaugeVecIn(registerer, prometheus.GaugeOpts{
+			Namespace: opts.Namespace,
+			Subsystem: opts.Subsystem,
+			Name:      "job_last_success_timestamp",
+			Help:      "The Unix timestamp of the last successful run of cron jobs.",
+		}, []string{"job"}),
+	}
+	return &Exporter{metrics: m}
+}
+
+// Metrics returns the metrics of the exporter.
+func (e *Exporter) Metrics() *Metrics {
+	return e.metrics
+}
+
+// ObserveRun implements cronjob.Observer.
+func (e *Exporter) ObserveRun(run *cronjob.Run) {
+	e.metrics.RunsTotal.WithLabelValues(run.Job, run.Outcome).Inc()
+	if run.Outcome == cronjob.OutcomeSkipped {
+		// Skipped runs do not execute the job at all.
+		return
+	}
+
+	e.metrics.DurationSeconds.WithLabelValues(run.Job).Observe(run.Duration().Seconds())
+	if run.Outcome == cronjob.OutcomeSuccess {
+		e.metrics.LastSuccessTimestamp.WithLabelValues(run.Job).Set(float64(run.FinishedAt.Unix()))
+	}
+}
@@ -0,0 +1,55 @@
+package cronstats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/cronjob"
+	"github.com/RussellLuo/kun/pkg/prometheus/exporter/cronstats"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestExporter_ObserveRun(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	e := cronstats.NewExporter(&cronstats.Opts{Namespace: "test", Registerer: registry})
+	m := e.Metrics()
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, r := range []*cronjob.Run{
+		{Job: "a", StartedAt: start, FinishedAt: start.Add(time.Second), Outcome: cronjob.OutcomeSuccess},
+		{Job: "a", StartedAt: start.Add(time.Minute), FinishedAt: start.Add(2 * time.Minute), Outcome: cronjob.OutcomeFailure},
+		{Job: "a", StartedAt: start.Add(time.Minute), FinishedAt: start.Add(time.Minute), Outcome: cronjob.OutcomeSkipped},
+	} {
+		e.ObserveRun(r)
+	}
+
+	for result, want := range map[string]float64{
+		cronjob.OutcomeSuccess: 1,
+		cronjob.OutcomeFailure: 1,
+		cronjob.OutcomeSkipped: 1,
+	} {
+		if got := testutil.ToFloat64(m.RunsTotal.WithLabelValues("a", result)); got != want {
+			t.Fatalf("RunsTotal(%s): got (%v), want (%v)", result, got, want)
+		}
+	}
+
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var count uint64
+	for _, mf := range mfs {
+		if mf.GetName() == "test_job_duration_seconds" {
+			count = mf.GetMetric()[0].GetHistogram().GetSampleCount()
+		}
+	}
+	if count != 2 { // Skipped runs are not observed.
+		t.Fatalf("DurationSeconds: got (%d) samples, want (2)", count)
+	}
+
+	want := float64(start.Add(time.Second).Unix())
+	if got := testutil.ToFloat64(m.LastSuccessTimestamp.WithLabelValues("a")); got != want {
+		t.Fatalf("LastSuccessTimestamp: got (%v), want (%v)", got, want)
+	}
+}
@@ -30,11 +30,30 @@ func NewSettableCounterVecFrom(opts SettableCounterOpts, labelNames []string) *S
 
 // NewGaugeFrom constructs, registers and returns a GaugeVec.
 func NewGaugeVecFrom(opts prometheus.GaugeOpts, labelNames []string) *prometheus.GaugeVec {
+	return NewGaugeVecIn(prometheus.DefaultRegisterer, opts, labelNames)
+}
+
+// NewGaugeVecIn constructs, registers into r and returns a GaugeVec.
+func NewGaugeVecIn(r prometheus.Registerer, opts prometheus.GaugeOpts, labelNames []string) *prometheus.GaugeVec {
 	gv := prometheus.NewGaugeVec(opts, labelNames)
-	prometheus.MustRegister(gv)
+	r.MustRegister(gv)
 	return gv
 }
 
+// NewCounterVecIn constructs, registers into r and returns a CounterVec.
+func NewCounterVecIn(r prometheus.Registerer, opts prometheus.CounterOpts, labelNames []string) *prometheus.CounterVec {
+	cv := prometheus.NewCounterVec(opts, labelNames)
+	r.MustRegister(cv)
+	return cv
+}
+
+// NewHistogramVecIn constructs, registers into r and returns a HistogramVec.
+func NewHistogramVecIn(r prometheus.Registerer, opts prometheus.HistogramOpts, labelNames []string) *prometheus.HistogramVec {
+	hv := prometheus.NewHistogramVec(opts, labelNames)
+	r.MustRegister(hv)
+	return hv
+}
+
 func MakeLabels(labelValues ...string) prometheus.Labels {
 	labels := prometheus.Labels{}
 	for i := 0; i < len(labelValues); i += 2 {
@@ -0,0 +1,31 @@
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// InsertIfAbsent executes insertQuery with args to insert a row, which is
+// identified by key, and reports whether the row has been inserted.
+//
+// Error codes of unique violations vary among drivers, so if the insertion
+// fails, existsQuery (which must select a single column of any type from the
+// row by key, e.g. "SELECT 1 FROM ... WHERE key = ?") is executed to tell an
+// existing row (false, nil) from a real failure.
+func InsertIfAbsent(ctx context.Context, db *sql.DB, insertQuery string, args []interface{}, existsQuery string, key interface{}) (bool, error) {
+	_, err := db.ExecContext(ctx, insertQuery, args...)
+	if err == nil {
+		return true, nil
+	}
+
+	var v interface{}
+	switch qErr := db.QueryRowContext(ctx, existsQuery, key).Scan(&v); qErr {
+	case nil:
+		return false, nil
+	case sql.ErrNoRows:
+		return false, err
+	default:
+		return false, fmt.Errorf("%w (failed to check %v: %v)", err, key, qErr)
+	}
+}
@@ -0,0 +1,75 @@
+package sqlutil_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/RussellLuo/kun/pkg/sqlutil"
+	_ "modernc.org/sqlite"
+)
+
+func TestInsertIfAbsent(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// Use a single connection to share the in-memory database.
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE items (key TEXT PRIMARY KEY, value TEXT NOT NULL)"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	const (
+		insertQuery = "INSERT INTO items (key, value) VALUES (?, ?)"
+		existsQuery = "SELECT 1 FROM items WHERE key = ?"
+	)
+
+	tests := []struct {
+		name          string
+		inArgs        []interface{}
+		inExistsQuery string // Defaults to existsQuery.
+		wantInserted  bool
+		wantErr       bool
+	}{
+		{
+			name:         "absent",
+			inArgs:       []interface{}{"a", "1"},
+			wantInserted: true,
+		},
+		{
+			name:         "present",
+			inArgs:       []interface{}{"a", "2"},
+			wantInserted: false,
+		},
+		{
+			name:          "present, selecting a string column",
+			inArgs:        []interface{}{"a", "3"},
+			inExistsQuery: "SELECT key FROM items WHERE key = ?",
+			wantInserted:  false,
+		},
+		{
+			name:    "real failure",
+			inArgs:  []interface{}{"b", nil}, // violates NOT NULL
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.inExistsQuery
+			if query == "" {
+				query = existsQuery
+			}
+			inserted, err := sqlutil.InsertIfAbsent(context.Background(), db, insertQuery, tt.inArgs, query, tt.inArgs[0])
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Err: got (%v), want error (%v)", err, tt.wantErr)
+			}
+			if inserted != tt.wantInserted {
+				t.Fatalf("Inserted: got (%v), want (%v)", inserted, tt.wantInserted)
+			}
+		})
+	}
+}
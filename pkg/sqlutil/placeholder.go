@@ -0,0 +1,18 @@
+// Package sqlutil provides helpers shared by the database/sql backed
+// implementations (e.g. outboxes, task queues and cron lockers).
+package sqlutil
+
+import (
+	"strconv"
+)
+
+// Placeholder returns the placeholder of the i-th (starting from 1) query
+// argument, which varies among database drivers.
+type Placeholder func(i int) string
+
+var (
+	// Question is the placeholder used by MySQL and SQLite.
+	Question Placeholder = func(int) string { return "?" }
+	// Dollar is the placeholder used by PostgreSQL.
+	Dollar Placeholder = func(i int) string { return "$" + strconv.Itoa(i) }
+)
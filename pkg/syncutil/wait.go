@@ -0,0 +1,25 @@
+// Package syncutil provides synchronization helpers shared by the concurrent
+// implementations (e.g. event buses, dispatchers and webhook publishers).
+package syncutil
+
+import (
+	"context"
+	"sync"
+)
+
+// Wait waits for wg until ctx is done. It returns ctx.Err() if ctx is done
+// first, in which case wg is still being waited for in the background.
+func Wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,97 @@
+package taskqueue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/uuidutil"
+)
+
+// MemoryQueue is an in-memory Queue, which is only shared within a process.
+// It's useful for development and testing.
+type MemoryQueue struct {
+	mu    sync.Mutex
+	tasks map[string][]*Task // queue -> tasks
+}
+
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{tasks: make(map[string][]*Task)}
+}
+
+// Enqueue implements Queue.
+func (q *MemoryQueue) Enqueue(ctx context.Context, task *Task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t := *task
+	if t.ID == "" {
+		t.ID = uuidutil.New()
+	}
+	if t.RunAt.IsZero() {
+		t.RunAt = time.Now()
+	}
+	q.tasks[t.Queue] = append(q.tasks[t.Queue], &t)
+	return nil
+}
+
+// Dequeue implements Queue.
+func (q *MemoryQueue) Dequeue(ctx context.Context, queue string, lease time.Duration) (*Task, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	var due *Task
+	for _, t := range q.tasks[queue] {
+		if !t.RunAt.After(now) && (due == nil || t.RunAt.Before(due.RunAt)) {
+			due = t
+		}
+	}
+	if due == nil {
+		return nil, ErrNoTask
+	}
+
+	due.RunAt = now.Add(lease)
+	due.Attempts++
+
+	t := *due
+	return &t, nil
+}
+
+// Delete implements Queue.
+func (q *MemoryQueue) Delete(ctx context.Context, task *Task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	tasks := q.tasks[task.Queue]
+	for i, t := range tasks {
+		if t.ID == task.ID && t.Attempts == task.Attempts {
+			q.tasks[task.Queue] = append(tasks[:i], tasks[i+1:]...)
+			return nil
+		}
+	}
+	return leaseLost(task)
+}
+
+// Retry implements Queue.
+func (q *MemoryQueue) Retry(ctx context.Context, task *Task, runAt time.Time, lastErr string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, t := range q.tasks[task.Queue] {
+		if t.ID == task.ID && t.Attempts == task.Attempts {
+			t.RunAt = runAt
+			t.LastError = lastErr
+			return nil
+		}
+	}
+	return leaseLost(task)
+}
+
+// Len returns the number of tasks (including the ones being executed) in
+// the queue named queue.
+func (q *MemoryQueue) Len(queue string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.tasks[queue])
+}
@@ -0,0 +1,110 @@
+package taskqueue_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/taskqueue"
+	_ "modernc.org/sqlite"
+)
+
+func TestMemoryQueue(t *testing.T) {
+	testQueue(t, taskqueue.NewMemoryQueue())
+}
+
+func TestSQLQueue(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// Use a single connection to share the in-memory database.
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE tasks (
+		id         VARCHAR(36) PRIMARY KEY,
+		queue      VARCHAR(255) NOT NULL,
+		name       VARCHAR(255) NOT NULL,
+		payload    BLOB NOT NULL,
+		run_at     BIGINT NOT NULL,
+		attempts   INTEGER NOT NULL,
+		last_error TEXT NOT NULL
+	)`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	testQueue(t, taskqueue.NewSQLQueue(db))
+}
+
+func testQueue(t *testing.T, q taskqueue.Queue) {
+	ctx := context.Background()
+	lease := 50 * time.Millisecond
+
+	dequeue := func(wantName string) *taskqueue.Task {
+		task, err := q.Dequeue(ctx, "q", lease)
+		if wantName == "" {
+			if err != taskqueue.ErrNoTask {
+				t.Fatalf("Err: got (%v), want (%v)", err, taskqueue.ErrNoTask)
+			}
+			return nil
+		}
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if task.Name != wantName {
+			t.Fatalf("Name: got (%q), want (%q)", task.Name, wantName)
+		}
+		return task
+	}
+
+	must := func(err error) {
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	must(taskqueue.Enqueue(taskqueue.WithDelay(ctx, time.Hour), q, "q", "later", []byte(`{}`)))
+	must(taskqueue.Enqueue(ctx, q, "q", "a", []byte(`{"n":1}`)))
+	must(taskqueue.Enqueue(ctx, q, "other", "b", []byte(`{}`)))
+
+	// Only the due task in the queue is available.
+	task := dequeue("a")
+	if string(task.Payload) != `{"n":1}` || task.Attempts != 1 {
+		t.Fatalf("Task: got (%#v)", task)
+	}
+	dequeue("")
+
+	// The task is available again after the lease expires.
+	stale := task
+	time.Sleep(lease)
+	task = dequeue("a")
+	if task.Attempts != 2 {
+		t.Fatalf("Attempts: got (%d), want (%d)", task.Attempts, 2)
+	}
+
+	// The previous worker, whose lease has been lost, can neither delete
+	// nor retry the task.
+	if err := q.Delete(ctx, stale); !errors.Is(err, taskqueue.ErrLeaseLost) {
+		t.Fatalf("Err: got (%v), want (%v)", err, taskqueue.ErrLeaseLost)
+	}
+	if err := q.Retry(ctx, stale, time.Now(), "stale"); !errors.Is(err, taskqueue.ErrLeaseLost) {
+		t.Fatalf("Err: got (%v), want (%v)", err, taskqueue.ErrLeaseLost)
+	}
+	dequeue("")
+
+	// Retry the task.
+	must(q.Retry(ctx, task, time.Now(), "oops"))
+	task = dequeue("a")
+	if task.Attempts != 3 || task.LastError != "oops" {
+		t.Fatalf("Task: got (%#v)", task)
+	}
+
+	// Delete the task.
+	must(q.Delete(ctx, task))
+	time.Sleep(lease)
+	dequeue("")
+}
@@ -0,0 +1,170 @@
+package taskqueue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/sqlutil"
+	"github.com/RussellLuo/kun/pkg/uuidutil"
+)
+
+type SQLQueueOptions struct {
+	table       string
+	placeholder sqlutil.Placeholder
+}
+
+// SQLQueueOption sets an optional parameter for SQLQueueOptions.
+type SQLQueueOption func(*SQLQueueOptions)
+
+// Table sets the name of the table, in which the tasks are stored. Defaults
+// to "tasks".
+func Table(name string) SQLQueueOption {
+	return func(o *SQLQueueOptions) {
+		o.table = name
+	}
+}
+
+// Placeholders sets the placeholder style of the query arguments. Defaults
+// to sqlutil.Question.
+func Placeholders(p sqlutil.Placeholder) SQLQueueOption {
+	return func(o *SQLQueueOptions) {
+		o.placeholder = p
+	}
+}
+
+// SQLQueue is a Queue on top of database/sql, which is shared among the
+// processes using the same database. It supports PostgreSQL, MySQL and
+// SQLite.
+//
+// The table must be created in advance. For example, in SQLite:
+//
+//	CREATE TABLE tasks (
+//	    id         VARCHAR(36) PRIMARY KEY,
+//	    queue      VARCHAR(255) NOT NULL,
+//	    name       VARCHAR(255) NOT NULL,
+//	    payload    BLOB NOT NULL,
+//	    run_at     BIGINT NOT NULL, -- Unix time in milliseconds
+//	    attempts   INTEGER NOT NULL,
+//	    last_error TEXT NOT NULL
+//	);
+//	CREATE INDEX tasks_queue_run_at ON tasks (queue, run_at);
+//
+// Or in PostgreSQL, just use BYTEA instead of BLOB.
+type SQLQueue struct {
+	db *sql.DB
+
+	insertQuery string
+	selectQuery string
+	claimQuery  string
+	deleteQuery string
+	retryQuery  string
+}
+
+// NewSQLQueue creates a SQL queue on top of db.
+func NewSQLQueue(db *sql.DB, opts ...SQLQueueOption) *SQLQueue {
+	options := &SQLQueueOptions{
+		table:       "tasks",
+		placeholder: sqlutil.Question,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	p := options.placeholder
+	return &SQLQueue{
+		db: db,
+		insertQuery: fmt.Sprintf("INSERT INTO %s (id, queue, name, payload, run_at, attempts, last_error) VALUES (%s, %s, %s, %s, %s, %s, %s)",
+			options.table, p(1), p(2), p(3), p(4), p(5), p(6), p(7)),
+		selectQuery: fmt.Sprintf("SELECT id, name, payload, run_at, attempts, last_error FROM %s WHERE queue = %s AND run_at <= %s ORDER BY run_at LIMIT 1",
+			options.table, p(1), p(2)),
+		claimQuery: fmt.Sprintf("UPDATE %s SET run_at = %s, attempts = attempts + 1 WHERE id = %s AND run_at = %s AND attempts = %s",
+			options.table, p(1), p(2), p(3), p(4)),
+		deleteQuery: fmt.Sprintf("DELETE FROM %s WHERE id = %s AND attempts = %s",
+			options.table, p(1), p(2)),
+		retryQuery: fmt.Sprintf("UPDATE %s SET run_at = %s, last_error = %s WHERE id = %s AND attempts = %s",
+			options.table, p(1), p(2), p(3), p(4)),
+	}
+}
+
+// Enqueue implements Queue.
+func (q *SQLQueue) Enqueue(ctx context.Context, task *Task) error {
+	id := task.ID
+	if id == "" {
+		id = uuidutil.New()
+	}
+	runAt := task.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+
+	_, err := q.db.ExecContext(ctx, q.insertQuery,
+		id, task.Queue, task.Name, task.Payload, runAt.UnixMilli(), task.Attempts, task.LastError)
+	return err
+}
+
+// Dequeue implements Queue.
+func (q *SQLQueue) Dequeue(ctx context.Context, queue string, lease time.Duration) (*Task, error) {
+	for {
+		now := time.Now()
+
+		task := &Task{Queue: queue}
+		var runAt int64
+		err := q.db.QueryRowContext(ctx, q.selectQuery, queue, now.UnixMilli()).Scan(
+			&task.ID, &task.Name, &task.Payload, &runAt, &task.Attempts, &task.LastError)
+		switch err {
+		case nil:
+		case sql.ErrNoRows:
+			return nil, ErrNoTask
+		default:
+			return nil, err
+		}
+
+		// Claim the task optimistically, which fails if the task has been
+		// claimed by another worker in the meantime.
+		leaseUntil := now.Add(lease)
+		result, err := q.db.ExecContext(ctx, q.claimQuery,
+			leaseUntil.UnixMilli(), task.ID, runAt, task.Attempts)
+		if err != nil {
+			return nil, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			task.RunAt = time.UnixMilli(leaseUntil.UnixMilli())
+			task.Attempts++
+			return task, nil
+		}
+	}
+}
+
+// Delete implements Queue.
+func (q *SQLQueue) Delete(ctx context.Context, task *Task) error {
+	result, err := q.db.ExecContext(ctx, q.deleteQuery, task.ID, task.Attempts)
+	return q.checkLease(result, err, task)
+}
+
+// Retry implements Queue.
+func (q *SQLQueue) Retry(ctx context.Context, task *Task, runAt time.Time, lastErr string) error {
+	result, err := q.db.ExecContext(ctx, q.retryQuery, runAt.UnixMilli(), lastErr, task.ID, task.Attempts)
+	return q.checkLease(result, err, task)
+}
+
+// checkLease checks the result of a query fenced by the attempts of task,
+// which affects no rows if the lease of task has been lost.
+func (q *SQLQueue) checkLease(result sql.Result, err error, task *Task) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return leaseLost(task)
+	}
+	return nil
+}
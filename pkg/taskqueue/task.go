@@ -0,0 +1,145 @@
+// Package taskqueue provides task queues, which execute tasks (e.g. "run
+// this method in 10 minutes with these args") asynchronously by workers,
+// with retries on failure.
+//
+// A task is added into a queue by Enqueue, at the time carried by the
+// enqueuing context (see WithDelay and WithRunAt), and is then executed by
+// a Worker of the queue, once it is due.
+package taskqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/uuidutil"
+)
+
+var (
+	ErrNoTask      = errors.New("taskqueue: no task")
+	ErrUnknownTask = errors.New("taskqueue: unknown task")
+	ErrLeaseLost   = errors.New("taskqueue: lease lost")
+)
+
+// Task is a unit of work in a queue.
+type Task struct {
+	ID      string
+	Queue   string
+	Name    string // The name of the task, by which the handler is chosen.
+	Payload []byte // The arguments of the task, typically in JSON.
+
+	// RunAt is the time, since which the task is available to workers.
+	RunAt time.Time
+
+	// Attempts is the number of attempts made so far, including the
+	// current one (if the task is being executed).
+	Attempts int
+
+	// LastError is the error message of the last failed attempt, if any.
+	LastError string
+}
+
+// Queue is the backend of task queues.
+type Queue interface {
+	// Enqueue adds task into the queue named task.Queue.
+	Enqueue(ctx context.Context, task *Task) error
+
+	// Dequeue claims the earliest due task from the queue named queue, and
+	// returns ErrNoTask if there are no due tasks.
+	//
+	// The claimed task is leased for the duration lease, during which it's
+	// invisible to other workers. Unless it's deleted or retried, the task
+	// will be available again after the lease expires (e.g. if the worker
+	// crashes), thus the execution is at-least-once.
+	Dequeue(ctx context.Context, queue string, lease time.Duration) (*Task, error)
+
+	// Delete removes the task, typically after it has been executed.
+	//
+	// The task is fenced by its attempts: if the task has been claimed by
+	// another worker since the lease expired, Delete will leave it untouched
+	// and return an error wrapping ErrLeaseLost.
+	Delete(ctx context.Context, task *Task) error
+
+	// Retry makes the task available again at runAt, along with the error
+	// message of the failed attempt.
+	//
+	// Like Delete, Retry returns an error wrapping ErrLeaseLost if the task
+	// has been claimed by another worker since the lease expired.
+	Retry(ctx context.Context, task *Task, runAt time.Time, lastErr string) error
+}
+
+type contextKeyRunAt struct{}
+
+// WithRunAt returns a copy of ctx, which indicates that the task enqueued
+// within it should be executed at t.
+func WithRunAt(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, contextKeyRunAt{}, t)
+}
+
+// WithDelay returns a copy of ctx, which indicates that the task enqueued
+// within it should be executed after the duration d.
+func WithDelay(ctx context.Context, d time.Duration) context.Context {
+	return WithRunAt(ctx, time.Now().Add(d))
+}
+
+// RunAtFromContext returns the time carried by ctx, at which the task should
+// be executed. It returns the current time if ctx carries no time.
+func RunAtFromContext(ctx context.Context) time.Time {
+	if t, ok := ctx.Value(contextKeyRunAt{}).(time.Time); ok {
+		return t
+	}
+	return time.Now()
+}
+
+// Enqueue adds a task named name, with the arguments payload, into the queue
+// named queue of q. The task will be executed at the time carried by ctx
+// (see WithDelay and WithRunAt), or as soon as possible otherwise.
+func Enqueue(ctx context.Context, q Queue, queue, name string, payload []byte) error {
+	return q.Enqueue(ctx, &Task{
+		ID:      uuidutil.New(),
+		Queue:   queue,
+		Name:    name,
+		Payload: payload,
+		RunAt:   RunAtFromContext(ctx),
+	})
+}
+
+// Handler executes tasks.
+type Handler interface {
+	Handle(ctx context.Context, task *Task) error
+}
+
+// HandlerFunc is an adapter to allow the use of ordinary functions as Handlers.
+type HandlerFunc func(ctx context.Context, task *Task) error
+
+// Handle implements Handler.
+func (f HandlerFunc) Handle(ctx context.Context, task *Task) error {
+	return f(ctx, task)
+}
+
+// HandlerSet dispatches tasks to the handlers by their names.
+type HandlerSet struct {
+	set map[string]Handler
+}
+
+func NewHandlerSet() *HandlerSet {
+	return &HandlerSet{set: make(map[string]Handler)}
+}
+
+func (hs *HandlerSet) Add(name string, handler Handler) {
+	hs.set[name] = handler
+}
+
+// Handle implements Handler.
+func (hs *HandlerSet) Handle(ctx context.Context, task *Task) error {
+	h, ok := hs.set[task.Name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownTask, task.Name)
+	}
+	return h.Handle(ctx, task)
+}
+
+func leaseLost(task *Task) error {
+	return fmt.Errorf("%w: task %s (attempt %d)", ErrLeaseLost, task.ID, task.Attempts)
+}
@@ -0,0 +1,237 @@
+package taskqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/werror/gcode"
+)
+
+type WorkerOptions struct {
+	concurrency    int
+	pollInterval   time.Duration
+	lease          time.Duration
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	retryable      func(err error) bool
+	errorHandler   func(ctx context.Context, task *Task, err error)
+}
+
+// WorkerOption sets an optional parameter for WorkerOptions.
+type WorkerOption func(*WorkerOptions)
+
+// Concurrency sets the maximum number of tasks executed concurrently.
+// Defaults to 1.
+func Concurrency(n int) WorkerOption {
+	return func(o *WorkerOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// PollInterval sets the interval, at which the worker polls the queue when
+// there are no due tasks. Defaults to 1s.
+func PollInterval(d time.Duration) WorkerOption {
+	return func(o *WorkerOptions) {
+		if d > 0 {
+			o.pollInterval = d
+		}
+	}
+}
+
+// Lease sets the duration, for which a task is leased to the worker (see
+// Queue.Dequeue), which must be long enough for the task to finish. Defaults
+// to 1m.
+//
+// The timeout of each attempt is strictly shorter than the lease (see
+// attemptTimeout), so that the task can be deleted or retried before the
+// lease expires.
+func Lease(d time.Duration) WorkerOption {
+	return func(o *WorkerOptions) {
+		if d > 0 {
+			o.lease = d
+		}
+	}
+}
+
+// MaxAttempts sets the maximum number of attempts (including the first one)
+// to execute a task. Defaults to 3.
+func MaxAttempts(n int) WorkerOption {
+	return func(o *WorkerOptions) {
+		if n > 0 {
+			o.maxAttempts = n
+		}
+	}
+}
+
+// Backoff sets the exponential backoff between attempts, which starts from
+// initial and doubles after each attempt, up to max. Defaults to starting
+// from 1s, up to 1h.
+func Backoff(initial, max time.Duration) WorkerOption {
+	return func(o *WorkerOptions) {
+		o.initialBackoff = initial
+		o.maxBackoff = max
+	}
+}
+
+// Retryable sets the function to determine whether to retry on an error.
+// Defaults to gcode.IsRetryable.
+func Retryable(f func(err error) bool) WorkerOption {
+	return func(o *WorkerOptions) {
+		o.retryable = f
+	}
+}
+
+// ErrorHandler sets the handler for the tasks that finally failed (i.e. after
+// all attempts, or on a non-retryable error), which have been removed from
+// the queue, and for errors occurred when accessing the queue (in which case
+// task is nil). The errors are ignored by default.
+func ErrorHandler(h func(ctx context.Context, task *Task, err error)) WorkerOption {
+	return func(o *WorkerOptions) {
+		o.errorHandler = h
+	}
+}
+
+// Worker executes the tasks in a queue.
+type Worker struct {
+	q     Queue
+	queue string
+	h     Handler
+	opts  *WorkerOptions
+}
+
+// NewWorker creates a worker, which executes the tasks in the queue named
+// queue of q, by using h.
+func NewWorker(q Queue, queue string, h Handler, opts ...WorkerOption) *Worker {
+	options := &WorkerOptions{
+		concurrency:    1,
+		pollInterval:   time.Second,
+		lease:          time.Minute,
+		maxAttempts:    3,
+		initialBackoff: time.Second,
+		maxBackoff:     time.Hour,
+		retryable:      gcode.IsRetryable,
+		errorHandler:   func(ctx context.Context, task *Task, err error) {},
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	return &Worker{
+		q:     q,
+		queue: queue,
+		h:     h,
+		opts:  options,
+	}
+}
+
+// Run executes the due tasks continuously, until ctx is done. The tasks
+// being executed will be waited for before Run returns.
+func (w *Worker) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	sem := make(chan struct{}, w.opts.concurrency)
+	for {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		task, err := w.q.Dequeue(ctx, w.queue, w.opts.lease)
+		if err != nil {
+			<-sem
+			if !errors.Is(err, ErrNoTask) && ctx.Err() == nil {
+				w.opts.errorHandler(ctx, nil, err)
+			}
+
+			select {
+			case <-time.After(w.opts.pollInterval):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			// Finish the task even if ctx is done.
+			w.execute(context.Background(), task)
+		}()
+	}
+}
+
+// Work executes one due task, if any, and reports whether a task has been
+// executed. It's useful for executing tasks manually (e.g. in tests).
+func (w *Worker) Work(ctx context.Context) (bool, error) {
+	task, err := w.q.Dequeue(ctx, w.queue, w.opts.lease)
+	switch {
+	case errors.Is(err, ErrNoTask):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+
+	w.execute(ctx, task)
+	return true, nil
+}
+
+// execute executes task, and then deletes or retries it according to the
+// result.
+func (w *Worker) execute(ctx context.Context, task *Task) {
+	attemptCtx, cancel := context.WithTimeout(ctx, w.attemptTimeout())
+	err := w.h.Handle(attemptCtx, task)
+	cancel()
+
+	if err == nil {
+		if err := w.q.Delete(ctx, task); err != nil {
+			w.opts.errorHandler(ctx, nil, err)
+		}
+		return
+	}
+
+	if task.Attempts >= w.opts.maxAttempts || !w.opts.retryable(err) {
+		if dErr := w.q.Delete(ctx, task); dErr != nil {
+			w.opts.errorHandler(ctx, nil, dErr)
+			if errors.Is(dErr, ErrLeaseLost) {
+				// The task is now owned by another worker.
+				return
+			}
+		}
+		w.opts.errorHandler(ctx, task, fmt.Errorf("taskqueue: task %s (%s) failed after %d attempt(s): %w", task.ID, task.Name, task.Attempts, err))
+		return
+	}
+
+	runAt := time.Now().Add(w.backoff(task.Attempts))
+	if rErr := w.q.Retry(ctx, task, runAt, err.Error()); rErr != nil {
+		w.opts.errorHandler(ctx, nil, rErr)
+	}
+}
+
+// attemptTimeout returns the timeout of each attempt, which reserves a tenth
+// of the lease for deleting or retrying the task.
+func (w *Worker) attemptTimeout() time.Duration {
+	return w.opts.lease - w.opts.lease/10
+}
+
+// backoff returns the backoff after the given number of attempts.
+func (w *Worker) backoff(attempts int) time.Duration {
+	d := w.opts.initialBackoff
+	for i := 1; i < attempts && d < w.opts.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > w.opts.maxBackoff {
+		d = w.opts.maxBackoff
+	}
+	return d
+}
@@ -0,0 +1,186 @@
+package taskqueue_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RussellLuo/kun/pkg/taskqueue"
+	"github.com/RussellLuo/kun/pkg/werror"
+	"github.com/RussellLuo/kun/pkg/werror/gcode"
+)
+
+func TestWorker_Work(t *testing.T) {
+	ctx := context.Background()
+	backoff := 20 * time.Millisecond
+
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		wantAttempts int
+		wantErrStr   string
+	}{
+		{
+			name:         "success",
+			wantAttempts: 1,
+		},
+		{
+			name:         "success after retries",
+			failures:     2,
+			err:          errors.New("oops"),
+			wantAttempts: 3,
+		},
+		{
+			name:         "failure after all attempts",
+			failures:     3,
+			err:          errors.New("oops"),
+			wantAttempts: 3,
+			wantErrStr:   "failed after 3 attempt(s): oops",
+		},
+		{
+			name:         "non-retryable failure",
+			failures:     1,
+			err:          werror.Wrap(gcode.ErrInvalidArgument, errors.New("bad args")),
+			wantAttempts: 1,
+			wantErrStr:   "failed after 1 attempt(s): bad args",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := taskqueue.NewMemoryQueue()
+			if err := taskqueue.Enqueue(ctx, q, "q", "greet", []byte(`"hi"`)); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			attempts := 0
+			h := taskqueue.NewHandlerSet()
+			h.Add("greet", taskqueue.HandlerFunc(func(ctx context.Context, task *taskqueue.Task) error {
+				attempts++
+				if attempts <= tt.failures {
+					return tt.err
+				}
+				return nil
+			}))
+
+			var gotErr error
+			w := taskqueue.NewWorker(q, "q", h,
+				taskqueue.Backoff(backoff, time.Second),
+				taskqueue.ErrorHandler(func(ctx context.Context, task *taskqueue.Task, err error) {
+					gotErr = err
+				}),
+			)
+
+			for i := 0; i < 5; i++ {
+				if _, err := w.Work(ctx); err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				time.Sleep(2 * backoff)
+			}
+
+			if attempts != tt.wantAttempts {
+				t.Fatalf("Attempts: got (%d), want (%d)", attempts, tt.wantAttempts)
+			}
+			if (gotErr == nil && tt.wantErrStr != "") || (gotErr != nil && !strings.HasSuffix(gotErr.Error(), tt.wantErrStr)) {
+				t.Fatalf("Err: got (%#v), want (%#v)", gotErr, tt.wantErrStr)
+			}
+			if n := q.Len("q"); n != 0 {
+				t.Fatalf("Len: got (%d), want (0)", n)
+			}
+		})
+	}
+}
+
+func TestWorker_Run(t *testing.T) {
+	q := taskqueue.NewMemoryQueue()
+
+	var mu sync.Mutex
+	var names []string
+	done := make(chan struct{})
+	h := taskqueue.HandlerFunc(func(ctx context.Context, task *taskqueue.Task) error {
+		mu.Lock()
+		defer mu.Unlock()
+		names = append(names, task.Name)
+		if len(names) == 2 {
+			close(done)
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := taskqueue.NewWorker(q, "q", h, taskqueue.PollInterval(10*time.Millisecond))
+	errC := make(chan error, 1)
+	go func() {
+		errC <- w.Run(ctx)
+	}()
+
+	_ = taskqueue.Enqueue(taskqueue.WithDelay(context.Background(), 50*time.Millisecond), q, "q", "b", nil)
+	_ = taskqueue.Enqueue(context.Background(), q, "q", "a", nil)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the tasks")
+	}
+
+	cancel()
+	if err := <-errC; err != context.Canceled {
+		t.Fatalf("Err: got (%v), want (%v)", err, context.Canceled)
+	}
+
+	if strings.Join(names, ",") != "a,b" {
+		t.Fatalf("Names: got (%v), want ([a b])", names)
+	}
+}
+
+func TestWorker_Lease(t *testing.T) {
+	ctx := context.Background()
+	lease := 50 * time.Millisecond
+
+	q := taskqueue.NewMemoryQueue()
+	if err := taskqueue.Enqueue(ctx, q, "q", "slow", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var deadline time.Time
+	h := taskqueue.HandlerFunc(func(ctx context.Context, task *taskqueue.Task) error {
+		deadline, _ = ctx.Deadline()
+		// Ignore the timeout, and let the lease expire.
+		time.Sleep(lease)
+		// The expired task is claimed by another worker.
+		if _, err := q.Dequeue(context.Background(), "q", time.Hour); err != nil {
+			t.Errorf("err: %v", err)
+		}
+		return nil
+	})
+
+	var gotErr error
+	w := taskqueue.NewWorker(q, "q", h,
+		taskqueue.Lease(lease),
+		taskqueue.ErrorHandler(func(ctx context.Context, task *taskqueue.Task, err error) {
+			gotErr = err
+		}),
+	)
+
+	start := time.Now()
+	if _, err := w.Work(ctx); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The attempt times out before the lease expires.
+	if !deadline.Before(start.Add(lease)) {
+		t.Fatalf("Deadline: got (%v), want before (%v)", deadline, start.Add(lease))
+	}
+
+	// The task, which is now owned by another worker, is left untouched.
+	if !errors.Is(gotErr, taskqueue.ErrLeaseLost) {
+		t.Fatalf("Err: got (%v), want (%v)", gotErr, taskqueue.ErrLeaseLost)
+	}
+	if n := q.Len("q"); n != 1 {
+		t.Fatalf("Len: got (%d), want (1)", n)
+	}
+}
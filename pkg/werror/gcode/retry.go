@@ -0,0 +1,26 @@
+package gcode
+
+import (
+	"context"
+	"errors"
+)
+
+// IsRetryable reports whether err is retryable, according to its error code
+// (see ToCodeMessage). Client-side errors (e.g. ErrInvalidArgument) and the
+// cancellation of the context are not retryable, since retrying would fail
+// again anyway.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	code, _ := ToCodeMessage(err)
+	switch code {
+	case ErrInvalidArgument.Error(), ErrFailedPrecondition.Error(), ErrOutOfRange.Error(),
+		ErrUnauthenticated.Error(), ErrPermissionDenied.Error(), ErrNotFound.Error(),
+		ErrAlreadyExists.Error(), ErrNotImplemented.Error():
+		return false
+	default:
+		return true
+	}
+}